@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ai/brewol/internal/export"
+)
+
+// runExport implements `brewol export [flags] [branch]`: it squashes an agent
+// branch's checkpoints into one commit per completed task and writes them to
+// a review branch or as a patch series
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	workspace := fs.String("workspace", "", "Workspace root directory (default: current directory)")
+	fs.StringVar(workspace, "w", "", "Workspace root directory (shorthand)")
+	base := fs.String("base", "", "Commit the agent branch started from (default: where it forked from other branches)")
+	branch := fs.String("branch", "", "Review branch to create (default: review/<agent branch name>)")
+	patches := fs.String("patches", "", "Write a format-patch series and SUMMARY.md to this directory instead of a branch")
+	force := fs.Bool("force", false, "Replace the review branch if it exists")
+	dryRun := fs.Bool("dry-run", false, "Show how commits would be grouped without writing anything")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage:
+  brewol export [flags] [branch]     Squash an agent branch (default: current) for review
+
+Checkpoints are grouped by the tasks they complete and squashed into one
+commit per group, written to a review branch or as a patch series.
+
+Flags:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// Allow flags after the branch
+	var agentBranch string
+	if fs.NArg() > 0 {
+		agentBranch = fs.Arg(0)
+		fs.Parse(fs.Args()[1:])
+	}
+
+	root, err := resolveWorkspace(*workspace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	series, err := export.Plan(root, agentBranch, *base)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *dryRun {
+		fmt.Print(series.Summary())
+		return 0
+	}
+	if err := series.Squash(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *patches != "" {
+		paths, err := series.WritePatches(*patches)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		for _, path := range paths {
+			fmt.Println(path)
+		}
+		return 0
+	}
+
+	name := *branch
+	if name == "" {
+		name = "review/" + strings.TrimPrefix(series.Branch, "agent/")
+	}
+	if err := series.WriteBranch(name, *force); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	commits := 0
+	for _, g := range series.Groups {
+		commits += len(g.Commits)
+	}
+	fmt.Printf("%s: %d commits squashed into %d\n", name, commits, len(series.Groups))
+	return 0
+}
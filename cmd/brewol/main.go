@@ -11,6 +11,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/ai/brewol/internal/config"
 	"github.com/ai/brewol/internal/engine"
 	"github.com/ai/brewol/internal/tui"
 )
@@ -23,13 +24,29 @@ var (
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "sessions":
+			os.Exit(runSessions(os.Args[2:]))
+		case "report":
+			os.Exit(runReport(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		}
+	}
+
 	var (
-		workspace   string
-		goal        string
-		model       string
-		showVersion bool
-		testMode    bool
-		maxCycles   int
+		workspace    string
+		goal         string
+		model        string
+		summaryModel string
+		embedModel   string
+		showVersion  bool
+		testMode     bool
+		maxCycles    int
+		coverage     bool
+		resume       resumeFlag
 	)
 
 	flag.StringVar(&workspace, "workspace", "", "Workspace root directory (default: current directory)")
@@ -38,10 +55,14 @@ func main() {
 	flag.StringVar(&goal, "g", "", "Initial goal for the agent (shorthand)")
 	flag.StringVar(&model, "model", "", "Ollama model to use (overrides OLLAMA_MODEL)")
 	flag.StringVar(&model, "m", "", "Ollama model to use (shorthand)")
+	flag.StringVar(&summaryModel, "summary-model", "", "Ollama model for memory summarization (default: main model)")
+	flag.StringVar(&embedModel, "embed-model", "", "Ollama embedding model for code retrieval (overrides OLLAMA_EMBED_MODEL)")
 	flag.BoolVar(&showVersion, "version", false, "Show version information")
 	flag.BoolVar(&showVersion, "v", false, "Show version information (shorthand)")
 	flag.BoolVar(&testMode, "test-mode", false, "Enable test mode (exit after max-cycles)")
 	flag.IntVar(&maxCycles, "max-cycles", 1, "Maximum cycles to run in test mode (default: 1)")
+	flag.BoolVar(&coverage, "coverage", false, "Start in coverage mode: write tests for the least covered code")
+	flag.Var(&resume, "resume", "Resume a previous session by ID (default: latest)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, `brewol - Autonomous Coding Agent
@@ -51,16 +72,26 @@ It never stops (only ESC ESC exits) and never asks for permission.
 
 Usage:
   brewol [flags]
+  brewol --resume [id]          Resume a previous session (default: latest)
+  brewol sessions [id|latest]   List or replay past sessions
+  brewol report [id|latest]     Write an HTML and Markdown report of a session
+  brewol export [branch]        Squash an agent branch into reviewable commits
 
 Flags:
 `)
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, `
+Configuration:
+  ~/.config/brewol/config.toml and .brewol/config.toml (repo) set models,
+  think mode, budgets, compaction, verification commands and tools.
+  Use /config in the TUI to see effective values and their sources.
+
 Environment Variables:
   OLLAMA_HOST       Ollama API base URL (default: http://localhost:11434)
   OLLAMA_MODEL      Default model to use
   OLLAMA_API_KEY    API key for cloud Ollama endpoint
   OLLAMA_KEEP_ALIVE Model keep-alive duration
+  OLLAMA_EMBED_MODEL Embedding model for code retrieval
 
 Keybindings:
   ESC               Cancel current operation
@@ -77,6 +108,7 @@ Commands (via Ctrl+K):
   /models           Show model picker
   /status           Show current status
   /checkpoint       Create a checkpoint
+  /coverage on|off  Toggle coverage-guided test writing
   /rollback         Rollback to last checkpoint
   /speed <n>        Set throttle (0 = no throttle)
 
@@ -85,6 +117,8 @@ Examples:
   brewol -w /path/to/project          Start in specified directory
   brewol -g "Fix all failing tests"   Start with a specific goal
   brewol -m codellama                 Use codellama model
+  brewol --resume                     Continue the most recent session
+  brewol --coverage                   Write tests where coverage is lowest
 
 For more information: https://github.com/ai/brewol
 `)
@@ -118,14 +152,41 @@ For more information: https://github.com/ai/brewol
 		os.Exit(1)
 	}
 
-	// Set model from flag if provided
-	if model != "" {
-		os.Setenv("OLLAMA_MODEL", model)
+	// Resolve the session to resume before a new one is created
+	resumeDir := ""
+	if resume.set {
+		id := resume.id
+		if id == "" && flag.NArg() > 0 {
+			id = flag.Arg(0)
+		}
+		if id == "" {
+			id = "latest"
+		}
+		resumeDir, err = resolveSessionDir(workspace, id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Load layered configuration; flags take precedence over files and environment
+	settings, err := config.Load(workspace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	for key, value := range map[string]string{"model.name": model, "model.summary": summaryModel, "model.embed": embedModel} {
+		if value != "" {
+			settings.Set(key, value, config.SourceFlag)
+		}
+	}
+	for _, warning := range settings.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
 	}
 
 	// Check if model is set
-	if os.Getenv("OLLAMA_MODEL") == "" {
-		fmt.Fprintf(os.Stderr, "Warning: No model specified. Use -m flag or set OLLAMA_MODEL environment variable.\n")
+	if settings.Model.Name == "" {
+		fmt.Fprintf(os.Stderr, "Warning: No model specified. Use -m flag, set OLLAMA_MODEL or model.name in config.toml.\n")
 		fmt.Fprintf(os.Stderr, "         Will attempt to use first available model from Ollama.\n\n")
 	}
 
@@ -133,8 +194,11 @@ For more information: https://github.com/ai/brewol
 	eng, err := engine.NewEngine(engine.Config{
 		WorkspaceRoot: workspace,
 		Goal:          goal,
+		Settings:      settings,
 		TestMode:      testMode,
 		MaxCycles:     maxCycles,
+		ResumeDir:     resumeDir,
+		Coverage:      coverage,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to create engine: %v\n", err)
@@ -192,3 +256,22 @@ For more information: https://github.com/ai/brewol
 
 	fmt.Println("Session logs saved to:", eng.Session().Path())
 }
+
+// resumeFlag is the --resume flag, which takes an optional session ID
+type resumeFlag struct {
+	set bool
+	id  string
+}
+
+func (f *resumeFlag) String() string { return f.id }
+
+func (f *resumeFlag) Set(value string) error {
+	f.set = true
+	if value != "true" {
+		f.id = value
+	}
+	return nil
+}
+
+// IsBoolFlag lets --resume be given without a value
+func (f *resumeFlag) IsBoolFlag() bool { return true }
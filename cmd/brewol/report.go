@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ai/brewol/internal/report"
+)
+
+// runReport implements `brewol report [flags] <session-id|latest>`: it renders
+// a session's logs as self-contained HTML and Markdown files
+func runReport(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	workspace := fs.String("workspace", "", "Workspace root directory (default: current directory)")
+	fs.StringVar(workspace, "w", "", "Workspace root directory (shorthand)")
+	format := fs.String("format", "all", "Report format: html, md or all")
+	output := fs.String("o", "", "Output directory (default: the session's log directory)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage:
+  brewol report [flags] <id|latest>     Write report.html and report.md for a session
+
+Flags:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// Allow flags after the session ID
+	id := "latest"
+	if fs.NArg() > 0 {
+		id = fs.Arg(0)
+		fs.Parse(fs.Args()[1:])
+	}
+
+	var writeHTML, writeMD bool
+	switch *format {
+	case "html":
+		writeHTML = true
+	case "md", "markdown":
+		writeMD = true
+	case "all":
+		writeHTML, writeMD = true, true
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (use html, md or all)\n", *format)
+		return 2
+	}
+
+	root, err := resolveWorkspace(*workspace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	dir, err := resolveSessionDir(root, id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	r, err := report.Build(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	outDir := *output
+	if outDir == "" {
+		outDir = dir
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to create output directory: %v\n", err)
+		return 1
+	}
+
+	files := map[string]string{}
+	if writeHTML {
+		files["report.html"] = r.HTML()
+	}
+	if writeMD {
+		files["report.md"] = r.Markdown()
+	}
+	for _, name := range []string{"report.html", "report.md"} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		path := filepath.Join(outDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to write report: %v\n", err)
+			return 1
+		}
+		fmt.Println(path)
+	}
+	return 0
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ai/brewol/internal/display"
+	"github.com/ai/brewol/internal/logs"
+)
+
+// runSessions implements `brewol sessions [flags] [session-id]`: without an
+// ID it lists past sessions, with one it replays the session's logs
+func runSessions(args []string) int {
+	fs := flag.NewFlagSet("sessions", flag.ExitOnError)
+	workspace := fs.String("workspace", "", "Workspace root directory (default: current directory)")
+	fs.StringVar(workspace, "w", "", "Workspace root directory (shorthand)")
+	search := fs.String("search", "", "Only replay entries containing this text")
+	full := fs.Bool("full", false, "Replay full entry content instead of one line per entry")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage:
+  brewol sessions [flags]                 List past sessions
+  brewol sessions [flags] <id|latest>     Replay a session's logs
+
+Flags:
+`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// Allow flags after the session ID
+	var id string
+	if fs.NArg() > 0 {
+		id = fs.Arg(0)
+		fs.Parse(fs.Args()[1:])
+	}
+
+	root, err := resolveWorkspace(*workspace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if id == "" {
+		return listSessions(root)
+	}
+
+	dir, err := resolveSessionDir(root, id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return replaySession(dir, *search, *full)
+}
+
+// listSessions prints a table of past sessions, newest first
+func listSessions(root string) int {
+	summaries, err := logs.ListSessionSummaries(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to list sessions: %v\n", err)
+		return 1
+	}
+	if len(summaries) == 0 {
+		fmt.Println("No sessions found in", logs.GetDefaultLogDir(root))
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tDURATION\tMODEL\tCYCLES\tCHECKPOINTS\tTOOLS\tOUTCOME\tGOAL")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d/%d ok\t%s\t%s\n",
+			s.ID, display.Duration(s.Duration), display.OrDash(s.Model), s.Cycles, s.Checkpoints,
+			s.ToolCalls-s.Failures, s.ToolCalls, s.Outcome, display.Truncate(s.Goal, 50))
+	}
+	w.Flush()
+	return 0
+}
+
+// replaySession prints a session's interleaved timeline
+func replaySession(dir, search string, full bool) int {
+	summary, err := logs.SummarizeSession(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read session: %v\n", err)
+		return 1
+	}
+	timeline, err := logs.ReadTimeline(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read session: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Session %s (%s, %s)\n", summary.ID, display.Duration(summary.Duration), summary.Outcome)
+	if summary.Goal != "" {
+		fmt.Printf("Goal: %s\n", summary.Goal)
+	}
+	fmt.Println()
+
+	indices := make([]int, 0, len(timeline))
+	if search != "" {
+		indices = logs.SearchTimeline(timeline, search)
+	} else {
+		for i := range timeline {
+			indices = append(indices, i)
+		}
+	}
+
+	for _, i := range indices {
+		t := timeline[i]
+		offset := display.Duration(t.Timestamp.Sub(summary.StartTime))
+		fmt.Printf("[%s] %-10s %s\n", offset, t.Source, display.Truncate(t.Title(), 120))
+		if full && strings.TrimSpace(t.Content) != "" {
+			for _, line := range strings.Split(strings.TrimRight(t.Content, "\n"), "\n") {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+	}
+
+	if search != "" {
+		fmt.Printf("\n%d of %d entries match %q\n", len(indices), len(timeline), search)
+	}
+	return 0
+}
+
+// resolveWorkspace returns the workspace directory, defaulting to the
+// current directory
+func resolveWorkspace(workspace string) (string, error) {
+	if workspace == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		workspace = wd
+	}
+
+	info, err := os.Stat(workspace)
+	if err != nil {
+		return "", fmt.Errorf("workspace not found: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("workspace is not a directory: %s", workspace)
+	}
+	return workspace, nil
+}
+
+// resolveSessionDir returns the log directory of a session ID, where
+// "latest" is the most recent session
+func resolveSessionDir(root, id string) (string, error) {
+	if id == "latest" {
+		ids, err := logs.ListSessions(root)
+		if err != nil {
+			return "", fmt.Errorf("failed to list sessions: %w", err)
+		}
+		if len(ids) == 0 {
+			return "", fmt.Errorf("no sessions found in %s", logs.GetDefaultLogDir(root))
+		}
+		id = ids[len(ids)-1]
+	}
+
+	dir := filepath.Join(logs.GetDefaultLogDir(root), id)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("session not found: %s", id)
+	}
+	return dir, nil
+}
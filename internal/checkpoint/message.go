@@ -0,0 +1,177 @@
+// Package checkpoint defines the layout of checkpoint commit messages, which
+// the engine writes and export reads back.
+package checkpoint
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxSubjectLength is the longest subject line written
+	MaxSubjectLength = 72
+	// Tag marks checkpoint subjects that don't follow Conventional Commits
+	Tag = "[brewol] "
+)
+
+// ConventionalPrefix matches a Conventional Commits prefix, e.g. "fix(api): "
+var ConventionalPrefix = regexp.MustCompile(`^(?:feat|fix|docs|style|refactor|perf|test|build|ci|chore|revert)(?:\([^)]+\))?!?: `)
+
+// Message is a commit message in checkpoint layout:
+//
+//	<prefix><subject>
+//
+//	<summary>
+//
+//	Files:
+//	- <file>
+//
+//	Tasks completed:
+//	- <task>
+//
+//	Cycle: <n>
+//	Objective: <objective>
+//	Squashed: <sha> <sha>
+//	Verification: <results>
+type Message struct {
+	Prefix       string // Conventional Commits prefix, e.g. "fix(api): "
+	Subject      string // Without the prefix or tag
+	Summary      string
+	Files        []string
+	Tasks        []string
+	Cycle        int
+	Objective    string
+	Squashed     []string
+	Verification string
+	Checkpoint   bool // Made by the engine: tagged, with Cycle and Objective trailers
+}
+
+// String formats the message
+func (m Message) String() string {
+	var sb strings.Builder
+	switch {
+	case m.Prefix != "":
+		sb.WriteString(m.Prefix + LowerFirst(m.Subject) + "\n")
+	case m.Checkpoint:
+		sb.WriteString(Tag + m.Subject + "\n")
+	default:
+		sb.WriteString(m.Subject + "\n")
+	}
+	if m.Summary != "" {
+		sb.WriteString("\n" + m.Summary + "\n")
+	}
+	for _, section := range []struct {
+		title string
+		items []string
+	}{
+		{"Files:", m.Files},
+		{"Tasks completed:", m.Tasks},
+	} {
+		if len(section.items) > 0 {
+			sb.WriteString("\n" + section.title + "\n")
+			for _, item := range section.items {
+				sb.WriteString("- " + item + "\n")
+			}
+		}
+	}
+
+	var trailers []string
+	if m.Checkpoint {
+		trailers = append(trailers, fmt.Sprintf("Cycle: %d", m.Cycle), "Objective: "+m.Objective)
+	}
+	if len(m.Squashed) > 0 {
+		trailers = append(trailers, "Squashed: "+strings.Join(m.Squashed, " "))
+	}
+	if m.Verification != "" {
+		trailers = append(trailers, "Verification: "+m.Verification)
+	}
+	if len(trailers) == 0 {
+		return strings.TrimRight(sb.String(), "\n")
+	}
+	return sb.String() + "\n" + strings.Join(trailers, "\n")
+}
+
+// Parse reads a commit message. Checkpoints are recognized by their tag or
+// their "Cycle:" trailer; the sections and trailers of other messages are
+// left in the summary.
+func Parse(message string) Message {
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	m := Message{Subject: strings.TrimSpace(lines[0])}
+	if strings.HasPrefix(m.Subject, Tag) {
+		m.Subject = strings.TrimPrefix(m.Subject, Tag)
+		m.Checkpoint = true
+	}
+	if prefix := ConventionalPrefix.FindString(m.Subject); prefix != "" {
+		m.Prefix = prefix
+		m.Subject = UpperFirst(strings.TrimPrefix(m.Subject, prefix))
+	}
+
+	section := "summary"
+	var summary []string
+	for _, line := range lines[1:] {
+		value, trailer := trailerValue(line)
+		switch {
+		case trailer == "Cycle":
+			m.Cycle, _ = strconv.Atoi(value)
+			m.Checkpoint = true
+			section = "trailers"
+		case trailer == "Objective":
+			m.Objective = value
+		case trailer == "Squashed":
+			m.Squashed = strings.Fields(value)
+		case trailer == "Verification":
+			m.Verification = value
+		case line == "Files:" || line == "Tasks completed:":
+			section = line
+		case section == "Files:" && strings.HasPrefix(line, "- "):
+			m.Files = append(m.Files, strings.TrimPrefix(line, "- "))
+		case section == "Tasks completed:" && strings.HasPrefix(line, "- "):
+			m.Tasks = append(m.Tasks, strings.TrimPrefix(line, "- "))
+		case section == "summary":
+			summary = append(summary, line)
+		}
+	}
+	m.Summary = strings.TrimSpace(strings.Join(summary, "\n"))
+	if !m.Checkpoint {
+		m.Files, m.Tasks = nil, nil // Only checkpoint messages have sections
+		m.Summary = strings.TrimSpace(strings.Join(lines[1:], "\n"))
+	}
+	return m
+}
+
+// trailerValue splits a "Key: value" trailer line
+func trailerValue(line string) (string, string) {
+	for _, key := range []string{"Cycle", "Objective", "Squashed", "Verification"} {
+		if strings.HasPrefix(line, key+": ") {
+			return strings.TrimPrefix(line, key+": "), key
+		}
+	}
+	return "", ""
+}
+
+// LowerFirst lowercases the first letter of a subject, unless it starts an
+// acronym or identifier such as "API" or "HTTPClient"
+func LowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	next, _ := utf8.DecodeRuneInString(s[size:])
+	if unicode.IsUpper(next) {
+		return s
+	}
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
+// UpperFirst capitalizes the first letter
+func UpperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
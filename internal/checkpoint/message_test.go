@@ -0,0 +1,62 @@
+package checkpoint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMessage_RoundTrip(t *testing.T) {
+	m := Message{
+		Prefix:       "fix(api): ",
+		Subject:      "Start listening in Serve",
+		Summary:      "Serve now calls listen.",
+		Files:        []string{"M internal/api/server.go (+3 -1): Serve"},
+		Tasks:        []string{"Fix failing test: TestServe"},
+		Cycle:        4,
+		Objective:    "Fix the server",
+		Verification: "test PASSED",
+		Checkpoint:   true,
+	}
+	want := `fix(api): start listening in Serve
+
+Serve now calls listen.
+
+Files:
+- M internal/api/server.go (+3 -1): Serve
+
+Tasks completed:
+- Fix failing test: TestServe
+
+Cycle: 4
+Objective: Fix the server
+Verification: test PASSED`
+	if got := m.String(); got != want {
+		t.Fatalf("unexpected message:\n%s", got)
+	}
+	if got := Parse(want); !reflect.DeepEqual(got, m) {
+		t.Errorf("expected the message to parse back, got %+v", got)
+	}
+
+	tagged := Message{Subject: "Manual checkpoint", Cycle: 1, Objective: "Serve", Checkpoint: true}
+	if got := tagged.String(); got != "[brewol] Manual checkpoint\n\nCycle: 1\nObjective: Serve" {
+		t.Errorf("unexpected tagged message:\n%s", got)
+	}
+	if got := Parse(tagged.String()); !reflect.DeepEqual(got, tagged) {
+		t.Errorf("expected the tagged message to parse back, got %+v", got)
+	}
+}
+
+func TestParse_OtherCommits(t *testing.T) {
+	m := Parse("docs: Add readme\n\nFiles:\n- notes\n")
+	if m.Checkpoint || m.Prefix != "docs: " || m.Subject != "Add readme" || m.Files != nil || m.Summary != "Files:\n- notes" {
+		t.Errorf("unexpected parse of a regular commit: %+v", m)
+	}
+}
+
+func TestLowerFirst(t *testing.T) {
+	for in, want := range map[string]string{"Add API": "add API", "API client": "API client", "HTTPClient": "HTTPClient", "": ""} {
+		if got := LowerFirst(in); got != want {
+			t.Errorf("LowerFirst(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
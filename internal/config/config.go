@@ -0,0 +1,540 @@
+// Package config loads brewol's layered configuration: built-in defaults,
+// the user config file, the repository config file, environment variables
+// and command-line flags, in increasing order of precedence.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ai/brewol/internal/repo"
+)
+
+// Sources of a setting
+const (
+	SourceDefault = "default"
+	SourceFlag    = "flag"
+)
+
+// ModelConfig selects the models used by the engine
+type ModelConfig struct {
+	Name    string // Main model (empty = first available)
+	Summary string // Model for memory summarization (empty = main model)
+	Embed   string // Model for code retrieval embeddings (empty = retrieval disabled)
+	Think   string // Think mode: auto, on, off, low, medium, high
+}
+
+// EngineConfig controls the autonomy loop
+type EngineConfig struct {
+	CycleInterval time.Duration // Pause between cycles
+	MaxErrors     int           // Consecutive errors before auto-pausing
+}
+
+// MemoryConfig controls working memory summarization
+type MemoryConfig struct {
+	UpdateInterval int           // Summarize every N cycles
+	SummaryTimeout time.Duration // Timeout for a single summarization call
+}
+
+// BudgetConfig controls the context budget
+type BudgetConfig struct {
+	NumCtx             int     // Context window size (0 = from the model)
+	HighWatermark      float64 // Usage ratio that triggers compaction
+	LowWatermark       float64 // Usage ratio to compact down to
+	ReserveOutput      int     // Tokens reserved for the response
+	MaxTranscriptTurns int     // Conversation turns kept in context
+}
+
+// CompactorConfig controls tool output compaction
+type CompactorConfig struct {
+	MaxToolOutputLines  int // Tool output longer than this is compacted
+	ToolOutputHeadLines int // Lines kept from the start of compacted output
+	ToolOutputTailLines int // Lines kept from the end of compacted output
+}
+
+// VerifyConfig overrides the detected verification commands. Steps, when
+// declared, replace detection entirely and take precedence over the
+// single-command overrides.
+type VerifyConfig struct {
+	Build            string
+	Test             string
+	Lint             string
+	Format           string
+	Steps            []repo.Step
+	Targeted         bool // Run tests affected by changes after each cycle
+	BeforeCheckpoint bool // Run the full verification before checkpoints
+	FlakyReruns      int  // Reruns of failing tests to detect flakiness
+	Baseline         bool // Verify everything at session start to tell new failures from old
+}
+
+// LintConfig controls the post-edit format and lint pipeline
+type LintConfig struct {
+	Enabled         bool // Format, fix and lint changed files after each cycle
+	Format          bool // Run the formatter on changed files
+	Fix             bool // Apply the linter's safe fixes to changed files
+	BlockCheckpoint bool // Whether lint failures block checkpoints
+}
+
+// TodoConfig controls the TODO/FIXME scanner
+type TodoConfig struct {
+	Scan   bool     // Queue TODO comments as tasks
+	Ignore []string // Paths not scanned ("dir/" or glob patterns)
+}
+
+// CoverageConfig controls coverage-guided test writing
+type CoverageConfig struct {
+	MaxTasks int // Under-tested units queued as tasks at a time
+}
+
+// CheckpointConfig controls checkpoint commit messages
+type CheckpointConfig struct {
+	Summary      bool   // Ask the summary model to describe the change
+	Conventional string // Conventional Commits subjects: auto (when the history uses them), always, never
+}
+
+// ToolsConfig controls tool execution
+type ToolsConfig struct {
+	ExecTimeout time.Duration // Default timeout for shell commands
+	Disabled    []string      // Tools that are not registered
+}
+
+// Config is the effective configuration
+type Config struct {
+	Model      ModelConfig
+	Engine     EngineConfig
+	Memory     MemoryConfig
+	Budget     BudgetConfig
+	Compactor  CompactorConfig
+	Verify     VerifyConfig
+	Lint       LintConfig
+	Todo       TodoConfig
+	Coverage   CoverageConfig
+	Checkpoint CheckpointConfig
+	Tools      ToolsConfig
+
+	sources  map[string]string
+	Warnings []string // Unknown keys and other non-fatal problems
+}
+
+// Setting is one effective value and where it came from
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		Model: ModelConfig{Think: "auto"},
+		Engine: EngineConfig{
+			CycleInterval: 2 * time.Second,
+			MaxErrors:     3,
+		},
+		Memory: MemoryConfig{
+			UpdateInterval: 5,
+			SummaryTimeout: 60 * time.Second,
+		},
+		Budget: BudgetConfig{
+			HighWatermark:      0.80,
+			LowWatermark:       0.60,
+			ReserveOutput:      2048,
+			MaxTranscriptTurns: 5,
+		},
+		Compactor: CompactorConfig{
+			MaxToolOutputLines:  20,
+			ToolOutputHeadLines: 10,
+			ToolOutputTailLines: 10,
+		},
+		Verify: VerifyConfig{
+			Targeted:         true,
+			BeforeCheckpoint: true,
+			FlakyReruns:      2,
+			Baseline:         true,
+		},
+		Lint: LintConfig{
+			Enabled: true,
+			Format:  true,
+			Fix:     true,
+		},
+		Todo: TodoConfig{
+			Scan: true,
+		},
+		Coverage: CoverageConfig{
+			MaxTasks: 10,
+		},
+		Checkpoint: CheckpointConfig{
+			Summary:      true,
+			Conventional: "auto",
+		},
+		Tools: ToolsConfig{
+			ExecTimeout: 120 * time.Second,
+		},
+		sources: make(map[string]string),
+	}
+}
+
+// UserPath returns the user config file path
+func UserPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "brewol", "config.toml")
+}
+
+// RepoPath returns the repository config file path
+func RepoPath(workspaceRoot string) string {
+	return filepath.Join(workspaceRoot, ".brewol", "config.toml")
+}
+
+// Load builds the effective configuration for a workspace from the defaults,
+// the user and repository config files and the environment. Missing files
+// are skipped; malformed files are an error.
+func Load(workspaceRoot string) (*Config, error) {
+	c := Default()
+	for _, path := range []string{UserPath(), RepoPath(workspaceRoot)} {
+		if path == "" {
+			continue
+		}
+		if err := c.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, env := range envVars {
+		if v := os.Getenv(env); v != "" {
+			if err := c.Set(key, v, "env "+env); err != nil {
+				c.Warnings = append(c.Warnings, err.Error())
+			}
+		}
+	}
+	return c, c.Validate()
+}
+
+// thinkModes are the accepted model.think values
+var thinkModes = []string{"auto", "on", "off", "low", "medium", "high"}
+
+// conventionalModes are the accepted checkpoint.conventional values
+var conventionalModes = []string{"auto", "always", "never"}
+
+// Validate checks that values are within range
+func (c *Config) Validate() error {
+	switch {
+	case !contains(thinkModes, c.Model.Think):
+		return fmt.Errorf("invalid model.think %q (use %s)", c.Model.Think, strings.Join(thinkModes, ", "))
+	case c.Budget.HighWatermark <= 0 || c.Budget.HighWatermark > 1:
+		return fmt.Errorf("budget.high_watermark must be between 0 and 1")
+	case c.Budget.LowWatermark <= 0 || c.Budget.LowWatermark >= c.Budget.HighWatermark:
+		return fmt.Errorf("budget.low_watermark must be between 0 and budget.high_watermark")
+	case c.Engine.MaxErrors < 1:
+		return fmt.Errorf("engine.max_errors must be at least 1")
+	case c.Memory.UpdateInterval < 1:
+		return fmt.Errorf("memory.update_interval must be at least 1")
+	case c.Verify.FlakyReruns < 0:
+		return fmt.Errorf("verify.flaky_reruns must not be negative")
+	case c.Coverage.MaxTasks < 1:
+		return fmt.Errorf("coverage.max_tasks must be at least 1")
+	case !contains(conventionalModes, c.Checkpoint.Conventional):
+		return fmt.Errorf("invalid checkpoint.conventional %q (use %s)", c.Checkpoint.Conventional, strings.Join(conventionalModes, ", "))
+	case c.Tools.ExecTimeout <= 0:
+		return fmt.Errorf("tools.exec_timeout must be positive")
+	}
+	return nil
+}
+
+// envVars maps settings to the environment variables that override them
+var envVars = map[string]string{
+	"model.name":  "OLLAMA_MODEL",
+	"model.embed": "OLLAMA_EMBED_MODEL",
+}
+
+// LoadFile merges a TOML config file over the current values
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	values, err := parseTOML(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		f, ok := c.field(key)
+		if !ok {
+			c.Warnings = append(c.Warnings, fmt.Sprintf("%s: unknown setting %q", path, key))
+			continue
+		}
+		if err := f.assign(value); err != nil {
+			return fmt.Errorf("invalid config %s: %s: %w", path, key, err)
+		}
+		c.sources[key] = path
+	}
+	return nil
+}
+
+// Set overrides a setting from a string, e.g. a flag or environment variable
+func (c *Config) Set(key, value, source string) error {
+	f, ok := c.field(key)
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	if err := f.parse(value); err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	c.sources[key] = source
+	return nil
+}
+
+// Source returns where a setting's value came from
+func (c *Config) Source(key string) string {
+	if source, ok := c.sources[key]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// Settings returns every setting with its effective value and source
+func (c *Config) Settings() []Setting {
+	fields := c.fields()
+	settings := make([]Setting, 0, len(fields))
+	for _, f := range fields {
+		// Each declared verification step is listed on its own
+		if steps, ok := f.ptr.(*[]repo.Step); ok && len(*steps) > 0 {
+			for _, step := range *steps {
+				settings = append(settings, Setting{Key: f.key + "." + step.Name, Value: step.Describe(), Source: c.Source(f.key)})
+			}
+			continue
+		}
+		settings = append(settings, Setting{Key: f.key, Value: f.String(), Source: c.Source(f.key)})
+	}
+	return settings
+}
+
+// ToolEnabled reports whether a tool is enabled
+func (c *Config) ToolEnabled(name string) bool {
+	for _, disabled := range c.Tools.Disabled {
+		if disabled == name {
+			return false
+		}
+	}
+	return true
+}
+
+// field is a named pointer into the config
+type field struct {
+	key string
+	ptr interface{}
+}
+
+// fields lists every setting in display order
+func (c *Config) fields() []field {
+	return []field{
+		{"model.name", &c.Model.Name},
+		{"model.summary", &c.Model.Summary},
+		{"model.embed", &c.Model.Embed},
+		{"model.think", &c.Model.Think},
+		{"engine.cycle_interval", &c.Engine.CycleInterval},
+		{"engine.max_errors", &c.Engine.MaxErrors},
+		{"memory.update_interval", &c.Memory.UpdateInterval},
+		{"memory.summary_timeout", &c.Memory.SummaryTimeout},
+		{"budget.num_ctx", &c.Budget.NumCtx},
+		{"budget.high_watermark", &c.Budget.HighWatermark},
+		{"budget.low_watermark", &c.Budget.LowWatermark},
+		{"budget.reserve_output", &c.Budget.ReserveOutput},
+		{"budget.max_transcript_turns", &c.Budget.MaxTranscriptTurns},
+		{"compactor.max_tool_output_lines", &c.Compactor.MaxToolOutputLines},
+		{"compactor.tool_output_head_lines", &c.Compactor.ToolOutputHeadLines},
+		{"compactor.tool_output_tail_lines", &c.Compactor.ToolOutputTailLines},
+		{"verify.build", &c.Verify.Build},
+		{"verify.test", &c.Verify.Test},
+		{"verify.lint", &c.Verify.Lint},
+		{"verify.format", &c.Verify.Format},
+		{"verify.steps", &c.Verify.Steps},
+		{"verify.targeted", &c.Verify.Targeted},
+		{"verify.before_checkpoint", &c.Verify.BeforeCheckpoint},
+		{"verify.flaky_reruns", &c.Verify.FlakyReruns},
+		{"verify.baseline", &c.Verify.Baseline},
+		{"lint.enabled", &c.Lint.Enabled},
+		{"lint.format", &c.Lint.Format},
+		{"lint.fix", &c.Lint.Fix},
+		{"lint.block_checkpoint", &c.Lint.BlockCheckpoint},
+		{"todo.scan", &c.Todo.Scan},
+		{"todo.ignore", &c.Todo.Ignore},
+		{"coverage.max_tasks", &c.Coverage.MaxTasks},
+		{"checkpoint.summary", &c.Checkpoint.Summary},
+		{"checkpoint.conventional", &c.Checkpoint.Conventional},
+		{"tools.exec_timeout", &c.Tools.ExecTimeout},
+		{"tools.disabled", &c.Tools.Disabled},
+	}
+}
+
+// field looks up a setting by key
+func (c *Config) field(key string) (field, bool) {
+	for _, f := range c.fields() {
+		if f.key == key {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// assign stores a value parsed from TOML
+func (f field) assign(value interface{}) error {
+	switch p := f.ptr.(type) {
+	case *string:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a string")
+		}
+		*p = s
+	case *bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected true or false")
+		}
+		*p = b
+	case *int:
+		n, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("expected an integer")
+		}
+		*p = int(n)
+	case *float64:
+		switch v := value.(type) {
+		case float64:
+			*p = v
+		case int64:
+			*p = float64(v)
+		default:
+			return fmt.Errorf("expected a number")
+		}
+	case *time.Duration:
+		switch v := value.(type) {
+		case string:
+			return f.parse(v)
+		case int64:
+			*p = time.Duration(v) * time.Second
+		default:
+			return fmt.Errorf(`expected a duration such as "30s" or a number of seconds`)
+		}
+	case *[]string:
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected an array of strings")
+		}
+		list := make([]string, 0, len(items))
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("expected an array of strings")
+			}
+			list = append(list, s)
+		}
+		*p = list
+	case *[]repo.Step:
+		steps, err := parseSteps(value)
+		if err != nil {
+			return err
+		}
+		*p = steps
+	}
+	return nil
+}
+
+// parse stores a value given as a string
+func (f field) parse(value string) error {
+	switch p := f.ptr.(type) {
+	case *string:
+		*p = value
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("expected true or false")
+		}
+		*p = b
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("expected an integer")
+		}
+		*p = n
+	case *float64:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number")
+		}
+		*p = v
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			if n, nerr := strconv.Atoi(value); nerr == nil {
+				d, err = time.Duration(n)*time.Second, nil
+			}
+		}
+		if err != nil {
+			return fmt.Errorf(`expected a duration such as "30s"`)
+		}
+		*p = d
+	case *[]string:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*p = list
+	case *[]repo.Step:
+		return fmt.Errorf("steps can only be declared with [[verify.steps]] in a config file")
+	}
+	return nil
+}
+
+// String renders the field's current value
+func (f field) String() string {
+	switch p := f.ptr.(type) {
+	case *string:
+		return strconv.Quote(*p)
+	case *bool:
+		return strconv.FormatBool(*p)
+	case *int:
+		return strconv.Itoa(*p)
+	case *float64:
+		return strconv.FormatFloat(*p, 'g', -1, 64)
+	case *time.Duration:
+		return strconv.Quote(p.String())
+	case *[]string:
+		quoted := make([]string, len(*p))
+		for i, s := range *p {
+			quoted[i] = strconv.Quote(s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	case *[]repo.Step:
+		names := make([]string, len(*p))
+		for i, step := range *p {
+			names[i] = strconv.Quote(step.Name)
+		}
+		return "[" + strings.Join(names, ", ") + "]"
+	}
+	return ""
+}
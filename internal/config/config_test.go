@@ -0,0 +1,269 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTOML(t *testing.T) {
+	values, err := parseTOML(`
+# Top-level comment
+title = "brewol" # trailing comment
+
+[model]
+name = "qwen2.5-coder:14b"
+think = 'off'
+
+[budget]
+high_watermark = 0.85
+num_ctx = 32_768
+
+[tools]
+disabled = [
+  "git_reset_hard", # never reset
+  "exec",
+]
+flag = true
+hash = "a # b"
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"title":                 "brewol",
+		"model.name":            "qwen2.5-coder:14b",
+		"model.think":           "off",
+		"budget.high_watermark": 0.85,
+		"budget.num_ctx":        int64(32768),
+		"tools.flag":            true,
+		"tools.hash":            "a # b",
+	}
+	for key, want := range expected {
+		if values[key] != want {
+			t.Errorf("%s: expected %v, got %v", key, want, values[key])
+		}
+	}
+	if list, ok := values["tools.disabled"].([]interface{}); !ok || len(list) != 2 || list[1] != "exec" {
+		t.Errorf("unexpected array: %v", values["tools.disabled"])
+	}
+
+	for _, bad := range []string{"[model", "name", "name = ", `name = "open`, "a = 1\na = 2", "[[steps]", "a = 1\n[[a]]", "x = [1, 2", "x = { a = 1"} {
+		if _, err := parseTOML(bad); err == nil {
+			t.Errorf("expected error parsing %q", bad)
+		}
+	}
+}
+
+func TestParseTOML_NestedArraysAndEscapes(t *testing.T) {
+	values, err := parseTOML(`
+tables = [{ A = "1", B = "2" }, { A = "3" }]
+nested = [[1, 2], ["a,b"], []]
+trailing = [1, 2,]
+escapes = "tab\tcr\rbs\b\u00e9\U0001F600\\\""
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tables, ok := values["tables"].([]interface{})
+	if !ok || len(tables) != 2 {
+		t.Fatalf("unexpected array of inline tables: %v", values["tables"])
+	}
+	if first, ok := tables[0].(map[string]interface{}); !ok || first["A"] != "1" || first["B"] != "2" {
+		t.Errorf("unexpected first table: %v", tables[0])
+	}
+
+	nested, ok := values["nested"].([]interface{})
+	if !ok || len(nested) != 3 {
+		t.Fatalf("unexpected nested array: %v", values["nested"])
+	}
+	if inner, ok := nested[1].([]interface{}); !ok || len(inner) != 1 || inner[0] != "a,b" {
+		t.Errorf("unexpected inner array: %v", nested[1])
+	}
+	if inner, ok := nested[2].([]interface{}); !ok || len(inner) != 0 {
+		t.Errorf("expected empty inner array, got %v", nested[2])
+	}
+	if list, ok := values["trailing"].([]interface{}); !ok || len(list) != 2 {
+		t.Errorf("unexpected array with trailing comma: %v", values["trailing"])
+	}
+	if want := "tab\tcr\rbs\b\u00e9\U0001F600\\\""; values["escapes"] != want {
+		t.Errorf("expected %q, got %q", want, values["escapes"])
+	}
+
+	for _, bad := range []string{`x = [1,,2]`, `x = "\u00"`, `x = "\uZZZZ"`, `x = "\UFFFFFFFF"`, `x = "\q"`} {
+		if _, err := parseTOML(bad); err == nil {
+			t.Errorf("expected error parsing %q", bad)
+		}
+	}
+}
+
+func TestLoad_Layering(t *testing.T) {
+	home := t.TempDir()
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("OLLAMA_MODEL", "")
+	t.Setenv("OLLAMA_EMBED_MODEL", "nomic-embed-text")
+
+	os.MkdirAll(filepath.Join(home, "brewol"), 0755)
+	os.WriteFile(filepath.Join(home, "brewol", "config.toml"), []byte(`
+[model]
+name = "user-model"
+summary = "small-model"
+
+[engine]
+cycle_interval = "5s"
+`), 0644)
+	os.MkdirAll(filepath.Join(root, ".brewol"), 0755)
+	os.WriteFile(RepoPath(root), []byte(`
+[model]
+name = "repo-model"
+
+[tools]
+exec_timeout = 300
+disabled = ["git_reset_hard"]
+
+[verify]
+test = "make check"
+
+[unknown]
+key = 1
+`), 0644)
+
+	c, err := Load(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if c.Model.Name != "repo-model" || c.Source("model.name") != RepoPath(root) {
+		t.Errorf("expected repo config to override user config, got %q from %s", c.Model.Name, c.Source("model.name"))
+	}
+	if c.Model.Summary != "small-model" || c.Engine.CycleInterval != 5*time.Second {
+		t.Errorf("expected user settings to apply, got %q / %v", c.Model.Summary, c.Engine.CycleInterval)
+	}
+	if c.Model.Embed != "nomic-embed-text" || c.Source("model.embed") != "env OLLAMA_EMBED_MODEL" {
+		t.Errorf("expected embed model from env, got %q from %s", c.Model.Embed, c.Source("model.embed"))
+	}
+	if c.Tools.ExecTimeout != 300*time.Second || c.ToolEnabled("git_reset_hard") || !c.ToolEnabled("exec") {
+		t.Errorf("unexpected tools config: %+v", c.Tools)
+	}
+	if c.Verify.Test != "make check" || c.Source("engine.max_errors") != SourceDefault {
+		t.Errorf("unexpected verify config or source: %+v", c.Verify)
+	}
+	if len(c.Warnings) != 1 || !strings.Contains(c.Warnings[0], "unknown.key") {
+		t.Errorf("expected a warning for the unknown key, got %v", c.Warnings)
+	}
+
+	if err := c.Set("model.name", "flag-model", SourceFlag); err != nil || c.Source("model.name") != SourceFlag {
+		t.Errorf("expected flag to override, got %v", err)
+	}
+	if err := c.Set("budget.num_ctx", "lots", SourceFlag); err == nil {
+		t.Error("expected error setting an integer from text")
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	os.MkdirAll(filepath.Join(root, ".brewol"), 0755)
+
+	for _, content := range []string{
+		"[engine]\nmax_errors = \"three\"",
+		"[budget]\nlow_watermark = 0.9",
+		"[model]\nthink = \"maybe\"",
+		"[model\n",
+	} {
+		os.WriteFile(RepoPath(root), []byte(content), 0644)
+		if _, err := Load(root); err == nil {
+			t.Errorf("expected error loading %q", content)
+		}
+	}
+}
+
+func TestSettings(t *testing.T) {
+	c := Default()
+	c.Tools.Disabled = []string{"exec"}
+
+	values := map[string]string{}
+	for _, s := range c.Settings() {
+		values[s.Key] = s.Value
+		if s.Source != SourceDefault {
+			t.Errorf("%s: expected default source, got %s", s.Key, s.Source)
+		}
+	}
+	if values["engine.cycle_interval"] != `"2s"` || values["tools.disabled"] != `["exec"]` || values["budget.high_watermark"] != "0.8" {
+		t.Errorf("unexpected rendered values: %v", values)
+	}
+}
+
+func TestLoad_VerifySteps(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	os.MkdirAll(filepath.Join(root, ".brewol"), 0755)
+	os.WriteFile(RepoPath(root), []byte(`
+[[verify.steps]]
+kind = "build"
+command = "task build"
+
+[[verify.steps]]
+name = "web"
+kind = "test"
+command = "just test"
+dir = "web"
+timeout = "5m"
+env = { NODE_ENV = "test", CI = "1" }
+
+[[verify.steps]]
+command = "./scripts/check-licenses.sh"
+required = false
+
+[tools]
+exec_timeout = 60
+`), 0644)
+
+	c, err := Load(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	steps := c.Verify.Steps
+	if len(steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d", len(steps))
+	}
+	if steps[0].Name != "build" || !steps[0].Required {
+		t.Errorf("expected name to default to kind and step to be required: %+v", steps[0])
+	}
+	if steps[1].Dir != "web" || steps[1].Timeout != 5*time.Minute || steps[1].Env["NODE_ENV"] != "test" {
+		t.Errorf("unexpected step: %+v", steps[1])
+	}
+	if steps[2].Name != "./scripts/check-licenses.sh" || steps[2].Required {
+		t.Errorf("expected advisory step named after its command: %+v", steps[2])
+	}
+	if c.Tools.ExecTimeout != time.Minute {
+		t.Errorf("expected tables after steps to apply, got %v", c.Tools.ExecTimeout)
+	}
+
+	var listed []string
+	for _, s := range c.Settings() {
+		if strings.HasPrefix(s.Key, "verify.steps.") {
+			listed = append(listed, s.Key+" = "+s.Value)
+		}
+	}
+	if len(listed) != 3 || !strings.Contains(listed[1], "verify.steps.web = web: `just test` (in web, timeout 5m0s, env CI NODE_ENV)") {
+		t.Errorf("unexpected listed steps: %v", listed)
+	}
+
+	for _, bad := range []string{
+		"[[verify.steps]]\nkind = \"test\"",
+		"[[verify.steps]]\ncommand = \"x\"\nkind = \"deploy\"",
+		"[[verify.steps]]\ncommand = \"x\"\nrequried = false",
+		"[[verify.steps]]\ncommand = \"x\"\n[[verify.steps]]\ncommand = \"x\"",
+	} {
+		os.WriteFile(RepoPath(root), []byte(bad), 0644)
+		if _, err := Load(root); err == nil {
+			t.Errorf("expected error loading %q", bad)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ai/brewol/internal/repo"
+)
+
+// stepKinds are the accepted verify.steps kind values
+var stepKinds = []string{repo.StepBuild, repo.StepTest, repo.StepLint, repo.StepFormat}
+
+// parseSteps converts a TOML array of tables into verification steps
+func parseSteps(value interface{}) ([]repo.Step, error) {
+	tables, ok := value.([]map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected [[verify.steps]] tables")
+	}
+
+	steps := make([]repo.Step, 0, len(tables))
+	names := make(map[string]bool)
+	for i, table := range tables {
+		keys := make([]string, 0, len(table))
+		for key := range table {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		step := repo.Step{Required: true}
+		for _, key := range keys {
+			v := table[key]
+			var err error
+			switch key {
+			case "name":
+				step.Name, err = stepString(v)
+			case "kind":
+				step.Kind, err = stepString(v)
+			case "command":
+				step.Command, err = stepString(v)
+			case "dir":
+				step.Dir, err = stepString(v)
+			case "timeout":
+				f := field{ptr: &step.Timeout}
+				err = f.assign(v)
+			case "required":
+				var isBool bool
+				if step.Required, isBool = v.(bool); !isBool {
+					err = fmt.Errorf("expected true or false")
+				}
+			case "env":
+				step.Env, err = stepEnv(v)
+			default:
+				err = fmt.Errorf("unknown setting")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("step %d: %s: %w", i+1, key, err)
+			}
+		}
+
+		if step.Command == "" {
+			return nil, fmt.Errorf("step %d: missing command", i+1)
+		}
+		if step.Kind != "" && !contains(stepKinds, step.Kind) {
+			return nil, fmt.Errorf("step %d: invalid kind %q (use %s)", i+1, step.Kind, strings.Join(stepKinds, ", "))
+		}
+		if step.Name == "" {
+			step.Name = step.Kind
+		}
+		if step.Name == "" {
+			step.Name = step.Command
+		}
+		if names[step.Name] {
+			return nil, fmt.Errorf("step %d: duplicate name %q", i+1, step.Name)
+		}
+		names[step.Name] = true
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// stepString converts a step value to a string
+func stepString(v interface{}) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("expected a string")
+	}
+	return s, nil
+}
+
+// stepEnv converts an inline table of strings to environment variables
+func stepEnv(v interface{}) (map[string]string, error) {
+	table, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf(`expected an inline table such as { KEY = "value" }`)
+	}
+	env := make(map[string]string, len(table))
+	for k, val := range table {
+		s, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected a string", k)
+		}
+		env[k] = s
+	}
+	return env, nil
+}
+
+// contains reports whether list contains s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,329 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseTOML parses the subset of TOML used by config files: [table],
+// [dotted.table] and [[array.of.tables]] headers, bare keys, basic and
+// literal strings, integers, floats, booleans, (possibly multi-line) arrays
+// and inline tables. It returns values keyed by their full dotted path;
+// arrays of tables are []map[string]interface{} keyed by their own keys.
+func parseTOML(data string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	table := ""
+	var item map[string]interface{} // Current [[array]] entry, if any
+
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[[") {
+			if !strings.HasSuffix(line, "]]") {
+				return nil, fmt.Errorf("line %d: unterminated table header", lineNo)
+			}
+			name := strings.TrimSpace(line[2 : len(line)-2])
+			if !validKey(name) {
+				return nil, fmt.Errorf("line %d: invalid table name %q", lineNo, name)
+			}
+			list, exists := values[name].([]map[string]interface{})
+			if _, set := values[name]; set && !exists {
+				return nil, fmt.Errorf("line %d: %q is not an array of tables", lineNo, name)
+			}
+			item = make(map[string]interface{})
+			values[name] = append(list, item)
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			item = nil
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated table header", lineNo)
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			if !validKey(table) {
+				return nil, fmt.Errorf("line %d: invalid table name %q", lineNo, table)
+			}
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key := strings.TrimSpace(line[:eq])
+		raw := strings.TrimSpace(line[eq+1:])
+		if !validKey(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", lineNo, key)
+		}
+
+		// Multi-line arrays continue until the brackets balance
+		for strings.HasPrefix(raw, "[") && !bracketsClosed(raw) && i+1 < len(lines) {
+			i++
+			raw += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+
+		value, err := parseValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if item != nil {
+			if _, dup := item[key]; dup {
+				return nil, fmt.Errorf("line %d: duplicate key %q", lineNo, key)
+			}
+			item[key] = value
+			continue
+		}
+		if table != "" {
+			key = table + "." + key
+		}
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", lineNo, key)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// parseValue parses a single TOML value
+func parseValue(raw string) (interface{}, error) {
+	switch {
+	case raw == "":
+		return nil, fmt.Errorf("missing value")
+	case raw == "true":
+		return true, nil
+	case raw == "false":
+		return false, nil
+	case strings.HasPrefix(raw, `"`), strings.HasPrefix(raw, "'"):
+		s, rest, err := parseString(raw)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("unexpected text after string: %q", rest)
+		}
+		return s, nil
+	case strings.HasPrefix(raw, "["):
+		return parseArray(raw)
+	case strings.HasPrefix(raw, "{"):
+		return parseInlineTable(raw)
+	}
+
+	number := strings.ReplaceAll(raw, "_", "")
+	if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(number, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("invalid value %q", raw)
+}
+
+// parseString parses a basic ("...") or literal ('...') string at the start
+// of raw and returns it with the remaining text
+func parseString(raw string) (string, string, error) {
+	quote := raw[0]
+	if quote == '\'' {
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated string")
+		}
+		return raw[1 : end+1], raw[end+2:], nil
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '"':
+			return b.String(), raw[i+1:], nil
+		case c == '\\' && i+1 < len(raw):
+			i++
+			switch raw[i] {
+			case 'b':
+				b.WriteByte('\b')
+			case 't':
+				b.WriteByte('\t')
+			case 'n':
+				b.WriteByte('\n')
+			case 'f':
+				b.WriteByte('\f')
+			case 'r':
+				b.WriteByte('\r')
+			case '"', '\\':
+				b.WriteByte(raw[i])
+			case 'u', 'U':
+				size := 4
+				if raw[i] == 'U' {
+					size = 8
+				}
+				if i+size >= len(raw) {
+					return "", "", fmt.Errorf("invalid escape \\%c", raw[i])
+				}
+				code, err := strconv.ParseUint(raw[i+1:i+1+size], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(code)) {
+					return "", "", fmt.Errorf("invalid escape \\%s", raw[i:i+1+size])
+				}
+				b.WriteRune(rune(code))
+				i += size
+			default:
+				return "", "", fmt.Errorf("unsupported escape \\%c", raw[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string")
+}
+
+// parseArray parses a single-line array of values, including nested arrays
+// and inline tables
+func parseArray(raw string) ([]interface{}, error) {
+	if !strings.HasSuffix(raw, "]") {
+		return nil, fmt.Errorf("unterminated array")
+	}
+	parts := splitTopLevel(raw[1 : len(raw)-1])
+
+	items := []interface{}{}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" && i == len(parts)-1 {
+			break // Trailing comma, or an empty array
+		}
+		item, err := parseValue(part)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseInlineTable parses a single-line table such as { KEY = "value", N = 1 }
+func parseInlineTable(raw string) (map[string]interface{}, error) {
+	if !strings.HasSuffix(raw, "}") {
+		return nil, fmt.Errorf("unterminated inline table")
+	}
+	table := make(map[string]interface{})
+	for _, entry := range splitTopLevel(raw[1 : len(raw)-1]) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		eq := strings.Index(entry, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("expected key = value in inline table")
+		}
+		key := strings.TrimSpace(entry[:eq])
+		if !validKey(key) {
+			return nil, fmt.Errorf("invalid key %q", key)
+		}
+		if _, dup := table[key]; dup {
+			return nil, fmt.Errorf("duplicate key %q", key)
+		}
+		value, err := parseValue(strings.TrimSpace(entry[eq+1:]))
+		if err != nil {
+			return nil, err
+		}
+		table[key] = value
+	}
+	return table, nil
+}
+
+// splitTopLevel splits s on commas that are outside strings and brackets
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, last := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, s[last:i])
+			last = i + 1
+		}
+	}
+	return append(parts, s[last:])
+}
+
+// stripComment removes a # comment that is not inside a string
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// bracketsClosed reports whether the brackets of an array value balance
+func bracketsClosed(raw string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		}
+	}
+	return depth == 0
+}
+
+// validKey reports whether k is a bare or dotted bare key
+func validKey(k string) bool {
+	if k == "" {
+		return false
+	}
+	for _, part := range strings.Split(k, ".") {
+		if part == "" {
+			return false
+		}
+		for _, r := range part {
+			if !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
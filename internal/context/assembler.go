@@ -0,0 +1,270 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tier is the priority class of a context item. Lower values are more
+// important; the highest tier is evicted first.
+type Tier int
+
+const (
+	TierSystem      Tier = iota // System prompt and current observation, never evicted
+	TierTaskBrief               // Task status
+	TierPinned                  // User-pinned files
+	TierToolResults             // Tool results since the last model reply
+	TierTranscript              // Older conversation
+	TierMemory                  // Working memory and knowledge
+	numTiers
+)
+
+// String returns the tier name
+func (t Tier) String() string {
+	switch t {
+	case TierSystem:
+		return "system"
+	case TierTaskBrief:
+		return "task-brief"
+	case TierPinned:
+		return "pinned"
+	case TierToolResults:
+		return "tool-results"
+	case TierTranscript:
+		return "transcript"
+	case TierMemory:
+		return "memory"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultTierShares returns each tier's guaranteed share of the budget. Once
+// the total exceeds the budget, a tier is only cut below its share after all
+// lower-priority tiers have been emptied.
+func DefaultTierShares() map[Tier]float64 {
+	return map[Tier]float64{
+		TierTaskBrief:   0.10,
+		TierPinned:      0.30,
+		TierToolResults: 0.25,
+		TierTranscript:  0.25,
+		TierMemory:      0.10,
+	}
+}
+
+// minTruncatedTokens is the smallest size an item is truncated to; below this it is dropped
+const minTruncatedTokens = 64
+
+// ContextItem is a piece of content competing for space in the prompt
+type ContextItem struct {
+	Tier    Tier
+	Label   string // Human-readable description used in compaction events
+	Role    string
+	Content string
+	tokens  int
+}
+
+// Assembly is the result of packing items into a budget
+type Assembly struct {
+	Items        []ContextItem // Kept items, in their original order
+	Dropped      []ContextItem // Evicted items
+	Truncated    []string      // Labels of items that were shortened to fit
+	TokensBefore int
+	TokensAfter  int
+	Budget       int
+}
+
+// Compacted reports whether anything was dropped or truncated
+func (a *Assembly) Compacted() bool {
+	return len(a.Dropped) > 0 || len(a.Truncated) > 0
+}
+
+// Messages returns the kept items as messages
+func (a *Assembly) Messages() []Message {
+	msgs := make([]Message, 0, len(a.Items))
+	for _, item := range a.Items {
+		msgs = append(msgs, Message{Role: item.Role, Content: item.Content})
+	}
+	return msgs
+}
+
+// Event describes the assembly as a compaction event
+func (a *Assembly) Event(reason string) CompactionEvent {
+	var dropped []string
+	for _, item := range a.Dropped {
+		dropped = append(dropped, fmt.Sprintf("%s: %s (%d tok)", item.Tier, item.Label, item.tokens))
+	}
+
+	return CompactionEvent{
+		Timestamp:      timeNow(),
+		Reason:         reason,
+		TokensBefore:   a.TokensBefore,
+		TokensAfter:    a.TokensAfter,
+		CompactedItems: a.Summary(),
+		Dropped:        dropped,
+		Truncated:      append([]string(nil), a.Truncated...),
+	}
+}
+
+// Summary returns a one-line description of what was dropped per tier
+func (a *Assembly) Summary() string {
+	counts := make(map[Tier]int)
+	for _, item := range a.Dropped {
+		counts[item.Tier]++
+	}
+
+	var parts []string
+	for t := Tier(0); t < numTiers; t++ {
+		if counts[t] > 0 {
+			parts = append(parts, fmt.Sprintf("%s(%d dropped)", t, counts[t]))
+		}
+	}
+	if len(a.Truncated) > 0 {
+		parts = append(parts, fmt.Sprintf("%d truncated", len(a.Truncated)))
+	}
+	if len(parts) == 0 {
+		return "nothing"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Assembler packs context items into a token budget by tier
+type Assembler struct {
+	tokenizer Tokenizer
+	model     string
+	shares    map[Tier]float64
+}
+
+// NewAssembler creates an assembler counting tokens for model with tokenizer
+func NewAssembler(tokenizer Tokenizer, model string) *Assembler {
+	return &Assembler{
+		tokenizer: tokenizer,
+		model:     model,
+		shares:    DefaultTierShares(),
+	}
+}
+
+// SetTierShare overrides a tier's share of the budget
+func (a *Assembler) SetTierShare(tier Tier, share float64) {
+	a.shares[tier] = share
+}
+
+// Pack fits items into budget tokens. Items are evicted oldest first from
+// tiers over their share, lowest priority tier first; if that is not enough,
+// tiers are emptied lowest priority first. The newest item of a tier is
+// truncated rather than dropped when that alone makes it fit. System items
+// are never evicted.
+func (a *Assembler) Pack(items []ContextItem, budget int) *Assembly {
+	kept := make([]ContextItem, len(items))
+	copy(kept, items)
+
+	total := 0
+	tierTokens := make([]int, numTiers)
+	for i := range kept {
+		kept[i].tokens = a.count(kept[i].Content) + MessageOverheadTok
+		total += kept[i].tokens
+		tierTokens[kept[i].Tier] += kept[i].tokens
+	}
+
+	result := &Assembly{TokensBefore: total, Budget: budget}
+	removed := make([]bool, len(kept))
+
+	// evict drops or shortens items of a tier, oldest first, while over and
+	// while the tier is above its floor
+	evict := func(tier Tier, floor int) {
+		indices := tierIndices(kept, removed, tier)
+		for n, i := range indices {
+			if total <= budget || tierTokens[tier] <= floor {
+				return
+			}
+
+			excess := total - budget
+			if tierExcess := tierTokens[tier] - floor; tierExcess < excess {
+				excess = tierExcess
+			}
+
+			// Shorten the newest item of the tier instead of dropping it
+			if target := kept[i].tokens - excess; n == len(indices)-1 && target >= minTruncatedTokens {
+				shortened := kept[i]
+				a.truncate(&shortened, target)
+				shortened.tokens = a.count(shortened.Content) + MessageOverheadTok
+				if shortened.tokens <= target {
+					total -= kept[i].tokens - shortened.tokens
+					tierTokens[tier] -= kept[i].tokens - shortened.tokens
+					kept[i] = shortened
+					result.Truncated = append(result.Truncated, kept[i].Label)
+					return
+				}
+			}
+
+			removed[i] = true
+			total -= kept[i].tokens
+			tierTokens[tier] -= kept[i].tokens
+			result.Dropped = append(result.Dropped, kept[i])
+		}
+	}
+
+	if total > budget {
+		// Pass 1: trim tiers that exceed their share
+		for t := numTiers - 1; t > TierSystem; t-- {
+			evict(t, int(float64(budget)*a.shares[t]))
+		}
+		// Pass 2: empty tiers, lowest priority first
+		for t := numTiers - 1; t > TierSystem; t-- {
+			evict(t, 0)
+		}
+	}
+
+	for i, item := range kept {
+		if !removed[i] {
+			result.Items = append(result.Items, item)
+		}
+	}
+	result.TokensAfter = total
+	return result
+}
+
+// count returns the tokens in text
+func (a *Assembler) count(text string) int {
+	return a.tokenizer.CountTokens(a.model, text)
+}
+
+// truncate shortens an item to roughly target tokens, keeping its head and tail
+func (a *Assembler) truncate(item *ContextItem, target int) {
+	content := item.Content
+	for attempt := 0; attempt < 4; attempt++ {
+		tokens := a.count(content) + MessageOverheadTok
+		if tokens <= target {
+			break
+		}
+		keep := int(float64(len(content)) * float64(target) / float64(tokens) * 0.9)
+		content = headTail(item.Content, keep)
+	}
+	item.Content = content
+}
+
+// headTail keeps the first and last halves of keep characters of s
+func headTail(s string, keep int) string {
+	const marker = "\n... [truncated to fit context] ...\n"
+	keep -= len(marker)
+	if keep <= 0 {
+		return strings.TrimSpace(marker)
+	}
+	if keep >= len(s) {
+		return s
+	}
+	head := keep / 2
+	tail := keep - head
+	return s[:head] + marker + s[len(s)-tail:]
+}
+
+// tierIndices returns indices of kept items in a tier, oldest first
+func tierIndices(items []ContextItem, removed []bool, tier Tier) []int {
+	var indices []int
+	for i, item := range items {
+		if item.Tier == tier && !removed[i] {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
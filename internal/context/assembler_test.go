@@ -0,0 +1,153 @@
+package context
+
+import (
+	"strings"
+	"testing"
+)
+
+// charTokenizer counts one token per character
+type charTokenizer struct{}
+
+func (charTokenizer) CountTokens(model, text string) int { return len(text) }
+
+func item(tier Tier, label string, size int) ContextItem {
+	return ContextItem{Tier: tier, Label: label, Role: "user", Content: strings.Repeat("x", size)}
+}
+
+func labels(items []ContextItem) []string {
+	var out []string
+	for _, it := range items {
+		out = append(out, it.Label)
+	}
+	return out
+}
+
+func TestAssemblerFitsWithoutEviction(t *testing.T) {
+	a := NewAssembler(charTokenizer{}, "m")
+	items := []ContextItem{item(TierSystem, "system", 100), item(TierTranscript, "msg", 100)}
+
+	result := a.Pack(items, 1000)
+	if result.Compacted() {
+		t.Errorf("Expected no compaction, got %s", result.Summary())
+	}
+	if len(result.Items) != 2 {
+		t.Errorf("Expected 2 items, got %d", len(result.Items))
+	}
+	if result.TokensBefore != result.TokensAfter {
+		t.Errorf("Expected unchanged token count, got %d → %d", result.TokensBefore, result.TokensAfter)
+	}
+}
+
+func TestAssemblerEvictsLowestPriorityFirst(t *testing.T) {
+	a := NewAssembler(charTokenizer{}, "m")
+	items := []ContextItem{
+		item(TierSystem, "system", 196),
+		item(TierMemory, "memory", 96),
+		item(TierTaskBrief, "brief", 46),
+		item(TierPinned, "pin:a.go", 96),
+		item(TierTranscript, "old-1", 96),
+		item(TierTranscript, "old-2", 96),
+		item(TierToolResults, "tool", 96),
+		item(TierSystem, "observation", 46),
+	}
+
+	// Total is 800 tokens (with overhead); a budget of 600 requires freeing 200.
+	// Memory (lowest priority) is over its share and too small to truncate, then
+	// the transcript is over its share so its oldest item goes.
+	result := a.Pack(items, 600)
+
+	if result.TokensAfter > 600 {
+		t.Fatalf("Expected result within budget, got %d", result.TokensAfter)
+	}
+	dropped := strings.Join(labels(result.Dropped), ",")
+	if dropped != "memory,old-1" {
+		t.Errorf("Expected memory then oldest transcript to be dropped, got %q", dropped)
+	}
+
+	kept := strings.Join(labels(result.Items), ",")
+	if kept != "system,brief,pin:a.go,old-2,tool,observation" {
+		t.Errorf("Expected original order of kept items, got %q", kept)
+	}
+}
+
+func TestAssemblerNeverEvictsSystem(t *testing.T) {
+	a := NewAssembler(charTokenizer{}, "m")
+	items := []ContextItem{item(TierSystem, "system", 500), item(TierTranscript, "msg", 100)}
+
+	result := a.Pack(items, 100)
+	if len(result.Items) != 1 || result.Items[0].Label != "system" {
+		t.Errorf("Expected only the system item to remain, got %v", labels(result.Items))
+	}
+}
+
+func TestAssemblerRespectsTierShares(t *testing.T) {
+	a := NewAssembler(charTokenizer{}, "m")
+	// Tool results far exceed their 25% share; pinned files are within theirs
+	items := []ContextItem{
+		item(TierPinned, "pin", 196),
+		item(TierToolResults, "tool-1", 396),
+		item(TierToolResults, "tool-2", 396),
+	}
+
+	result := a.Pack(items, 800)
+	for _, d := range result.Dropped {
+		if d.Tier == TierPinned {
+			t.Errorf("Pinned item should survive while tool results exceed their share")
+		}
+	}
+	if result.TokensAfter > 800 {
+		t.Errorf("Expected result within budget, got %d", result.TokensAfter)
+	}
+}
+
+func TestAssemblerTruncatesNewestItem(t *testing.T) {
+	a := NewAssembler(charTokenizer{}, "m")
+	big := ContextItem{Tier: TierToolResults, Label: "go test", Role: "user",
+		Content: "HEAD" + strings.Repeat("x", 2000) + "TAIL"}
+	items := []ContextItem{item(TierSystem, "system", 96), big}
+
+	result := a.Pack(items, 1000)
+	if len(result.Dropped) != 0 {
+		t.Errorf("Expected the only tool result to be truncated, not dropped")
+	}
+	if len(result.Truncated) != 1 || result.Truncated[0] != "go test" {
+		t.Fatalf("Expected go test to be truncated, got %v", result.Truncated)
+	}
+	content := result.Items[1].Content
+	if !strings.HasPrefix(content, "HEAD") || !strings.HasSuffix(content, "TAIL") {
+		t.Error("Expected head and tail to be preserved")
+	}
+	if !strings.Contains(content, "truncated to fit context") {
+		t.Error("Expected truncation marker")
+	}
+	if result.TokensAfter > 1000 {
+		t.Errorf("Expected result within budget, got %d", result.TokensAfter)
+	}
+}
+
+func TestAssemblyEvent(t *testing.T) {
+	a := NewAssembler(charTokenizer{}, "m")
+	items := []ContextItem{item(TierSystem, "system", 96), item(TierMemory, "working memory", 96)}
+
+	result := a.Pack(items, 120)
+	event := result.Event("pre_send")
+
+	if event.Reason != "pre_send" {
+		t.Errorf("Expected reason pre_send, got %s", event.Reason)
+	}
+	if event.TokensBefore != 200 || event.TokensAfter != 100 {
+		t.Errorf("Expected 200 → 100 tokens, got %d → %d", event.TokensBefore, event.TokensAfter)
+	}
+	if len(event.Dropped) != 1 || !strings.Contains(event.Dropped[0], "memory: working memory") {
+		t.Errorf("Expected dropped item description, got %v", event.Dropped)
+	}
+	if event.CompactedItems != "memory(1 dropped)" {
+		t.Errorf("Expected summary memory(1 dropped), got %s", event.CompactedItems)
+	}
+
+	bm := NewBudgetManager(DefaultBudgetConfig())
+	bm.RecordEvent(event)
+	if last := bm.GetLastCompactionEvent(); last == nil || len(last.Dropped) != 1 {
+		t.Error("Expected recorded event to keep dropped items")
+	}
+}
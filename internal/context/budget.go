@@ -46,11 +46,13 @@ type TokenUsage struct {
 
 // CompactionEvent represents a compaction that occurred
 type CompactionEvent struct {
-	Timestamp      int64  // Unix timestamp
-	Reason         string // Why compaction was triggered
-	TokensBefore   int    // Tokens before compaction
-	TokensAfter    int    // Tokens after compaction
-	CompactedItems string // Description of what was compacted
+	Timestamp      int64    // Unix timestamp
+	Reason         string   // Why compaction was triggered
+	TokensBefore   int      // Tokens before compaction
+	TokensAfter    int      // Tokens after compaction
+	CompactedItems string   // Description of what was compacted
+	Dropped        []string // Items evicted from the prompt, if known
+	Truncated      []string // Items shortened to fit, if known
 }
 
 // BudgetState represents the current state of context budget
@@ -61,6 +63,7 @@ type BudgetState struct {
 	AvailableTokens  int     // Tokens available for output
 	LastPromptTokens int     // Last measured prompt tokens
 	LastEvalTokens   int     // Last measured eval tokens
+	NextPromptTokens int     // Counted tokens of the prompt about to be sent
 	UsageRatio       float64 // Current usage as ratio
 	NeedsCompaction  bool    // Whether compaction is needed
 }
@@ -70,6 +73,7 @@ type BudgetManager struct {
 	config           BudgetConfig
 	lastPromptTokens int
 	lastEvalTokens   int
+	nextPromptTokens int
 	compactionEvents []CompactionEvent
 	maxEvents        int
 	mu               sync.RWMutex
@@ -131,6 +135,13 @@ func (b *BudgetManager) UpdateMetrics(promptTokens, evalTokens int) {
 	b.lastEvalTokens = evalTokens
 }
 
+// SetNextPromptTokens records the counted size of the prompt about to be sent
+func (b *BudgetManager) SetNextPromptTokens(promptTokens int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextPromptTokens = promptTokens
+}
+
 // GetState returns the current budget state
 func (b *BudgetManager) GetState() BudgetState {
 	b.mu.RLock()
@@ -152,6 +163,7 @@ func (b *BudgetManager) GetState() BudgetState {
 		AvailableTokens:  availableTokens,
 		LastPromptTokens: b.lastPromptTokens,
 		LastEvalTokens:   b.lastEvalTokens,
+		NextPromptTokens: b.nextPromptTokens,
 		UsageRatio:       usageRatio,
 		NeedsCompaction:  b.lastPromptTokens >= highWatermark,
 	}
@@ -166,6 +178,17 @@ func (b *BudgetManager) NeedsCompaction() bool {
 	return b.lastPromptTokens >= highWatermark
 }
 
+// NeedsCompactionFor returns true if a prompt of promptTokens tokens would reach
+// the high watermark or leave less than the reserved output space. Used to
+// compact before a request is sent rather than after it overflows.
+func (b *BudgetManager) NeedsCompactionFor(promptTokens int) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	highWatermark := int(float64(b.config.NumCtx) * b.config.HighWatermarkRatio)
+	return promptTokens >= highWatermark || promptTokens+b.config.ReserveOutputTokens > b.config.NumCtx
+}
+
 // TargetTokens returns the target number of prompt tokens after compaction
 func (b *BudgetManager) TargetTokens() int {
 	b.mu.RLock()
@@ -191,14 +214,24 @@ func (b *BudgetManager) RecordCompaction(reason string, tokensBefore, tokensAfte
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	event := CompactionEvent{
+	b.recordEvent(CompactionEvent{
 		Timestamp:      timeNow(),
 		Reason:         reason,
 		TokensBefore:   tokensBefore,
 		TokensAfter:    tokensAfter,
 		CompactedItems: compactedItems,
-	}
+	})
+}
+
+// RecordEvent records a fully described compaction event
+func (b *BudgetManager) RecordEvent(event CompactionEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.recordEvent(event)
+}
 
+// recordEvent appends an event. Caller must hold b.mu.
+func (b *BudgetManager) recordEvent(event CompactionEvent) {
 	b.compactionEvents = append(b.compactionEvents, event)
 
 	// Keep only the last N events
@@ -109,6 +109,42 @@ func TestBudgetManagerCompactionTrigger(t *testing.T) {
 	}
 }
 
+func TestBudgetManagerNeedsCompactionFor(t *testing.T) {
+	bm := NewBudgetManager(BudgetConfig{
+		NumCtx:              10000,
+		HighWatermarkRatio:  0.9,
+		LowWatermarkRatio:   0.6,
+		ReserveOutputTokens: 2048,
+	})
+
+	tests := []struct {
+		promptTokens int
+		expected     bool
+	}{
+		{5000, false},
+		{7952, false}, // Exactly leaves the reserved output space
+		{7953, true},  // Would eat into the output reserve
+		{9000, true},  // High watermark
+	}
+
+	for _, tt := range tests {
+		if got := bm.NeedsCompactionFor(tt.promptTokens); got != tt.expected {
+			t.Errorf("NeedsCompactionFor(%d) = %v, expected %v", tt.promptTokens, got, tt.expected)
+		}
+	}
+
+	// Independent of the last measured metrics
+	bm.UpdateMetrics(9500, 100)
+	if bm.NeedsCompactionFor(1000) {
+		t.Error("pre-send check should use the given prompt size")
+	}
+
+	bm.SetNextPromptTokens(1234)
+	if state := bm.GetState(); state.NextPromptTokens != 1234 {
+		t.Errorf("Expected NextPromptTokens 1234, got %d", state.NextPromptTokens)
+	}
+}
+
 func TestBudgetManagerState(t *testing.T) {
 	bm := NewBudgetManager(BudgetConfig{
 		NumCtx:              8192,
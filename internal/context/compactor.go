@@ -372,10 +372,10 @@ func compactAssistantMessage(content string, maxLength int) string {
 	return output
 }
 
-// EstimateTokens provides a rough token estimate for a string
+// EstimateTokens provides a rough token estimate for a string when no model
+// is known. Use a TokenCounter for counts that drive compaction decisions.
 func EstimateTokens(s string) int {
-	// Rough estimate: ~4 characters per token
-	return len(s) / 4
+	return int(float64(len(s)) / DefaultCharsPerToken)
 }
 
 // GetLogDir returns the log directory path
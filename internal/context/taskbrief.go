@@ -215,9 +215,12 @@ func (b *TaskBrief) FormatOneLine() string {
 
 // EstimateTokens provides a rough estimate of tokens in the formatted brief
 func (b *TaskBrief) EstimateTokens() int {
-	// Rough estimate: ~4 chars per token
-	formatted := b.Format()
-	return len(formatted) / 4
+	return EstimateTokens(b.Format())
+}
+
+// CountTokens counts the tokens in the formatted brief with a model's tokenizer
+func (b *TaskBrief) CountTokens(t Tokenizer, model string) int {
+	return t.CountTokens(model, b.Format())
 }
 
 // ShrinkToLevel shrinks the brief to a specified level
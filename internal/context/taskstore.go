@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"sync"
 	"time"
@@ -37,6 +38,7 @@ type TaskCategory string
 const (
 	TaskCategoryBuild    TaskCategory = "build"    // Build/compile issues
 	TaskCategoryTest     TaskCategory = "test"     // Test failures
+	TaskCategoryCoverage TaskCategory = "coverage" // Under-tested code
 	TaskCategoryGoal     TaskCategory = "goal"     // User-defined goals
 	TaskCategoryTodo     TaskCategory = "todo"     // Code TODOs
 	TaskCategoryFixme    TaskCategory = "fixme"    // Code FIXMEs
@@ -168,6 +170,45 @@ func (ts *TaskStore) UpdateTask(id string, update func(*Task)) error {
 	return ts.save()
 }
 
+// UpsertTasks adds new tasks and refreshes the title, description and files
+// of existing ones when they differ, keeping their status, priority, category
+// and history. The store is saved once, and only if something changed. It
+// returns the number of tasks added.
+func (ts *TaskStore) UpsertTasks(tasks []*Task) (int, error) {
+	now := time.Now()
+	added, changed := 0, 0
+
+	ts.mu.Lock()
+	for _, task := range tasks {
+		if existing, ok := ts.tasks[task.ID]; ok {
+			if existing.Title == task.Title && existing.Description == task.Description && slices.Equal(existing.Files, task.Files) {
+				continue
+			}
+			existing.Title = task.Title
+			existing.Description = task.Description
+			existing.Files = task.Files
+			existing.UpdatedAt = now
+			changed++
+			continue
+		}
+		if task.CreatedAt.IsZero() {
+			task.CreatedAt = now
+		}
+		task.UpdatedAt = now
+		if task.Status == "" {
+			task.Status = TaskStatusPending
+		}
+		ts.tasks[task.ID] = task
+		added++
+	}
+	ts.mu.Unlock()
+
+	if added+changed == 0 {
+		return 0, nil
+	}
+	return added, ts.save()
+}
+
 // GetTask returns a task by ID
 func (ts *TaskStore) GetTask(id string) (*Task, bool) {
 	ts.mu.RLock()
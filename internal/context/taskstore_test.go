@@ -433,3 +433,45 @@ func TestTaskStoreTimestamps(t *testing.T) {
 		t.Error("UpdatedAt should be updated after modification")
 	}
 }
+
+func TestTaskStoreUpsert(t *testing.T) {
+	ts, err := NewTaskStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create task store: %v", err)
+	}
+	scanned := func(title string) []*Task {
+		return []*Task{{ID: "todo:a", Title: title, Priority: TaskPriorityLow, Category: TaskCategoryTodo, Files: []string{"main.go"}}}
+	}
+
+	if added, err := ts.UpsertTasks(scanned("TODO: add flags")); err != nil || added != 1 {
+		t.Fatalf("Expected 1 task added, got %d (%v)", added, err)
+	}
+
+	// Reprioritising survives rescans
+	ts.UpdateTask("todo:a", func(task *Task) {
+		task.Priority = TaskPriorityHigh
+		task.Category = TaskCategoryGoal
+	})
+	before, _ := ts.GetTask("todo:a")
+
+	// An unchanged rescan neither touches the task nor saves the store
+	os.Remove(ts.FilePath())
+	if added, err := ts.UpsertTasks(scanned("TODO: add flags")); err != nil || added != 0 {
+		t.Fatalf("Expected nothing added, got %d (%v)", added, err)
+	}
+	if _, err := os.Stat(ts.FilePath()); !os.IsNotExist(err) {
+		t.Error("Expected an unchanged rescan not to save the store")
+	}
+	if task, _ := ts.GetTask("todo:a"); !task.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Error("Expected an unchanged rescan not to touch the task")
+	}
+
+	ts.UpsertTasks(scanned("TODO: add verbose flag"))
+	task, _ := ts.GetTask("todo:a")
+	if task.Title != "TODO: add verbose flag" || task.Priority != TaskPriorityHigh || task.Category != TaskCategoryGoal {
+		t.Errorf("Expected the title refreshed and the priority and category kept, got %+v", task)
+	}
+	if _, err := os.Stat(ts.FilePath()); err != nil {
+		t.Errorf("Expected a changed rescan to save the store: %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package context
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Token counting defaults
+const (
+	DefaultCharsPerToken = 4.0  // Starting ratio before calibration
+	MinCharsPerToken     = 1.5  // Lower clamp for a learned ratio
+	MaxCharsPerToken     = 8.0  // Upper clamp for a learned ratio
+	CalibrationAlpha     = 0.3  // Weight of a new observation in the moving average
+	MessageOverheadTok   = 4    // Tokens added per message for role/template framing
+	maxTokenCacheEntries = 4096 // Cache is reset when it grows beyond this
+	tokenizeTimeout      = 2 * time.Second
+	tokenizeBackoff      = 30 * time.Second // Estimate for this long after a transient failure
+)
+
+// ErrTokenizeUnsupported is returned by a TokenizeFunc when the server can
+// never tokenize for the model
+var ErrTokenizeUnsupported = errors.New("tokenize not supported")
+
+// TokenizeFunc returns the exact token count of text for a model.
+// Implementations return ErrTokenizeUnsupported when the server has no
+// tokenizer, and other errors for failures worth retrying.
+type TokenizeFunc func(ctx context.Context, model, text string) (int, error)
+
+// Tokenizer counts tokens for a model
+type Tokenizer interface {
+	CountTokens(model, text string) int
+}
+
+// CalibratedEstimator estimates tokens from character counts using a
+// per-model chars-per-token ratio learned from observed prompt token counts
+type CalibratedEstimator struct {
+	ratios map[string]float64
+	mu     sync.RWMutex
+}
+
+// NewCalibratedEstimator creates an estimator starting at DefaultCharsPerToken
+func NewCalibratedEstimator() *CalibratedEstimator {
+	return &CalibratedEstimator{ratios: make(map[string]float64)}
+}
+
+// Ratio returns the current chars-per-token ratio for a model
+func (e *CalibratedEstimator) Ratio(model string) float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if r, ok := e.ratios[model]; ok {
+		return r
+	}
+	return DefaultCharsPerToken
+}
+
+// CountTokens estimates the tokens in text for a model
+func (e *CalibratedEstimator) CountTokens(model, text string) int {
+	if text == "" {
+		return 0
+	}
+	tokens := int(float64(len(text))/e.Ratio(model) + 0.5)
+	if tokens == 0 {
+		tokens = 1
+	}
+	return tokens
+}
+
+// Observe updates a model's ratio from a request of chars characters that
+// the server reported as promptTokens tokens
+func (e *CalibratedEstimator) Observe(model string, chars, promptTokens int) {
+	if chars <= 0 || promptTokens <= 0 {
+		return
+	}
+
+	observed := float64(chars) / float64(promptTokens)
+	if observed < MinCharsPerToken {
+		observed = MinCharsPerToken
+	}
+	if observed > MaxCharsPerToken {
+		observed = MaxCharsPerToken
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if current, ok := e.ratios[model]; ok {
+		e.ratios[model] = current*(1-CalibrationAlpha) + observed*CalibrationAlpha
+	} else {
+		e.ratios[model] = observed
+	}
+}
+
+// TokenCounter counts tokens using an exact tokenizer when available and the
+// calibrated estimator otherwise. Counts are cached per model and content.
+type TokenCounter struct {
+	tokenize    TokenizeFunc
+	estimator   *CalibratedEstimator
+	cache       map[string]int
+	unsupported map[string]bool      // Models the server can't tokenize for
+	retryAt     map[string]time.Time // Models backing off after a transient failure
+	mu          sync.Mutex
+}
+
+// NewTokenCounter creates a token counter. tokenize may be nil.
+func NewTokenCounter(tokenize TokenizeFunc) *TokenCounter {
+	return &TokenCounter{
+		tokenize:    tokenize,
+		estimator:   NewCalibratedEstimator(),
+		cache:       make(map[string]int),
+		unsupported: make(map[string]bool),
+		retryAt:     make(map[string]time.Time),
+	}
+}
+
+// Estimator returns the calibrated estimator used as a fallback
+func (tc *TokenCounter) Estimator() *CalibratedEstimator {
+	return tc.estimator
+}
+
+// IsExact reports whether counts for model come from the tokenizer
+func (tc *TokenCounter) IsExact(model string) bool {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.usable(model)
+}
+
+// usable reports whether the tokenizer should be asked about model; the
+// caller holds tc.mu
+func (tc *TokenCounter) usable(model string) bool {
+	return tc.tokenize != nil && !tc.unsupported[model] && !time.Now().Before(tc.retryAt[model])
+}
+
+// CountTokens returns the token count of text for a model
+func (tc *TokenCounter) CountTokens(model, text string) int {
+	if text == "" {
+		return 0
+	}
+
+	key := cacheKey(model, text)
+
+	tc.mu.Lock()
+	if n, ok := tc.cache[key]; ok {
+		tc.mu.Unlock()
+		return n
+	}
+	useTokenizer := tc.usable(model)
+	tc.mu.Unlock()
+
+	if !useTokenizer {
+		// Estimates are not cached: the ratio keeps improving
+		return tc.estimator.CountTokens(model, text)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenizeTimeout)
+	n, err := tc.tokenize(ctx, model, text)
+	cancel()
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if err != nil {
+		if errors.Is(err, ErrTokenizeUnsupported) {
+			tc.unsupported[model] = true
+		} else {
+			tc.retryAt[model] = time.Now().Add(tokenizeBackoff)
+		}
+		return tc.estimator.CountTokens(model, text)
+	}
+	if len(tc.cache) >= maxTokenCacheEntries {
+		tc.cache = make(map[string]int)
+	}
+	tc.cache[key] = n
+	return n
+}
+
+// CountMessages returns the token count of a conversation including per-message overhead
+func (tc *TokenCounter) CountMessages(model string, messages []Message) int {
+	total := 0
+	for _, m := range messages {
+		total += tc.CountTokens(model, m.Content) + MessageOverheadTok
+	}
+	return total
+}
+
+// Calibrate teaches the estimator from a sent conversation and the prompt
+// token count reported by the server
+func (tc *TokenCounter) Calibrate(model string, messages []Message, promptTokens int) {
+	chars := 0
+	for _, m := range messages {
+		chars += len(m.Content)
+	}
+	overhead := len(messages) * MessageOverheadTok
+	if promptTokens <= overhead {
+		return
+	}
+	tc.estimator.Observe(model, chars, promptTokens-overhead)
+}
+
+// cacheKey identifies a model/content pair
+func cacheKey(model, text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return model + ":" + hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,144 @@
+package context
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalibratedEstimator(t *testing.T) {
+	e := NewCalibratedEstimator()
+
+	if r := e.Ratio("llama3"); r != DefaultCharsPerToken {
+		t.Errorf("Expected default ratio %v, got %v", DefaultCharsPerToken, r)
+	}
+	if n := e.CountTokens("llama3", strings.Repeat("a", 400)); n != 100 {
+		t.Errorf("Expected 100 tokens at default ratio, got %d", n)
+	}
+	if n := e.CountTokens("llama3", "a"); n != 1 {
+		t.Errorf("Expected non-empty text to count at least 1 token, got %d", n)
+	}
+
+	// First observation sets the ratio
+	e.Observe("llama3", 3000, 1000)
+	if r := e.Ratio("llama3"); r != 3.0 {
+		t.Errorf("Expected ratio 3.0 after first observation, got %v", r)
+	}
+
+	// Later observations move it by the calibration weight
+	e.Observe("llama3", 5000, 1000)
+	expected := 3.0*(1-CalibrationAlpha) + 5.0*CalibrationAlpha
+	if r := e.Ratio("llama3"); r < expected-1e-9 || r > expected+1e-9 {
+		t.Errorf("Expected ratio %v, got %v", expected, r)
+	}
+
+	// Ratios are per model and clamped
+	e.Observe("tiny", 100, 1000)
+	if r := e.Ratio("tiny"); r != MinCharsPerToken {
+		t.Errorf("Expected clamped ratio %v, got %v", MinCharsPerToken, r)
+	}
+	if r := e.Ratio("other"); r != DefaultCharsPerToken {
+		t.Errorf("Expected untouched model to keep default ratio, got %v", r)
+	}
+
+	// Invalid observations are ignored
+	e.Observe("other", 0, 10)
+	e.Observe("other", 10, 0)
+	if r := e.Ratio("other"); r != DefaultCharsPerToken {
+		t.Errorf("Expected invalid observations to be ignored, got %v", r)
+	}
+}
+
+func TestTokenCounterUsesTokenizerAndCaches(t *testing.T) {
+	calls := 0
+	tc := NewTokenCounter(func(ctx context.Context, model, text string) (int, error) {
+		calls++
+		return len(strings.Fields(text)), nil
+	})
+
+	if n := tc.CountTokens("m", "one two three"); n != 3 {
+		t.Errorf("Expected 3 tokens, got %d", n)
+	}
+	if n := tc.CountTokens("m", "one two three"); n != 3 {
+		t.Errorf("Expected cached 3 tokens, got %d", n)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 tokenizer call with caching, got %d", calls)
+	}
+	if !tc.IsExact("m") {
+		t.Error("Expected exact counting when the tokenizer works")
+	}
+
+	msgs := []Message{{Role: "system", Content: "a b"}, {Role: "user", Content: "c"}}
+	if n := tc.CountMessages("m", msgs); n != 3+2*MessageOverheadTok {
+		t.Errorf("Expected %d tokens, got %d", 3+2*MessageOverheadTok, n)
+	}
+	if n := tc.CountTokens("m", ""); n != 0 {
+		t.Errorf("Expected 0 tokens for empty text, got %d", n)
+	}
+}
+
+func TestTokenCounterFallsBackToEstimate(t *testing.T) {
+	calls := 0
+	tc := NewTokenCounter(func(ctx context.Context, model, text string) (int, error) {
+		calls++
+		return 0, ErrTokenizeUnsupported
+	})
+
+	text := strings.Repeat("x", 40)
+	if n := tc.CountTokens("m", text); n != 10 {
+		t.Errorf("Expected estimated 10 tokens, got %d", n)
+	}
+	tc.CountTokens("m", "another text")
+	if calls != 1 {
+		t.Errorf("Expected tokenizer not to be retried after failure, got %d calls", calls)
+	}
+	if tc.IsExact("m") {
+		t.Error("Expected estimated counting after tokenizer failure")
+	}
+
+	// Calibration from server-reported prompt tokens changes the estimate
+	msgs := []Message{{Role: "user", Content: strings.Repeat("x", 200)}}
+	tc.Calibrate("m", msgs, 100+MessageOverheadTok)
+	if n := tc.CountTokens("m", text); n != 20 {
+		t.Errorf("Expected calibrated 20 tokens (2 chars/token), got %d", n)
+	}
+}
+
+func TestTokenCounterBacksOffOnTransientErrors(t *testing.T) {
+	calls := 0
+	tc := NewTokenCounter(func(ctx context.Context, model, text string) (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errors.New("connection refused")
+		}
+		return 7, nil
+	})
+
+	text := strings.Repeat("x", 40)
+	if n := tc.CountTokens("m", text); n != 10 {
+		t.Errorf("Expected estimated 10 tokens, got %d", n)
+	}
+	tc.CountTokens("m", text)
+	if calls != 1 || tc.IsExact("m") {
+		t.Errorf("Expected estimates while backing off, got %d calls", calls)
+	}
+
+	// Once the back-off expires the tokenizer is used again
+	tc.retryAt["m"] = time.Now().Add(-time.Second)
+	if n := tc.CountTokens("m", text); n != 7 || !tc.IsExact("m") {
+		t.Errorf("Expected the tokenizer to be retried, got %d tokens", n)
+	}
+}
+
+func TestTokenCounterNilTokenizer(t *testing.T) {
+	tc := NewTokenCounter(nil)
+	if tc.IsExact("m") {
+		t.Error("Expected estimate without a tokenizer")
+	}
+	if n := tc.CountTokens("m", strings.Repeat("x", 8)); n != 2 {
+		t.Errorf("Expected 2 tokens, got %d", n)
+	}
+}
@@ -0,0 +1,46 @@
+// Package display formats values for terminal, Markdown and HTML output.
+package display
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Duration renders a duration as h:mm:ss
+func Duration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+// Truncate shortens s to maxLen characters, ending it with "..."
+func Truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
+
+// OrDash returns s, or "-" when it is empty
+func OrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// OneLine collapses whitespace so text fits on a single line
+func OneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// ShortCommit abbreviates a commit SHA
+func ShortCommit(sha string) string {
+	if len(sha) > 10 {
+		return sha[:10]
+	}
+	return sha
+}
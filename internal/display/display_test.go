@@ -0,0 +1,45 @@
+package display
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0:00:00"},
+		{-time.Second, "0:00:00"},
+		{1500 * time.Millisecond, "0:00:02"},
+		{2*time.Hour + 3*time.Minute + 4*time.Second, "2:03:04"},
+		{26 * time.Hour, "26:00:00"},
+	}
+	for _, tt := range tests {
+		if got := Duration(tt.d); got != tt.want {
+			t.Errorf("Duration(%v): expected %s, got %s", tt.d, tt.want, got)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := Truncate("short", 10); got != "short" {
+		t.Errorf("expected short text unchanged, got %q", got)
+	}
+	if got := Truncate("a longer sentence", 10); got != "a longe..." {
+		t.Errorf("unexpected truncation: %q", got)
+	}
+}
+
+func TestOrDash(t *testing.T) {
+	if OrDash("") != "-" || OrDash("x") != "x" {
+		t.Error("expected a dash only for empty text")
+	}
+}
+
+func TestOneLine(t *testing.T) {
+	if got := OneLine("  go test\n\t./...  "); got != "go test ./..." {
+		t.Errorf("unexpected one-line text: %q", got)
+	}
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/logs"
+	"github.com/ai/brewol/internal/tools"
+)
+
+// account applies update to the session's usage and to the current task's
+func (e *Engine) account(update func(*logs.Usage)) {
+	taskID, title := e.currentTask()
+	e.accountTo(taskID, title, update)
+}
+
+// accountTo applies update to the session's usage and to a task's, if any
+func (e *Engine) accountTo(taskID, title string, update func(*logs.Usage)) {
+	if e.session == nil {
+		return
+	}
+
+	e.session.UpdateMetrics(func(m *logs.Metrics) {
+		update(&m.Session)
+		if taskID != "" {
+			update(&m.Task(taskID, title).Usage)
+		}
+	})
+}
+
+// currentTask returns the ID and title of the task in progress, if any
+func (e *Engine) currentTask() (string, string) {
+	if e.taskStore == nil {
+		return "", ""
+	}
+	if task := e.taskStore.GetCurrentTask(); task != nil {
+		return task.ID, task.Title
+	}
+	return "", ""
+}
+
+// accountTool adds a tool call's wall time
+func (e *Engine) accountTool(result *tools.ToolResult) {
+	e.account(func(u *logs.Usage) { u.ToolSeconds += result.Duration })
+}
+
+// accountCycle counts a completed cycle towards the task that was in progress
+// when it started, and marks accounted tasks that have since been completed
+func (e *Engine) accountCycle(taskID, title string) {
+	e.accountTo(taskID, title, func(u *logs.Usage) { u.Cycles++ })
+	if e.session == nil || e.taskStore == nil {
+		return
+	}
+
+	e.session.UpdateMetrics(func(m *logs.Metrics) {
+		for id, usage := range m.Tasks {
+			if task, ok := e.taskStore.GetTask(id); ok {
+				usage.Completed = task.Status == ctxmgr.TaskStatusCompleted
+			}
+		}
+	})
+}
+
+// Metrics returns the session's token and time accounting
+func (e *Engine) Metrics() logs.Metrics {
+	if e.session == nil {
+		return logs.Metrics{}
+	}
+	return e.session.Metrics()
+}
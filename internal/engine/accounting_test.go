@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"testing"
+
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/logs"
+	"github.com/ai/brewol/internal/tools"
+)
+
+func TestAccounting(t *testing.T) {
+	root := t.TempDir()
+	session, err := logs.NewSession(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer session.Close()
+	store, err := ctxmgr.NewTaskStore(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := &Engine{session: session, taskStore: store}
+
+	// Usage before any task is selected only counts towards the session
+	e.account(func(u *logs.Usage) { u.AddRequest(500, 100, 2, 3) })
+
+	store.AddTask(&ctxmgr.Task{ID: "t1", Title: "Fix build", Priority: ctxmgr.TaskPriorityHigh})
+	if err := e.SelectTask("t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e.account(func(u *logs.Usage) { u.AddRequest(1000, 200, 4, 5) })
+	e.accountTool(&tools.ToolResult{Duration: 1.5})
+	e.accountCycle(e.currentTask())
+
+	// The cycle that completes a task still counts towards it
+	taskID, title := e.currentTask()
+	store.SetTaskStatus("t1", ctxmgr.TaskStatusCompleted)
+	e.accountCycle(taskID, title)
+
+	m := e.Metrics()
+	if m.Session.Requests != 2 || m.Session.PromptTokens != 1500 || m.Session.ToolSeconds != 1.5 || m.Session.Cycles != 2 {
+		t.Errorf("unexpected session usage: %+v", m.Session)
+	}
+	task := m.Tasks["t1"]
+	if task == nil || task.Requests != 1 || task.Cycles != 2 || !task.Completed {
+		t.Fatalf("unexpected task usage: %+v", task)
+	}
+	if m.CyclesPerCompletedTask() != 2 {
+		t.Errorf("expected 2 cycles per completed task, got %.1f", m.CyclesPerCompletedTask())
+	}
+
+	// Without a session accounting is a no-op
+	(&Engine{}).account(func(u *logs.Usage) { u.Cycles++ })
+}
@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/ollama"
+	"github.com/ai/brewol/internal/tools"
+)
+
+const (
+	// toolOutputPrefix marks messages carrying command output
+	toolOutputPrefix = "Command output:"
+	// maxPackAttempts bounds re-packing a request whose encoding is too large
+	maxPackAttempts = 5
+)
+
+// Pin adds a workspace file to every request's pinned tier
+func (e *Engine) Pin(path string) error {
+	if err := tools.ValidatePathContainment(e.project.Root, path); err != nil {
+		return err
+	}
+
+	abs := path
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(e.project.Root, path)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return fmt.Errorf("failed to pin %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("failed to pin %s: is a directory", path)
+	}
+
+	rel, err := filepath.Rel(e.project.Root, abs)
+	if err != nil {
+		return fmt.Errorf("failed to pin %s: %w", path, err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, p := range e.pins {
+		if p == rel {
+			return nil
+		}
+	}
+	e.pins = append(e.pins, rel)
+	return nil
+}
+
+// Unpin removes a pinned file, reporting whether it was pinned
+func (e *Engine) Unpin(path string) bool {
+	rel := filepath.Clean(path)
+	if filepath.IsAbs(rel) {
+		if r, err := filepath.Rel(e.project.Root, rel); err == nil {
+			rel = r
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, p := range e.pins {
+		if p == rel {
+			e.pins = append(e.pins[:i], e.pins[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Pins returns the pinned files
+func (e *Engine) Pins() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	pins := make([]string, len(e.pins))
+	copy(pins, e.pins)
+	return pins
+}
+
+// requestBudget returns the prompt token budget for the next request: up to
+// the high watermark, leaving room for output and within the request size limit
+func (e *Engine) requestBudget(model string) int {
+	cfg := e.budgetMgr.GetConfig()
+
+	budget := int(float64(cfg.NumCtx) * cfg.HighWatermarkRatio)
+	if limit := cfg.NumCtx - cfg.ReserveOutputTokens; limit < budget {
+		budget = limit
+	}
+
+	// Leave ~10% of the byte limit for JSON encoding overhead
+	ratio := e.tokenCounter.Estimator().Ratio(model)
+	if limit := int(float64(ollama.MaxRequestBytes) * 0.9 / ratio); limit < budget {
+		budget = limit
+	}
+	return budget
+}
+
+// contextItems splits the conversation and supporting context into tiers
+func (e *Engine) contextItems() []ctxmgr.ContextItem {
+	var items []ctxmgr.ContextItem
+
+	if len(e.messages) > 0 {
+		items = append(items, ctxmgr.ContextItem{
+			Tier: ctxmgr.TierSystem, Label: "system prompt", Role: e.messages[0].Role, Content: e.messages[0].Content,
+		})
+	}
+
+	if text := e.memoryMgr.GetWorkingMemoryText(); text != "" {
+		items = append(items, ctxmgr.ContextItem{
+			Tier: ctxmgr.TierMemory, Label: "working memory", Role: "system", Content: text,
+		})
+	}
+	if text := e.memoryMgr.GetRelevantKnowledgeText(e.knowledgeQuery()); text != "" {
+		items = append(items, ctxmgr.ContextItem{
+			Tier: ctxmgr.TierMemory, Label: "knowledge", Role: "system", Content: text,
+		})
+	}
+
+	if e.relevantCode != "" {
+		items = append(items, ctxmgr.ContextItem{
+			Tier: ctxmgr.TierMemory, Label: "relevant code", Role: "system", Content: e.relevantCode,
+		})
+	}
+
+	if e.baseline != nil {
+		items = append(items, ctxmgr.ContextItem{
+			Tier: ctxmgr.TierMemory, Label: "verification baseline", Role: "system", Content: e.baseline.Summary(),
+		})
+	}
+
+	if e.taskStore.Count() > 0 {
+		items = append(items, ctxmgr.ContextItem{
+			Tier: ctxmgr.TierTaskBrief, Label: "task brief", Role: "system", Content: e.GetTaskBrief(ctxmgr.TaskBriefNormal).Format(),
+		})
+	}
+
+	for _, pin := range e.Pins() {
+		content, err := os.ReadFile(filepath.Join(e.project.Root, pin))
+		if err != nil {
+			continue
+		}
+		items = append(items, ctxmgr.ContextItem{
+			Tier:    ctxmgr.TierPinned,
+			Label:   "pin:" + pin,
+			Role:    "user",
+			Content: fmt.Sprintf("Pinned file: %s\n```\n%s\n```", pin, strings.TrimRight(string(content), "\n")),
+		})
+	}
+
+	if len(e.messages) <= 1 {
+		return items
+	}
+
+	// Tool output after the last model reply is "latest"; the final message is
+	// the current observation
+	lastAssistant := -1
+	for i, m := range e.messages {
+		if m.Role == "assistant" {
+			lastAssistant = i
+		}
+	}
+
+	last := len(e.messages) - 1
+	for i := 1; i < last; i++ {
+		m := e.messages[i]
+		tier := ctxmgr.TierTranscript
+		label := fmt.Sprintf("%s message #%d", m.Role, i)
+		if strings.HasPrefix(m.Content, toolOutputPrefix) {
+			label = fmt.Sprintf("tool output #%d", i)
+			if i > lastAssistant {
+				tier = ctxmgr.TierToolResults
+			}
+		}
+		items = append(items, ctxmgr.ContextItem{Tier: tier, Label: label, Role: m.Role, Content: m.Content})
+	}
+
+	items = append(items, ctxmgr.ContextItem{
+		Tier: ctxmgr.TierSystem, Label: "observation", Role: e.messages[last].Role, Content: e.messages[last].Content,
+	})
+	return items
+}
+
+// assembleRequest packs the next request into the context budget. The byte
+// limit in the budget is only an estimate, so while the encoded request is
+// over ollama.MaxRequestBytes (JSON escaping can more than double the size of
+// quotes, newlines and control characters) it is packed again into a budget
+// shrunk by the overshoot.
+func (e *Engine) assembleRequest(model string) *ctxmgr.Assembly {
+	assembler := ctxmgr.NewAssembler(e.tokenCounter, model)
+	items := e.contextItems()
+	budget := e.requestBudget(model)
+	assembly := assembler.Pack(items, budget)
+	for attempt := 0; attempt < maxPackAttempts; attempt++ {
+		size := e.client.RequestSize(fromContextMessages(assembly.Messages()))
+		if size <= ollama.MaxRequestBytes || assembly.TokensAfter == 0 {
+			break
+		}
+		budget = int(float64(min(budget, assembly.TokensAfter)) * float64(ollama.MaxRequestBytes) / float64(size) * 0.95)
+		assembly = assembler.Pack(items, budget)
+	}
+	return assembly
+}
@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/memory"
+	"github.com/ai/brewol/internal/ollama"
+	"github.com/ai/brewol/internal/repo"
+)
+
+func TestPinUnpin(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644)
+	os.Mkdir(filepath.Join(root, "pkg"), 0755)
+
+	e := &Engine{project: &repo.Project{Root: root}}
+
+	if err := e.Pin("main.go"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := e.Pin(filepath.Join(root, "main.go")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pins := e.Pins(); len(pins) != 1 || pins[0] != "main.go" {
+		t.Errorf("expected [main.go], got %v", pins)
+	}
+
+	if err := e.Pin("missing.go"); err == nil {
+		t.Error("expected error pinning a missing file")
+	}
+	if err := e.Pin("pkg"); err == nil {
+		t.Error("expected error pinning a directory")
+	}
+	if err := e.Pin("../outside.go"); err == nil {
+		t.Error("expected error pinning outside the workspace")
+	}
+
+	if !e.Unpin("main.go") {
+		t.Error("expected main.go to be unpinned")
+	}
+	if e.Unpin("main.go") {
+		t.Error("expected second unpin to report false")
+	}
+}
+
+func TestContextItemsTiers(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644)
+
+	memoryMgr, err := memory.NewManager(memory.DefaultConfig(root))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer memoryMgr.Close()
+
+	taskStore, err := ctxmgr.NewTaskStore(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := &Engine{
+		project:   &repo.Project{Root: root},
+		memoryMgr: memoryMgr,
+		taskStore: taskStore,
+		messages: []ollama.Message{
+			{Role: "system", Content: "system prompt"},
+			{Role: "user", Content: "Goal: first"},
+			{Role: "assistant", Content: "RUN: ls"},
+			{Role: "user", Content: toolOutputPrefix + "\nold output"},
+			{Role: "user", Content: "Goal: second"},
+			{Role: "assistant", Content: "RUN: go test ./..."},
+			{Role: "user", Content: toolOutputPrefix + "\nnew output"},
+			{Role: "user", Content: "Goal: third"},
+		},
+		relevantCode: "## RELEVANT CODE\n",
+	}
+	if err := e.Pin("main.go"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		tier  ctxmgr.Tier
+		label string
+	}{
+		{ctxmgr.TierSystem, "system prompt"},
+		{ctxmgr.TierMemory, "relevant code"},
+		{ctxmgr.TierPinned, "pin:main.go"},
+		{ctxmgr.TierTranscript, "user message #1"},
+		{ctxmgr.TierTranscript, "assistant message #2"},
+		{ctxmgr.TierTranscript, "tool output #3"},
+		{ctxmgr.TierTranscript, "user message #4"},
+		{ctxmgr.TierTranscript, "assistant message #5"},
+		{ctxmgr.TierToolResults, "tool output #6"},
+		{ctxmgr.TierSystem, "observation"},
+	}
+
+	items := e.contextItems()
+	if len(items) != len(expected) {
+		t.Fatalf("expected %d items, got %d", len(expected), len(items))
+	}
+	for i, want := range expected {
+		if items[i].Tier != want.tier || items[i].Label != want.label {
+			t.Errorf("item %d: expected %s %q, got %s %q", i, want.tier, want.label, items[i].Tier, items[i].Label)
+		}
+	}
+}
+
+func TestAssembleRequestFitsEncodedSize(t *testing.T) {
+	root := t.TempDir()
+	memoryMgr, err := memory.NewManager(memory.DefaultConfig(root))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer memoryMgr.Close()
+	taskStore, err := ctxmgr.NewTaskStore(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := ollama.NewClient()
+	client.SetModel("test-model")
+	e := &Engine{
+		project:      &repo.Project{Root: root},
+		client:       client,
+		memoryMgr:    memoryMgr,
+		taskStore:    taskStore,
+		budgetMgr:    ctxmgr.NewBudgetManager(ctxmgr.DefaultBudgetConfig()),
+		tokenCounter: ctxmgr.NewTokenCounter(nil),
+		messages:     []ollama.Message{{Role: "system", Content: "system prompt"}},
+	}
+
+	// Control characters and quotes take up to six bytes each once escaped
+	escaped := strings.Repeat("\"\x01\n\\", 2000)
+	for i := 0; i < 6; i++ {
+		e.messages = append(e.messages,
+			ollama.Message{Role: "assistant", Content: "RUN: cat data.bin"},
+			ollama.Message{Role: "user", Content: toolOutputPrefix + "\n" + escaped},
+		)
+	}
+	e.messages = append(e.messages, ollama.Message{Role: "user", Content: "Goal: parse it"})
+
+	first := ctxmgr.NewAssembler(e.tokenCounter, "test-model").Pack(e.contextItems(), e.requestBudget("test-model"))
+	if size := client.RequestSize(fromContextMessages(first.Messages())); size <= ollama.MaxRequestBytes {
+		t.Fatalf("expected the token budget alone to overshoot, got %d bytes", size)
+	}
+
+	assembly := e.assembleRequest("test-model")
+	if size := client.RequestSize(fromContextMessages(assembly.Messages())); size > ollama.MaxRequestBytes {
+		t.Errorf("expected the encoded request to fit %d bytes, got %d", ollama.MaxRequestBytes, size)
+	}
+	if !assembly.Compacted() {
+		t.Error("expected older tool output to be dropped")
+	}
+}
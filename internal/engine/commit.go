@@ -0,0 +1,264 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ai/brewol/internal/checkpoint"
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/repo"
+	"github.com/ai/brewol/internal/tools"
+)
+
+const (
+	maxCommitFiles   = 20    // Files listed in a checkpoint message
+	maxSummaryDiff   = 12000 // Diff characters sent to the summary model
+	maxSummaryLength = 600
+)
+
+// buildFiles are files whose changes are build changes
+var buildFiles = map[string]bool{
+	"go.mod": true, "go.sum": true, "go.work": true, "Makefile": true, "CMakeLists.txt": true,
+	"package.json": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"Cargo.toml": true, "Cargo.lock": true, "pyproject.toml": true, "requirements.txt": true,
+	"Gemfile": true, "Gemfile.lock": true, "Dockerfile": true,
+}
+
+// checkpointMessage builds the commit message for the workspace's changes:
+// a subject and summary from the summary model, the files and symbols
+// touched, tasks completed since the last commit and verification results.
+// The subject follows Conventional Commits when the repository does.
+func (e *Engine) checkpointMessage(ctx context.Context, message string) string {
+	root := e.project.Root
+	var diff string
+	var changes []repo.FileChange
+	if err := tools.StageAll(root); err == nil {
+		diff, _ = tools.GetDiff(root, "--cached", "--find-renames")
+		changes = repo.SummarizeDiff(diff)
+	}
+	tasks := e.completedTasks(tools.GetHeadTime(root))
+
+	subject, summary := message, ""
+	if len(tasks) == 1 {
+		subject = tasks[0].Title
+	}
+	if s, body := e.summarizeChange(ctx, diff, changes, tasks); s != "" {
+		subject, summary = s, body
+	}
+	msg := checkpoint.Message{
+		Subject:      subject,
+		Summary:      summary,
+		Cycle:        e.cycleCount,
+		Objective:    e.objective,
+		Verification: e.checkpointVerification(),
+		Checkpoint:   true,
+	}
+	if e.useConventional() {
+		msg.Prefix = conventionalType(changes, tasks) + conventionalScope(changes) + ": "
+	}
+	for i, c := range changes {
+		if i == maxCommitFiles {
+			msg.Files = append(msg.Files, fmt.Sprintf("... (%d more)", len(changes)-i))
+			break
+		}
+		msg.Files = append(msg.Files, c.String())
+	}
+	for _, task := range tasks {
+		msg.Tasks = append(msg.Tasks, task.Title)
+	}
+	return msg.String()
+}
+
+// completedTasks returns the tasks completed after a time, oldest first
+func (e *Engine) completedTasks(since time.Time) []*ctxmgr.Task {
+	if e.taskStore == nil {
+		return nil
+	}
+	var tasks []*ctxmgr.Task
+	for _, task := range e.taskStore.GetAllTasks() {
+		if task.Status == ctxmgr.TaskStatusCompleted && task.CompletedAt != nil && task.CompletedAt.After(since) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].CompletedAt.Before(*tasks[j].CompletedAt)
+	})
+	return tasks
+}
+
+// checkpointVerification describes the pre-checkpoint verification and its
+// delta from the baseline, consuming both
+func (e *Engine) checkpointVerification() string {
+	var parts []string
+	for _, result := range e.checkpointRuns {
+		parts = append(parts, result.Name+" "+verdict(result.Success))
+	}
+	s := strings.Join(parts, ", ")
+	if e.checkpointDiff != "" {
+		if s != "" {
+			s += "; "
+		}
+		s += e.checkpointDiff
+	}
+	e.checkpointRuns, e.checkpointDiff = nil, ""
+	return s
+}
+
+// summarizeChange asks the summary model for a subject line and a short
+// summary of the staged diff, returning "" when disabled or on failure
+func (e *Engine) summarizeChange(ctx context.Context, diff string, changes []repo.FileChange, tasks []*ctxmgr.Task) (string, string) {
+	cfg := e.config()
+	if !cfg.Checkpoint.Summary || e.summarize == nil || diff == "" {
+		return "", ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Write a commit message for the change below.\n")
+	sb.WriteString("Line 1: a subject of at most 60 characters in the imperative mood (\"Add ...\", \"Fix ...\"), with no type prefix and no trailing period.\n")
+	sb.WriteString("Then a blank line and one to three sentences on what changed and why.\n")
+	sb.WriteString("Reply with the commit message only.\n\n")
+	if e.objective != "" {
+		sb.WriteString("Objective: " + e.objective + "\n")
+	}
+	for _, task := range tasks {
+		sb.WriteString("Completed task: " + task.Title + "\n")
+	}
+	sb.WriteString("Files:\n")
+	for _, c := range changes {
+		sb.WriteString("- " + c.String() + "\n")
+	}
+	if len(diff) > maxSummaryDiff {
+		diff = diff[:maxSummaryDiff] + "\n... (diff truncated)"
+	}
+	sb.WriteString("\nDiff:\n" + diff)
+
+	if cfg.Memory.SummaryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Memory.SummaryTimeout)
+		defer cancel()
+	}
+	reply, err := e.summarize(ctx, sb.String())
+	if err != nil {
+		e.sendUpdate(CycleUpdate{State: StateCommitting, Message: fmt.Sprintf("Commit summary failed: %v", err)})
+		return "", ""
+	}
+	return parseCommitReply(reply)
+}
+
+// parseCommitReply splits a model-written commit message into a subject and
+// summary, dropping code fences and any type prefix the model added
+func parseCommitReply(reply string) (string, string) {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(reply), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "```") {
+			lines = append(lines, strings.TrimRight(line, " \t"))
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return "", ""
+	}
+
+	subject := strings.Trim(strings.TrimSpace(lines[0]), `"'`+"`")
+	subject = strings.TrimSpace(strings.TrimPrefix(subject, "Subject:"))
+	subject = checkpoint.ConventionalPrefix.ReplaceAllString(subject, "")
+	subject = truncateString(strings.TrimSuffix(subject, "."), checkpoint.MaxSubjectLength)
+	summary := strings.TrimSpace(strings.Join(lines[1:], "\n"))
+	return subject, truncateString(summary, maxSummaryLength)
+}
+
+// useConventional reports whether checkpoint subjects follow Conventional
+// Commits: always, never, or when most recent commits do
+func (e *Engine) useConventional() bool {
+	switch e.config().Checkpoint.Conventional {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	subjects := tools.GetRecentSubjects(e.project.Root, 20)
+	matches := 0
+	for _, subject := range subjects {
+		if checkpoint.ConventionalPrefix.MatchString(subject) {
+			matches++
+		}
+	}
+	return len(subjects) > 0 && matches*2 > len(subjects)
+}
+
+// conventionalType picks the Conventional Commits type of a change from the
+// kinds of files touched and the tasks completed
+func conventionalType(changes []repo.FileChange, tasks []*ctxmgr.Task) string {
+	kinds := make(map[string]bool)
+	added := false
+	for _, c := range changes {
+		kinds[fileKind(c.Path)] = true
+		added = added || (c.Status == "A" && fileKind(c.Path) == "")
+	}
+	if len(kinds) == 1 {
+		for kind := range kinds {
+			if kind != "" {
+				return kind
+			}
+		}
+	}
+
+	categories := make(map[ctxmgr.TaskCategory]bool)
+	for _, task := range tasks {
+		categories[task.Category] = true
+	}
+	switch {
+	case categories[ctxmgr.TaskCategoryBuild] || categories[ctxmgr.TaskCategoryTest] || categories[ctxmgr.TaskCategoryFixme]:
+		return "fix"
+	case categories[ctxmgr.TaskCategoryCoverage]:
+		return "test"
+	case categories[ctxmgr.TaskCategoryRefactor]:
+		return "refactor"
+	case added || categories[ctxmgr.TaskCategoryGoal]:
+		return "feat"
+	}
+	return "chore"
+}
+
+// fileKind classifies a path as test, docs, ci or build, or "" for code
+func fileKind(file string) string {
+	base := path.Base(file)
+	switch {
+	case strings.HasPrefix(file, ".github/") || strings.HasPrefix(file, ".gitlab-ci") || strings.HasPrefix(file, ".circleci/"):
+		return "ci"
+	case buildFiles[base]:
+		return "build"
+	case strings.HasSuffix(base, "_test.go") || strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py") ||
+		strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		strings.HasPrefix(file, "tests/") || strings.HasPrefix(file, "test/") || strings.Contains(file, "/tests/"):
+		return "test"
+	case strings.HasSuffix(base, ".md") || strings.HasSuffix(base, ".rst") || strings.HasPrefix(file, "docs/"):
+		return "docs"
+	}
+	return ""
+}
+
+// conventionalScope returns "(dir)" when all changed files share a directory,
+// named by its last element
+func conventionalScope(changes []repo.FileChange) string {
+	if len(changes) == 0 {
+		return ""
+	}
+	common := path.Dir(changes[0].Path)
+	for _, c := range changes[1:] {
+		dir := path.Dir(c.Path)
+		for common != "." && dir != common && !strings.HasPrefix(dir, common+"/") {
+			common = path.Dir(common)
+		}
+	}
+	if common == "." {
+		return ""
+	}
+	return "(" + path.Base(common) + ")"
+}
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/repo"
+)
+
+func TestCheckpointMessage(t *testing.T) {
+	root := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.email=a@example.com", "-c", "user.name=a"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	os.MkdirAll(filepath.Join(root, "internal", "api"), 0755)
+	os.WriteFile(filepath.Join(root, "internal", "api", "server.go"), []byte("package api\n\nfunc Serve() {\n\treturn\n}\n"), 0644)
+	for _, subject := range []string{"feat: add server", "fix(api): handle nil", "Update readme"} {
+		git("add", "-A")
+		git("commit", "-q", "--allow-empty", "-m", subject)
+	}
+
+	store, err := ctxmgr.NewTaskStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.AddTask(&ctxmgr.Task{ID: "t1", Title: "Fix failing test: TestServe", Category: ctxmgr.TaskCategoryTest})
+	store.SetTaskStatus("t1", ctxmgr.TaskStatusCompleted)
+
+	os.WriteFile(filepath.Join(root, "internal", "api", "server.go"), []byte("package api\n\nfunc Serve() {\n\tlisten()\n}\n\nfunc listen() {}\n"), 0644)
+
+	var prompt string
+	e := &Engine{
+		project:        &repo.Project{Root: root},
+		taskStore:      store,
+		objective:      "Fix the server",
+		cycleCount:     4,
+		checkpointRuns: []*repo.VerificationResult{{Name: "build", Success: true}, {Name: "test", Success: true}},
+		checkpointDiff: "fixed tests (1): TestServe",
+		summarize: func(ctx context.Context, p string) (string, error) {
+			prompt = p
+			return "```\nfix: Start listening in Serve.\n\nServe now calls listen so the server accepts connections.\n```", nil
+		},
+	}
+
+	want := `fix(api): start listening in Serve
+
+Serve now calls listen so the server accepts connections.
+
+Files:
+- M internal/api/server.go (+3 -1): Serve, listen
+
+Tasks completed:
+- Fix failing test: TestServe
+
+Cycle: 4
+Objective: Fix the server
+Verification: build PASSED, test PASSED; fixed tests (1): TestServe`
+	if got := e.checkpointMessage(context.Background(), "Manual checkpoint"); got != want {
+		t.Errorf("unexpected message:\n%s", got)
+	}
+	if !strings.Contains(prompt, "+\tlisten()") || !strings.Contains(prompt, "Completed task: Fix failing test: TestServe") {
+		t.Errorf("expected the prompt to include the diff and tasks, got %q", prompt)
+	}
+	if e.checkpointRuns != nil || e.checkpointDiff != "" {
+		t.Error("expected the verification results to be consumed")
+	}
+
+	// Without a summary or a conventional history, the subject keeps the old form
+	e.summarize = func(ctx context.Context, p string) (string, error) { return "", errors.New("model unavailable") }
+	e.taskStore = nil
+	git("commit", "-q", "-m", "Serve connections")
+	git("commit", "-q", "--allow-empty", "-m", "Tidy up")
+	os.WriteFile(filepath.Join(root, "NOTES.md"), []byte("notes\n"), 0644)
+	got := e.checkpointMessage(context.Background(), "Manual checkpoint")
+	if !strings.HasPrefix(got, "[brewol] Manual checkpoint\n\nFiles:\n- A NOTES.md (+1 -0)\n\nCycle: 4") {
+		t.Errorf("unexpected fallback message:\n%s", got)
+	}
+}
+
+func TestConventionalType(t *testing.T) {
+	changes := func(paths ...string) []repo.FileChange {
+		var c []repo.FileChange
+		for _, p := range paths {
+			c = append(c, repo.FileChange{Path: p, Status: "M"})
+		}
+		return c
+	}
+	for _, tt := range []struct {
+		changes []repo.FileChange
+		tasks   []*ctxmgr.Task
+		want    string
+	}{
+		{changes("README.md", "docs/guide.md"), nil, "docs"},
+		{changes("pkg/a_test.go", "tests/test_b.py"), nil, "test"},
+		{changes("go.mod", "go.sum"), nil, "build"},
+		{changes("pkg/a.go", "pkg/a_test.go"), []*ctxmgr.Task{{Category: ctxmgr.TaskCategoryFixme}}, "fix"},
+		{[]repo.FileChange{{Path: "pkg/new.go", Status: "A"}}, nil, "feat"},
+		{changes("pkg/a.go"), nil, "chore"},
+	} {
+		if got := conventionalType(tt.changes, tt.tasks); got != tt.want {
+			t.Errorf("conventionalType(%v) = %s, want %s", tt.changes, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/ollama"
+	"github.com/ai/brewol/internal/repo"
+	"github.com/ai/brewol/internal/tools"
+)
+
+// coverageTaskPrefix prefixes the IDs of tasks queued by coverage mode
+const coverageTaskPrefix = "coverage:"
+
+// StartCoverage switches to coverage-guided test writing: coverage is
+// measured, the most valuable under-tested units are queued as tasks and,
+// without a goal, raising coverage becomes the goal. From then on every
+// checkpoint must raise coverage.
+func (e *Engine) StartCoverage(ctx context.Context) (*repo.CoverageReport, error) {
+	e.setState(StateVerifying)
+	e.sendUpdate(CycleUpdate{State: StateVerifying, Message: "Measuring test coverage..."})
+
+	report, err := e.measureCoverage(ctx)
+	if err != nil {
+		e.sendUpdate(CycleUpdate{State: StateVerifying, Error: err, Message: err.Error()})
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.coverage = report
+	goal := e.goal
+	e.mu.Unlock()
+
+	e.queueCoverageTasks(report)
+	if goal == "" {
+		e.SetGoal(fmt.Sprintf("Raise test coverage (now %.1f%%) by writing tests for the under-tested units in the task list, without breaking existing tests", report.Percent))
+	}
+	e.sendUpdate(CycleUpdate{State: StateVerifying, Message: fmt.Sprintf("Coverage mode: %.1f%% covered, %d under-tested units", report.Percent, len(report.Units))})
+	return report, nil
+}
+
+// StopCoverage leaves coverage mode. Queued tasks are kept.
+func (e *Engine) StopCoverage() {
+	e.mu.Lock()
+	e.coverage = nil
+	e.mu.Unlock()
+}
+
+// Coverage returns the coverage at the last checkpoint, or nil outside
+// coverage mode
+func (e *Engine) Coverage() *repo.CoverageReport {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.coverage
+}
+
+// measureCoverage runs the tests with coverage and logs the run. The suite
+// only runs again once the workspace has changed since the last measurement.
+func (e *Engine) measureCoverage(ctx context.Context) (*repo.CoverageReport, error) {
+	key := tools.GetHeadCommit(e.project.Root) + " " + changeFingerprint(e.project.Root, tools.GetDirtyFiles(e.project.Root))
+	if e.measured != nil && e.measuredAt == key {
+		return e.measured, nil
+	}
+
+	report, err := e.verifier.Coverage(ctx)
+	if report != nil {
+		e.logVerification("coverage", report.Result)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure coverage: %w", err)
+	}
+	e.measured, e.measuredAt = report, key
+	return report, nil
+}
+
+// queueCoverageTasks tops up the open coverage tasks with the highest
+// ranked under-tested units
+func (e *Engine) queueCoverageTasks(report *repo.CoverageReport) {
+	open := 0
+	for _, t := range e.taskStore.GetTasksByCategory(ctxmgr.TaskCategoryCoverage) {
+		if t.Status == ctxmgr.TaskStatusPending || t.Status == ctxmgr.TaskStatusInProgress {
+			open++
+		}
+	}
+
+	now := time.Now()
+	for i, unit := range report.Units {
+		if open >= e.config().Coverage.MaxTasks {
+			break
+		}
+		id := coverageTaskPrefix + unit.Key()
+		if t, ok := e.taskStore.GetTask(id); ok && (t.Status == ctxmgr.TaskStatusPending || t.Status == ctxmgr.TaskStatusInProgress) {
+			continue
+		}
+
+		name := unit.File
+		if unit.Name != "" {
+			name = unit.Name + " in " + unit.File
+		}
+		description := unit.Describe()
+		if len(unit.Lines) > 0 {
+			description += "\nUncovered lines: " + lineRanges(unit.Lines, 10)
+		}
+		err := e.taskStore.AddTask(&ctxmgr.Task{
+			ID:          id,
+			Title:       "Write tests for " + name,
+			Description: description,
+			Priority:    ctxmgr.TaskPriorityMedium,
+			Category:    ctxmgr.TaskCategoryCoverage,
+			Files:       []string{unit.File},
+			NextAction:  "Add tests that exercise the uncovered lines, then run the tests",
+			Source:      "coverage",
+			CreatedAt:   now.Add(time.Duration(i)), // Tasks of equal priority keep the coverage ranking
+		})
+		if err != nil {
+			e.sendUpdate(CycleUpdate{State: StateVerifying, Error: err, Message: fmt.Sprintf("Failed to queue coverage task: %v", err)})
+			return
+		}
+		open++
+	}
+}
+
+// verifyCoverageIncrease measures coverage before a checkpoint in coverage
+// mode and returns an error unless it rose. Tasks of units that gained
+// coverage are completed and the next units are queued.
+func (e *Engine) verifyCoverageIncrease(ctx context.Context) error {
+	baseline := e.Coverage()
+	if baseline == nil || len(tools.GetDirtyFiles(e.project.Root)) == 0 {
+		return nil
+	}
+
+	e.setState(StateVerifying)
+	e.sendUpdate(CycleUpdate{State: StateVerifying, Message: "Measuring coverage before checkpoint..."})
+	report, err := e.measureCoverage(ctx)
+	if err != nil {
+		return fmt.Errorf("checkpoint blocked: %w", err)
+	}
+	e.reportCoverage(baseline, report)
+	if report.Percent <= baseline.Percent {
+		return fmt.Errorf("checkpoint blocked: coverage did not increase (%.2f%% -> %.2f%%)", baseline.Percent, report.Percent)
+	}
+
+	for _, t := range e.taskStore.GetTasksByCategory(ctxmgr.TaskCategoryCoverage) {
+		if t.Status != ctxmgr.TaskStatusPending && t.Status != ctxmgr.TaskStatusInProgress {
+			continue
+		}
+		key := strings.TrimPrefix(t.ID, coverageTaskPrefix)
+		before, wasUnder := baseline.Unit(key)
+		after, stillUnder := report.Unit(key)
+		if !stillUnder || (wasUnder && after.Uncovered < before.Uncovered) {
+			e.taskStore.SetTaskStatus(t.ID, ctxmgr.TaskStatusCompleted)
+		}
+	}
+
+	e.mu.Lock()
+	e.coverage = report
+	e.mu.Unlock()
+	e.queueCoverageTasks(report)
+	e.sendUpdate(CycleUpdate{State: StateVerifying, Message: fmt.Sprintf("Coverage %.1f%% -> %.1f%%", baseline.Percent, report.Percent)})
+	return nil
+}
+
+// reportCoverage tells the model how coverage moved since the last
+// checkpoint, as the progress signal of coverage mode
+func (e *Engine) reportCoverage(baseline, report *repo.CoverageReport) {
+	content := fmt.Sprintf("Coverage: %.1f%% (%+.1f points since the last checkpoint at %.1f%%; a checkpoint needs an increase), %d under-tested units",
+		report.Percent, report.Percent-baseline.Percent, baseline.Percent, len(report.Units))
+	e.messages = append(e.messages, ollama.Message{Role: "user", Content: toolOutputPrefix + "\n" + content})
+}
+
+// lineRanges formats sorted line numbers as ranges, e.g. "3-5, 9", listing
+// at most limit ranges
+func lineRanges(lines []int, limit int) string {
+	var ranges []string
+	for i := 0; i < len(lines); {
+		j := i
+		for j+1 < len(lines) && lines[j+1] == lines[j]+1 {
+			j++
+		}
+		if len(ranges) == limit {
+			ranges = append(ranges, "...")
+			break
+		}
+		if i == j {
+			ranges = append(ranges, strconv.Itoa(lines[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", lines[i], lines[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ranges, ", ")
+}
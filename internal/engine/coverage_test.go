@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/repo"
+)
+
+func TestCoverageMode(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":   ".brewol/\n",
+		"go.mod":       "module example.com/calc\n\ngo 1.21\n",
+		"calc.go":      "package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n\nfunc Clamp(v, lo, hi int) int {\n\tif v < lo {\n\t\treturn lo\n\t}\n\treturn v\n}\n",
+		"calc_test.go": "package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tif Add(1, 2) != 3 {\n\t\tt.Fatal(\"wrong sum\")\n\t}\n}\n",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(root, name), []byte(content), 0644)
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "-A"}, {"-c", "user.email=t@t", "-c", "user.name=t", "commit", "-qm", "init"}} {
+		if out, err := exec.Command("git", append([]string{"-C", root}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	store, err := ctxmgr.NewTaskStore(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	project := repo.DetectProject(root)
+	e := &Engine{
+		project:   project,
+		verifier:  repo.NewVerifier(project),
+		taskStore: store,
+		updates:   make(chan CycleUpdate, 100),
+	}
+
+	report, err := e.StartCoverage(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	task, ok := store.GetTask("coverage:calc.go:Clamp")
+	if !ok || task.Category != ctxmgr.TaskCategoryCoverage || !strings.Contains(task.Description, "Uncovered lines: 8-11") {
+		t.Fatalf("expected a coverage task for Clamp, got %+v", task)
+	}
+	if !strings.Contains(e.goal, "Raise test coverage") {
+		t.Errorf("expected coverage mode to set the goal, got %q", e.goal)
+	}
+
+	// A change that adds no coverage can't be checkpointed
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("x"), 0644)
+	if err := e.verifyCoverageIncrease(context.Background()); err == nil || !strings.Contains(err.Error(), "coverage did not increase") {
+		t.Fatalf("expected the checkpoint to be blocked, got %v", err)
+	}
+
+	// Progress is reported to the model
+	if len(e.messages) != 1 || !strings.Contains(e.messages[0].Content, "+0.0 points") {
+		t.Errorf("expected coverage progress feedback, got %v", e.messages)
+	}
+
+	// An unchanged workspace reuses the last measurement
+	measured := e.measured
+	if again, err := e.measureCoverage(context.Background()); err != nil || again != measured {
+		t.Errorf("expected the cached measurement, got %p (%v), want %p", again, err, measured)
+	}
+
+	os.WriteFile(filepath.Join(root, "clamp_test.go"), []byte("package calc\n\nimport \"testing\"\n\nfunc TestClamp(t *testing.T) {\n\tif Clamp(-1, 0, 5) != 0 || Clamp(3, 0, 5) != 3 {\n\t\tt.Fatal(\"wrong clamp\")\n\t}\n}\n"), 0644)
+	if err := e.verifyCoverageIncrease(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.measured == measured {
+		t.Error("expected a changed workspace to be measured again")
+	}
+	if e.Coverage().Percent <= report.Percent {
+		t.Errorf("expected the baseline to advance, got %.1f%%", e.Coverage().Percent)
+	}
+	if task, _ := store.GetTask("coverage:calc.go:Clamp"); task.Status != ctxmgr.TaskStatusCompleted {
+		t.Errorf("expected the Clamp task to be completed, got %s", task.Status)
+	}
+
+	e.StopCoverage()
+	if err := e.verifyCoverageIncrease(context.Background()); err != nil || e.Coverage() != nil {
+		t.Errorf("expected no coverage gate outside coverage mode, got %v", err)
+	}
+}
+
+func TestLineRanges(t *testing.T) {
+	if got := lineRanges([]int{3, 4, 5, 9, 11, 12}, 10); got != "3-5, 9, 11-12" {
+		t.Errorf("unexpected ranges: %s", got)
+	}
+	if got := lineRanges([]int{1, 3, 5}, 2); got != "1, 3, ..." {
+		t.Errorf("unexpected truncated ranges: %s", got)
+	}
+}
@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/logs"
+	"github.com/ai/brewol/internal/memory"
+	"github.com/ai/brewol/internal/ollama"
+	"github.com/ai/brewol/internal/tools"
+)
+
+// stateFile holds the resumable engine state inside a session's log directory
+const stateFile = "state.json"
+
+// maxLegacyMessages caps the transcript restored from sessions without a state file
+const maxLegacyMessages = 20
+
+// sessionState is the engine state needed to resume a session
+type sessionState struct {
+	SessionID     string                `json:"session_id"`
+	Goal          string                `json:"goal"`
+	Objective     string                `json:"objective,omitempty"`
+	CurrentTaskID string                `json:"current_task_id,omitempty"`
+	Branch        string                `json:"branch,omitempty"`
+	Pins          []string              `json:"pins,omitempty"`
+	CycleCount    int                   `json:"cycle_count"`
+	Messages      []ollama.Message      `json:"messages"` // Compacted transcript without the system prompt
+	Memory        *memory.WorkingMemory `json:"working_memory,omitempty"`
+	SavedAt       time.Time             `json:"saved_at"`
+}
+
+// saveState writes a resumable snapshot of the engine to the session directory
+func (e *Engine) saveState() error {
+	if e.session == nil {
+		return nil
+	}
+
+	e.mu.RLock()
+	goal, objective, cycles := e.goal, e.objective, e.cycleCount
+	e.mu.RUnlock()
+
+	// Keep the transcript compact: the same window trimContext keeps, with a
+	// note summarising what was dropped
+	var transcript []ctxmgr.Message
+	if len(e.messages) > 1 {
+		transcript = toContextMessages(e.messages[1:])
+	}
+	compacted, summary := e.compactor.CompactTranscript(transcript, false)
+	messages := make([]ollama.Message, 0, len(compacted)+1)
+	if summary != "" {
+		messages = append(messages, ollama.Message{Role: "user", Content: summary})
+	}
+	messages = append(messages, fromContextMessages(compacted)...)
+
+	mem := e.memoryMgr.GetWorkingMemory()
+	state := sessionState{
+		SessionID:  e.session.ID,
+		Goal:       goal,
+		Objective:  objective,
+		Branch:     tools.GetCurrentBranch(e.project.Root),
+		Pins:       e.Pins(),
+		CycleCount: cycles,
+		Messages:   messages,
+		Memory:     &mem,
+		SavedAt:    time.Now(),
+	}
+	if task := e.taskStore.GetCurrentTask(); task != nil {
+		state.CurrentTaskID = task.ID
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(e.session.LogDir, stateFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write session state: %w", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// loadSessionState reads the resumable state of a session directory. Sessions
+// that predate the state file are reconstructed from their metadata and
+// transcript log.
+func loadSessionState(sessionDir string) (*sessionState, error) {
+	data, err := os.ReadFile(filepath.Join(sessionDir, stateFile))
+	if err == nil {
+		var state sessionState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("failed to parse session state: %w", err)
+		}
+		return &state, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read session state: %w", err)
+	}
+
+	entries, err := logs.ReadTranscript(sessionDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session transcript: %w", err)
+	}
+
+	state := &sessionState{SessionID: filepath.Base(sessionDir)}
+	if meta, err := logs.ReadMeta(sessionDir); err == nil {
+		state.Goal = meta.Goal
+		state.Branch = meta.Branch
+		state.CycleCount = meta.Cycles
+	}
+	for _, entry := range entries {
+		switch entry.Type {
+		case "objective":
+			if state.Goal == "" {
+				state.Goal = entry.Content
+			}
+		case "assistant":
+			state.Messages = append(state.Messages, ollama.Message{Role: "assistant", Content: entry.Content})
+		}
+	}
+	if len(state.Messages) > maxLegacyMessages {
+		state.Messages = state.Messages[len(state.Messages)-maxLegacyMessages:]
+	}
+	return state, nil
+}
+
+// restoreState applies a resumed session's state. The system prompt must
+// already be built; the restored transcript is appended after it.
+func (e *Engine) restoreState(ctx context.Context, state *sessionState) {
+	if state.Branch != "" && tools.IsGitRepo(e.project.Root) && tools.GetCurrentBranch(e.project.Root) != state.Branch {
+		result, err := e.tools.Execute(ctx, "git_checkout", json.RawMessage(fmt.Sprintf(`{"ref": %q}`, state.Branch)))
+		if err != nil || (result != nil && result.ExitCode != 0) {
+			output := ""
+			if result != nil {
+				output = result.Output
+			}
+			e.sendUpdate(CycleUpdate{State: StateObserving, Message: fmt.Sprintf("Could not check out %s: %v %s", state.Branch, err, output)})
+		}
+	}
+
+	if state.Memory != nil {
+		e.memoryMgr.Restore(*state.Memory)
+	}
+
+	for _, pin := range state.Pins {
+		e.Pin(pin)
+	}
+
+	e.mu.Lock()
+	if e.goal == "" {
+		e.goal = state.Goal
+	}
+	e.objective = state.Objective
+	e.cycleCount = state.CycleCount
+	e.mu.Unlock()
+
+	if state.CurrentTaskID != "" {
+		if err := e.SelectTask(state.CurrentTaskID); err != nil {
+			e.sendUpdate(CycleUpdate{State: StateObserving, Message: fmt.Sprintf("Could not restore current task: %v", err)})
+		}
+	}
+
+	e.messages = append(e.messages, state.Messages...)
+	e.trimContext()
+}
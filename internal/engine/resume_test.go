@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/logs"
+	"github.com/ai/brewol/internal/memory"
+	"github.com/ai/brewol/internal/ollama"
+	"github.com/ai/brewol/internal/repo"
+)
+
+// newResumeEngine builds an engine with the components saveState and restoreState use
+func newResumeEngine(t *testing.T, root string) *Engine {
+	t.Helper()
+	memoryMgr, err := memory.NewManager(memory.DefaultConfig(root))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { memoryMgr.Close() })
+
+	taskStore, err := ctxmgr.NewTaskStore(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	budgetMgr := ctxmgr.NewBudgetManager(ctxmgr.DefaultBudgetConfig())
+	compactor, err := ctxmgr.NewCompactor(ctxmgr.DefaultCompactorConfig(root), budgetMgr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return &Engine{
+		project:   &repo.Project{Root: root},
+		memoryMgr: memoryMgr,
+		taskStore: taskStore,
+		budgetMgr: budgetMgr,
+		compactor: compactor,
+		messages:  []ollama.Message{{Role: "system", Content: "system prompt"}},
+	}
+}
+
+func TestSaveAndRestoreState(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644)
+
+	session, err := logs.NewSession(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer session.Close()
+
+	e := newResumeEngine(t, root)
+	e.session = session
+	e.goal = "Fix the parser"
+	e.cycleCount = 7
+	e.taskStore.AddTask(&ctxmgr.Task{ID: "t1", Title: "Handle empty input", Priority: ctxmgr.TaskPriorityHigh})
+	if err := e.SelectTask("t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := e.Pin("main.go"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e.memoryMgr.Restore(memory.WorkingMemory{TestCommand: "go test ./..."})
+	e.messages = append(e.messages,
+		ollama.Message{Role: "user", Content: "Goal: Fix the parser"},
+		ollama.Message{Role: "assistant", Content: "RUN: go test ./..."},
+	)
+
+	if err := e.saveState(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	state, err := loadSessionState(session.LogDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.SessionID != session.ID || state.Goal != "Fix the parser" || state.CurrentTaskID != "t1" || state.CycleCount != 7 {
+		t.Errorf("unexpected state: %+v", state)
+	}
+
+	// Restore into a fresh engine over the same workspace
+	resumed := newResumeEngine(t, root)
+	resumed.restoreState(context.Background(), state)
+
+	if resumed.goal != "Fix the parser" || resumed.GetObjective() != "Handle empty input" || resumed.cycleCount != 7 {
+		t.Errorf("expected goal, objective and cycle to be restored, got %q / %q / %d", resumed.goal, resumed.GetObjective(), resumed.cycleCount)
+	}
+	if pins := resumed.Pins(); len(pins) != 1 || pins[0] != "main.go" {
+		t.Errorf("expected pins to be restored, got %v", pins)
+	}
+	if mem := resumed.memoryMgr.GetWorkingMemory(); mem.TestCommand != "go test ./..." {
+		t.Errorf("expected working memory to be restored, got %+v", mem)
+	}
+	if len(resumed.messages) != 3 || resumed.messages[0].Role != "system" || resumed.messages[2].Content != "RUN: go test ./..." {
+		t.Errorf("expected transcript after the system prompt, got %+v", resumed.messages)
+	}
+}
+
+func TestLoadSessionState_Legacy(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	var b strings.Builder
+	for _, entry := range []logs.Entry{
+		{Timestamp: base, Type: "objective", Content: "Add caching"},
+		{Timestamp: base.Add(time.Second), Type: "user", Content: "Goal: Add caching"},
+		{Timestamp: base.Add(2 * time.Second), Type: "assistant", Content: "Looking at the store"},
+	} {
+		data, _ := json.Marshal(entry)
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	for i := 0; i < maxLegacyMessages+5; i++ {
+		data, _ := json.Marshal(logs.Entry{Timestamp: base.Add(time.Minute), Type: "assistant", Content: "step"})
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	if err := os.WriteFile(filepath.Join(dir, "transcript.jsonl"), []byte(b.String()), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	state, err := loadSessionState(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Goal != "Add caching" || state.SessionID != filepath.Base(dir) {
+		t.Errorf("expected goal from the transcript, got %+v", state)
+	}
+	if len(state.Messages) != maxLegacyMessages {
+		t.Errorf("expected %d messages, got %d", maxLegacyMessages, len(state.Messages))
+	}
+
+	if _, err := loadSessionState(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for a missing session")
+	}
+}
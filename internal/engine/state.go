@@ -4,18 +4,21 @@ package engine
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/ai/brewol/internal/config"
 	ctxmgr "github.com/ai/brewol/internal/context"
 	"github.com/ai/brewol/internal/logs"
 	"github.com/ai/brewol/internal/memory"
 	"github.com/ai/brewol/internal/ollama"
 	"github.com/ai/brewol/internal/prompt"
 	"github.com/ai/brewol/internal/repo"
+	"github.com/ai/brewol/internal/retrieval"
 	"github.com/ai/brewol/internal/tools"
 )
 
@@ -85,52 +88,101 @@ type CycleUpdate struct {
 
 // Engine is the autonomous agent engine
 type Engine struct {
-	client        *ollama.Client
-	tools         *tools.Registry
-	project       *repo.Project
-	verifier      *repo.Verifier
-	session       *logs.Session
-	promptMgr     *prompt.Manager
-	memoryMgr     *memory.Manager
-	budgetMgr     *ctxmgr.BudgetManager
-	taskStore     *ctxmgr.TaskStore
-	taskBriefGen  *ctxmgr.TaskBriefGenerator
-	compactor     *ctxmgr.Compactor
-	messages      []ollama.Message
-	backlog       []BacklogItem
-	objective     string
-	state         State
-	summary       string
-	cycleCount    int
-	updates       chan CycleUpdate
-	cancel        context.CancelFunc
-	mu            sync.RWMutex
-	goal          string // user-set goal
-	speed         int    // throttle (0 = no throttle)
-	paused        bool   // pause flag
-	errorCount    int    // consecutive error count
-	lastError     string // last error message
-	lastVerifyOK  bool   // last verification result
-	pendingCommit bool   // whether there are changes pending commit
-	testMode      bool   // test mode flag
-	maxCycles     int    // max cycles in test mode
+	client         *ollama.Client
+	tools          *tools.Registry
+	project        *repo.Project
+	verifier       *repo.Verifier
+	session        *logs.Session
+	promptMgr      *prompt.Manager
+	memoryMgr      *memory.Manager
+	budgetMgr      *ctxmgr.BudgetManager
+	taskStore      *ctxmgr.TaskStore
+	taskBriefGen   *ctxmgr.TaskBriefGenerator
+	compactor      *ctxmgr.Compactor
+	tokenCounter   *ctxmgr.TokenCounter
+	index          *retrieval.Index     // Embedding index (nil when retrieval is disabled)
+	relevantCode   string               // Chunks retrieved for the current cycle, added per request
+	pins           []string             // Workspace-relative files pinned into context
+	resume         *sessionState        // State of the session being resumed (nil for a fresh start)
+	verifiedChange string               // Fingerprint of the last change checked by targeted tests
+	coverage       *repo.CoverageReport // Coverage at the last checkpoint (nil = coverage mode off)
+	baseline       *repo.Baseline       // Verification state at session start (nil = not taken)
+	checkpointDiff string               // Baseline delta of the last pre-checkpoint verification
+	startCoverage  bool                 // Enter coverage mode when the session starts
+	settings       *config.Config       // Effective configuration
+	messages       []ollama.Message
+	backlog        []BacklogItem
+	objective      string
+	state          State
+	summary        string
+	cycleCount     int
+	updates        chan CycleUpdate
+	cancel         context.CancelFunc // Cancels the current operation
+	lifetime       context.Context    // Lives until Stop; operations derive from it
+	stop           context.CancelFunc // Cancels lifetime
+	mu             sync.RWMutex
+	goal           string // user-set goal
+	speed          int    // throttle (0 = no throttle)
+	paused         bool   // pause flag
+	errorCount     int    // consecutive error count
+	lastError      string // last error message
+	lastVerifyOK   bool   // last verification result
+	pendingCommit  bool   // whether there are changes pending commit
+	testMode       bool   // test mode flag
+	maxCycles      int    // max cycles in test mode
+
+	// checkpointRuns are the results of the last pre-checkpoint verification
+	checkpointRuns []*repo.VerificationResult
+	// summarize completes a prompt with the summary model (nil = no model summaries)
+	summarize func(ctx context.Context, prompt string) (string, error)
+
+	// measured is the last coverage measurement, reused while the workspace
+	// is unchanged since (measuredAt is its fingerprint)
+	measured   *repo.CoverageReport
+	measuredAt string
 }
 
 // Config holds engine configuration
 type Config struct {
 	WorkspaceRoot string
 	Goal          string
-	TestMode      bool // Enable test mode (exit after MaxCycles)
-	MaxCycles     int  // Maximum cycles in test mode
+	ResumeDir     string         // Log directory of a session to resume (empty = fresh start)
+	Settings      *config.Config // Layered configuration (nil = built-in defaults)
+	TestMode      bool           // Enable test mode (exit after MaxCycles)
+	MaxCycles     int            // Maximum cycles in test mode
+	Coverage      bool           // Start in coverage-guided test-writing mode
 }
 
 // NewEngine creates a new autonomous engine
 func NewEngine(cfg Config) (*Engine, error) {
+	settings := cfg.Settings
+	if settings == nil {
+		settings = config.Default()
+	}
+
 	client := ollama.NewClient()
+	if settings.Model.Name != "" {
+		client.SetModel(settings.Model.Name)
+	}
+	client.SetThinkMode(ollama.ThinkMode(settings.Model.Think))
 
-	toolRegistry := tools.NewRegistry(cfg.WorkspaceRoot)
 	project := repo.DetectProject(cfg.WorkspaceRoot)
+	applyVerifyOverrides(project, settings.Verify)
+	for _, p := range project.Projects() {
+		p.LintRequired = settings.Lint.BlockCheckpoint
+	}
+
+	toolRegistry := tools.NewRegistry(cfg.WorkspaceRoot, project)
+	toolRegistry.SetExecTimeout(settings.Tools.ExecTimeout)
+	toolRegistry.Disable(settings.Tools.Disabled...)
+
 	verifier := repo.NewVerifier(project)
+	flaky, err := repo.NewFlakyTracker(cfg.WorkspaceRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load flaky tests: %w", err)
+	}
+	verifier.SetFlakyTracker(flaky, settings.Verify.FlakyReruns)
+	toolRegistry.SetVerifier(verifier)
 
 	session, err := logs.NewSession(cfg.WorkspaceRoot)
 	if err != nil {
@@ -138,26 +190,46 @@ func NewEngine(cfg Config) (*Engine, error) {
 	}
 
 	// Create prompt manager for instruction layering
-	promptMgr := prompt.NewManager("brewol", cfg.WorkspaceRoot, string(project.Type))
+	promptMgr := prompt.NewManager("brewol", cfg.WorkspaceRoot, project.TypeSummary())
 
 	// Create memory manager for rolling memory
-	memoryMgr, err := memory.NewManager(memory.Config{
-		WorkspaceRoot:   cfg.WorkspaceRoot,
-		UpdateInterval:  5,
-		MaxContextTurns: 10,
-	})
+	memoryCfg := memory.DefaultConfig(cfg.WorkspaceRoot)
+	memoryCfg.SummaryModel = settings.Model.Summary
+	memoryCfg.UpdateInterval = settings.Memory.UpdateInterval
+	memoryCfg.SummaryTimeout = settings.Memory.SummaryTimeout
+	memoryCfg.SessionID = session.ID
+	memoryMgr, err := memory.NewManager(memoryCfg)
 	if err != nil {
 		session.Close()
 		return nil, fmt.Errorf("failed to create memory manager: %w", err)
 	}
 
+	// Summarize recent activity with the (optionally smaller) summary model
+	summarize := func(ctx context.Context, model, prompt string) (string, error) {
+		resp, err := client.ChatWithModel(ctx, model, []ollama.Message{
+			{Role: "user", Content: prompt},
+		}, nil)
+		if err != nil {
+			return "", err
+		}
+		return resp.Message.Content, nil
+	}
+	memoryMgr.SetSummarizer(summarize)
+
 	// Initialize memory with project info
-	memoryMgr.SetProjectInfo(string(project.Type), project.BuildCommand, project.TestCommand)
+	memoryMgr.SetProjectInfo(project.TypeSummary(), project.BuildCommand, project.TestCommand)
+	memoryMgr.SetVerifySteps(project.StepDescriptions())
 
 	// Create context budget manager
 	// Initialize with model's context size if available
 	budgetCfg := ctxmgr.DefaultBudgetConfig()
-	if model := client.GetModel(); model != "" {
+	budgetCfg.HighWatermarkRatio = settings.Budget.HighWatermark
+	budgetCfg.LowWatermarkRatio = settings.Budget.LowWatermark
+	budgetCfg.ReserveOutputTokens = settings.Budget.ReserveOutput
+	budgetCfg.MaxTranscriptTurns = settings.Budget.MaxTranscriptTurns
+	if settings.Budget.NumCtx > 0 {
+		budgetCfg.NumCtx = settings.Budget.NumCtx
+	} else if model := client.GetModel(); model != "" {
 		budgetCfg.NumCtx = client.GetModelContextSize()
 	}
 	budgetMgr := ctxmgr.NewBudgetManager(budgetCfg)
@@ -175,6 +247,10 @@ func NewEngine(cfg Config) (*Engine, error) {
 
 	// Create compactor
 	compactorCfg := ctxmgr.DefaultCompactorConfig(cfg.WorkspaceRoot)
+	compactorCfg.MaxToolOutputLines = settings.Compactor.MaxToolOutputLines
+	compactorCfg.ToolOutputHeadLines = settings.Compactor.ToolOutputHeadLines
+	compactorCfg.ToolOutputTailLines = settings.Compactor.ToolOutputTailLines
+	compactorCfg.MaxTranscriptTurns = settings.Budget.MaxTranscriptTurns
 	compactor, err := ctxmgr.NewCompactor(compactorCfg, budgetMgr)
 	if err != nil {
 		session.Close()
@@ -182,25 +258,68 @@ func NewEngine(cfg Config) (*Engine, error) {
 		return nil, fmt.Errorf("failed to create compactor: %w", err)
 	}
 
+	// Count tokens with the model's tokenizer, falling back to a calibrated estimate
+	tokenCounter := ctxmgr.NewTokenCounter(func(ctx context.Context, model, text string) (int, error) {
+		n, err := client.Tokenize(ctx, model, text)
+		if errors.Is(err, ollama.ErrTokenizeUnsupported) {
+			return 0, ctxmgr.ErrTokenizeUnsupported
+		}
+		return n, err
+	})
+
+	// Create embedding index for code retrieval
+	var index *retrieval.Index
+	if embedModel := settings.Model.Embed; embedModel != "" {
+		index, err = retrieval.NewIndex(cfg.WorkspaceRoot, embedModel, func(ctx context.Context, texts []string) ([][]float32, error) {
+			return client.Embed(ctx, embedModel, texts)
+		})
+		if err != nil {
+			session.Close()
+			memoryMgr.Close()
+			return nil, fmt.Errorf("failed to create retrieval index: %w", err)
+		}
+	}
+
+	// Load the state of the session being resumed
+	var resume *sessionState
+	if cfg.ResumeDir != "" {
+		resume, err = loadSessionState(cfg.ResumeDir)
+		if err != nil {
+			session.Close()
+			memoryMgr.Close()
+			return nil, fmt.Errorf("failed to resume session: %w", err)
+		}
+	}
+
 	e := &Engine{
-		client:       client,
-		tools:        toolRegistry,
-		project:      project,
-		verifier:     verifier,
-		session:      session,
-		promptMgr:    promptMgr,
-		memoryMgr:    memoryMgr,
-		budgetMgr:    budgetMgr,
-		taskStore:    taskStore,
-		taskBriefGen: taskBriefGen,
-		compactor:    compactor,
-		messages:     make([]ollama.Message, 0),
-		backlog:      make([]BacklogItem, 0),
-		state:        StateObserving,
-		updates:      make(chan CycleUpdate, 100),
-		goal:         cfg.Goal,
-		testMode:     cfg.TestMode,
-		maxCycles:    cfg.MaxCycles,
+		client:        client,
+		tools:         toolRegistry,
+		project:       project,
+		verifier:      verifier,
+		session:       session,
+		promptMgr:     promptMgr,
+		memoryMgr:     memoryMgr,
+		budgetMgr:     budgetMgr,
+		taskStore:     taskStore,
+		taskBriefGen:  taskBriefGen,
+		compactor:     compactor,
+		tokenCounter:  tokenCounter,
+		index:         index,
+		resume:        resume,
+		settings:      settings,
+		messages:      make([]ollama.Message, 0),
+		backlog:       make([]BacklogItem, 0),
+		state:         StateObserving,
+		updates:       make(chan CycleUpdate, 100),
+		goal:          cfg.Goal,
+		startCoverage: cfg.Coverage,
+		testMode:      cfg.TestMode,
+		maxCycles:     cfg.MaxCycles,
+	}
+
+	// Checkpoint messages are summarized by the memory summary model
+	e.summarize = func(ctx context.Context, prompt string) (string, error) {
+		return summarize(ctx, settings.Model.Summary, prompt)
 	}
 
 	return e, nil
@@ -213,7 +332,10 @@ func (e *Engine) Updates() <-chan CycleUpdate {
 
 // Start begins the autonomous loop
 func (e *Engine) Start(ctx context.Context) {
-	ctx, e.cancel = context.WithCancel(ctx)
+	e.mu.Lock()
+	e.lifetime, e.stop = context.WithCancel(ctx)
+	ctx, e.cancel = context.WithCancel(e.lifetime)
+	e.mu.Unlock()
 
 	go e.run(ctx)
 }
@@ -222,12 +344,26 @@ func (e *Engine) Start(ctx context.Context) {
 func (e *Engine) Stop() {
 	e.mu.Lock()
 	e.state = StateTerminating
+	if e.stop != nil {
+		e.stop()
+	}
 	if e.cancel != nil {
 		e.cancel()
 	}
 	e.mu.Unlock()
 }
 
+// Context returns a context that is cancelled when the engine stops, for
+// work started outside the cycle loop
+func (e *Engine) Context() context.Context {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.lifetime == nil {
+		return context.Background()
+	}
+	return e.lifetime
+}
+
 // CancelCurrent cancels the current operation (single ESC)
 func (e *Engine) CancelCurrent() {
 	e.mu.Lock()
@@ -243,6 +379,11 @@ func (e *Engine) SetGoal(goal string) {
 	e.goal = goal
 	e.mu.Unlock()
 
+	if e.session != nil {
+		e.session.LogObjective(goal, "set")
+	}
+	e.recordMeta(func(m *logs.Meta) { m.Goal = goal })
+
 	// Add to backlog with high priority
 	e.addToBacklog(BacklogItem{
 		ID:          fmt.Sprintf("goal-%d", time.Now().UnixNano()),
@@ -427,6 +568,7 @@ func (e *Engine) GetSummary() Summary {
 		EvalTokens:         budgetState.LastEvalTokens,
 		ContextUsageRatio:  budgetState.UsageRatio,
 		LastCompaction:     lastCompaction,
+		Metrics:            e.Metrics(),
 	}
 }
 
@@ -449,12 +591,13 @@ type Summary struct {
 	EvalTokens        int
 	ContextUsageRatio float64
 	LastCompaction    string
+	// Cumulative session and per-task accounting
+	Metrics logs.Metrics
 }
 
 // ResetMemory resets the working memory
 func (e *Engine) ResetMemory() {
 	e.memoryMgr.Reset()
-	e.rebuildSystemPrompt()
 }
 
 // GetWorkingMemory returns the current working memory text
@@ -462,6 +605,39 @@ func (e *Engine) GetWorkingMemory() string {
 	return e.memoryMgr.GetWorkingMemoryText()
 }
 
+// TokenCounter returns the prompt token counter
+func (e *Engine) TokenCounter() *ctxmgr.TokenCounter {
+	return e.tokenCounter
+}
+
+// promptTokens returns the token count of the conversation as it would be sent now
+func (e *Engine) promptTokens() int {
+	return e.tokenCounter.CountMessages(e.client.GetModel(), toContextMessages(e.messages))
+}
+
+// toContextMessages converts ollama messages to the context package's format
+func toContextMessages(messages []ollama.Message) []ctxmgr.Message {
+	msgs := make([]ctxmgr.Message, 0, len(messages))
+	for _, m := range messages {
+		msgs = append(msgs, ctxmgr.Message{Role: m.Role, Content: m.Content})
+	}
+	return msgs
+}
+
+// fromContextMessages converts context package messages to ollama messages
+func fromContextMessages(messages []ctxmgr.Message) []ollama.Message {
+	msgs := make([]ollama.Message, 0, len(messages))
+	for _, m := range messages {
+		msgs = append(msgs, ollama.Message{Role: m.Role, Content: m.Content})
+	}
+	return msgs
+}
+
+// RetrievalIndex returns the code retrieval index (nil when disabled)
+func (e *Engine) RetrievalIndex() *retrieval.Index {
+	return e.index
+}
+
 // BudgetManager returns the context budget manager
 func (e *Engine) BudgetManager() *ctxmgr.BudgetManager {
 	return e.budgetMgr
@@ -501,10 +677,50 @@ func (e *Engine) GetNumCtx() int {
 // SyncContextSize updates the context size based on the current model
 // Call this after changing the model
 func (e *Engine) SyncContextSize() {
+	if numCtx := e.config().Budget.NumCtx; numCtx > 0 {
+		e.budgetMgr.SetNumCtx(numCtx)
+		return
+	}
 	modelCtxSize := e.client.GetModelContextSize()
 	e.budgetMgr.SetNumCtx(modelCtxSize)
 }
 
+// Settings returns the effective configuration
+func (e *Engine) Settings() *config.Config {
+	return e.config()
+}
+
+// config returns the effective configuration, defaulting for engines built
+// without one
+func (e *Engine) config() *config.Config {
+	if e.settings == nil {
+		return config.Default()
+	}
+	return e.settings
+}
+
+// applyVerifyOverrides replaces detected verification commands with
+// configured ones. Declared steps replace detection entirely.
+func applyVerifyOverrides(project *repo.Project, verify config.VerifyConfig) {
+	if len(verify.Steps) > 0 {
+		project.SetSteps(verify.Steps)
+		return
+	}
+
+	if verify.Build != "" {
+		project.BuildCommand = verify.Build
+	}
+	if verify.Test != "" {
+		project.TestCommand = verify.Test
+	}
+	if verify.Lint != "" {
+		project.LintCommand = verify.Lint
+	}
+	if verify.Format != "" {
+		project.FormatCommand = verify.Format
+	}
+}
+
 // GetTaskBrief returns a task brief at the specified level
 func (e *Engine) GetTaskBrief(level ctxmgr.TaskBriefLevel) *ctxmgr.TaskBrief {
 	e.mu.RLock()
@@ -530,14 +746,10 @@ func (e *Engine) ForceCompact() {
 
 // compactContext performs context compaction
 func (e *Engine) compactContext(reason string) {
-	tokensBefore := e.budgetMgr.GetState().LastPromptTokens
+	tokensBefore := e.promptTokens()
 
 	// 1. Compact transcript
-	var msgs []ctxmgr.Message
-	for _, m := range e.messages {
-		msgs = append(msgs, ctxmgr.Message{Role: m.Role, Content: m.Content})
-	}
-	compactedMsgs, transcriptSummary := e.compactor.CompactTranscript(msgs, true)
+	compactedMsgs, transcriptSummary := e.compactor.CompactTranscript(toContextMessages(e.messages), true)
 
 	// Convert back to ollama.Message
 	e.messages = make([]ollama.Message, 0, len(compactedMsgs))
@@ -562,13 +774,13 @@ func (e *Engine) compactContext(reason string) {
 	// Update memory manager with the rolling memory
 	e.memoryMgr.SetBacklogSummary([]string{rollingMemory})
 
-	// Rebuild system prompt with new memory
-	e.rebuildSystemPrompt()
-
 	// Record compaction event
-	tokensAfter := len(e.messages) * 100 // Rough estimate
+	tokensAfter := e.promptTokens()
 	items := fmt.Sprintf("transcript(%d msgs)+taskbrief", len(compactedMsgs))
 	e.budgetMgr.RecordCompaction(reason, tokensBefore, tokensAfter, items)
+	if e.session != nil {
+		e.session.LogCompaction(reason, tokensBefore, tokensAfter, items)
+	}
 
 	e.sendUpdate(CycleUpdate{
 		State:   e.state,
@@ -582,6 +794,15 @@ func (e *Engine) run(ctx context.Context) {
 	defer e.session.Close()
 	defer e.memoryMgr.Close()
 
+	outcome := logs.OutcomeStopped
+	defer func() {
+		e.saveState()
+		e.recordMeta(func(m *logs.Meta) {
+			m.EndTime = time.Now()
+			m.Outcome = outcome
+		})
+	}()
+
 	// Initial setup
 	e.initializeSession(ctx)
 
@@ -611,7 +832,7 @@ func (e *Engine) run(ctx context.Context) {
 		if err := e.runCycle(ctx); err != nil {
 			if ctx.Err() != nil {
 				// Context cancelled - restart with fresh context
-				ctx, e.cancel = context.WithCancel(context.Background())
+				ctx, e.cancel = context.WithCancel(e.Context())
 				e.sendUpdate(CycleUpdate{State: StateObserving, Message: "Operation cancelled, continuing..."})
 				e.errorCount = 0
 				continue
@@ -642,12 +863,14 @@ func (e *Engine) run(ctx context.Context) {
 			}
 
 			// Exponential backoff for other errors
-			if errorCount >= 3 {
+			maxErrors := e.config().Engine.MaxErrors
+			if errorCount >= maxErrors {
 				e.sendUpdate(CycleUpdate{
 					State:   StateRecovering,
 					Error:   err,
 					Message: fmt.Sprintf("Too many errors (%d). Auto-pausing. Use /resume to retry.", errorCount),
 				})
+				e.memoryMgr.OnSignificantFailure(ctx, fmt.Sprintf("%d consecutive errors: %v", errorCount, err))
 				e.Pause()
 				continue
 			}
@@ -657,7 +880,7 @@ func (e *Engine) run(ctx context.Context) {
 			e.sendUpdate(CycleUpdate{
 				State:   StateRecovering,
 				Error:   err,
-				Message: fmt.Sprintf("Error %d/3. Retrying in %v...", errorCount, backoff),
+				Message: fmt.Sprintf("Error %d/%d. Retrying in %v...", errorCount, maxErrors, backoff),
 			})
 			time.Sleep(backoff)
 			continue
@@ -669,9 +892,17 @@ func (e *Engine) run(ctx context.Context) {
 		e.mu.Unlock()
 
 		e.cycleCount++
+		cycles := e.cycleCount
+		model := e.client.GetModel()
+		e.recordMeta(func(m *logs.Meta) {
+			m.Cycles = cycles
+			m.Model = model
+		})
+		e.saveState()
 
 		// Check if test mode cycle limit reached
 		if e.testMode && e.cycleCount >= e.maxCycles {
+			outcome = logs.OutcomeCompleted
 			e.sendUpdate(CycleUpdate{
 				State:   StateTerminating,
 				Message: fmt.Sprintf("Test mode: reached max cycles (%d). Exiting.", e.maxCycles),
@@ -684,39 +915,72 @@ func (e *Engine) run(ctx context.Context) {
 func (e *Engine) initializeSession(ctx context.Context) {
 	e.sendUpdate(CycleUpdate{State: StateObserving, Message: "Initializing session..."})
 
-	// Create agent branch
+	// Create agent branch, unless resuming on the previous session's branch
 	branchName := fmt.Sprintf("agent/%s", time.Now().Format("20060102-150405"))
-	if tools.IsGitRepo(e.project.Root) {
+	if tools.IsGitRepo(e.project.Root) && (e.resume == nil || e.resume.Branch == "") {
 		e.tools.Execute(ctx, "git_create_branch", json.RawMessage(fmt.Sprintf(`{"name": %q}`, branchName)))
 	}
 
 	// Build initial context
 	e.buildSystemPrompt()
-}
 
-func (e *Engine) buildSystemPrompt() {
-	// Get effective prompt from all instruction layers
-	systemPrompt := e.promptMgr.GetEffectivePrompt()
+	resumedFrom := ""
+	if e.resume != nil {
+		e.restoreState(ctx, e.resume)
+		resumedFrom = e.resume.SessionID
+		e.sendUpdate(CycleUpdate{
+			State:   StateObserving,
+			Message: fmt.Sprintf("Resumed session %s (%d messages, cycle %d)", resumedFrom, len(e.messages)-1, e.cycleCount),
+		})
+	}
 
-	// Append working memory if available
-	memoryText := e.memoryMgr.GetWorkingMemoryText()
-	if memoryText != "" {
-		systemPrompt += "\n\n" + memoryText
+	e.mu.RLock()
+	goal := e.goal
+	e.mu.RUnlock()
+	branch := tools.GetCurrentBranch(e.project.Root)
+	model := e.client.GetModel()
+	if goal != "" {
+		e.session.LogObjective(goal, "set")
 	}
+	e.recordMeta(func(m *logs.Meta) {
+		m.Goal = goal
+		m.Branch = branch
+		m.Model = model
+		m.ResumedFrom = resumedFrom
+	})
 
+	e.takeBaseline(ctx)
+	if err := e.syncTODOTasks(); err != nil {
+		e.sendUpdate(CycleUpdate{State: StateObserving, Error: err, Message: err.Error()})
+	}
+	if e.startCoverage {
+		e.StartCoverage(ctx)
+	}
+}
+
+func (e *Engine) buildSystemPrompt() {
 	e.messages = []ollama.Message{
-		{Role: "system", Content: systemPrompt},
+		{Role: "system", Content: e.promptMgr.GetEffectivePrompt()},
 	}
 }
 
-// rebuildSystemPrompt rebuilds the system prompt and updates messages[0]
-func (e *Engine) rebuildSystemPrompt() {
-	systemPrompt := e.promptMgr.GetEffectivePrompt()
+// knowledgeQuery returns the text used to look up relevant knowledge and code
+func (e *Engine) knowledgeQuery() string {
+	e.mu.RLock()
+	query := e.goal
+	e.mu.RUnlock()
 
-	memoryText := e.memoryMgr.GetWorkingMemoryText()
-	if memoryText != "" {
-		systemPrompt += "\n\n" + memoryText
+	if task := e.taskStore.GetCurrentTask(); task != nil {
+		query += " " + task.Title + " " + task.Description + " " + strings.Join(task.Files, " ")
 	}
+	return query
+}
+
+// rebuildSystemPrompt updates messages[0] after the instruction layers change.
+// Working memory, knowledge and the task brief are added per request by the
+// context assembler.
+func (e *Engine) rebuildSystemPrompt() {
+	systemPrompt := e.promptMgr.GetEffectivePrompt()
 
 	if len(e.messages) > 0 {
 		e.messages[0] = ollama.Message{Role: "system", Content: systemPrompt}
@@ -747,6 +1011,8 @@ func (e *Engine) runCycle(ctx context.Context) error {
 	}
 
 	// Phase 1: Observe
+	e.session.LogCycle(e.cycleCount, goal)
+	taskID, taskTitle := e.currentTask()
 	e.setState(StateObserving)
 	e.sendUpdate(CycleUpdate{State: StateObserving, Message: fmt.Sprintf("Goal: %s | Model: %s", goal, model)})
 
@@ -790,10 +1056,13 @@ func (e *Engine) runCycle(ctx context.Context) error {
 				e.sendUpdate(CycleUpdate{State: StateExecuting, Message: fmt.Sprintf("Error: %v", err)})
 			} else if result != nil {
 				e.sendUpdate(CycleUpdate{State: StateExecuting, ToolResult: result})
+				e.session.LogToolCall(result.Name, cmd, result.Output, result.Duration, result.ExitCode, result.Error)
+				e.accountTool(result)
+				e.memoryMgr.LogToolCall(result.Name, cmd, result.Output, result.ExitCode, result.Duration)
 				// Add result to conversation
 				e.messages = append(e.messages, ollama.Message{
 					Role:    "user",
-					Content: fmt.Sprintf("Command output:\n%s", result.Output),
+					Content: toolOutputPrefix + "\n" + result.Output,
 				})
 			}
 		}
@@ -801,18 +1070,23 @@ func (e *Engine) runCycle(ctx context.Context) error {
 		e.sendUpdate(CycleUpdate{State: StateExecuting, Message: "No commands found in response"})
 	}
 
+	// Verify the tests affected by this cycle's changes
+	e.verifyChanges(ctx)
+
 	// Trim context to avoid growing too large
 	e.trimContext()
 
 	// Update git state in memory
 	branch := tools.GetCurrentBranch(e.project.Root)
 	e.memoryMgr.SetGitState(branch, "")
+	e.memoryMgr.SetDirtyFiles(tools.GetDirtyFiles(e.project.Root))
 
 	// Notify memory manager of cycle completion (may trigger periodic update)
-	e.memoryMgr.OnCycleComplete(e.cycleCount + 1)
+	e.memoryMgr.OnCycleComplete(ctx, e.cycleCount+1)
+	e.accountCycle(taskID, taskTitle)
 
 	// Short pause before next cycle
-	time.Sleep(2 * time.Second)
+	time.Sleep(e.config().Engine.CycleInterval)
 
 	return nil
 }
@@ -859,17 +1133,61 @@ func (e *Engine) observe(ctx context.Context) (string, error) {
 	goal := e.goal
 	e.mu.RUnlock()
 
+	// Retrieved code replaces the previous cycle's in the assembled request
+	// rather than accumulating in the conversation
+	e.relevantCode = ""
 	if goal != "" {
+		e.relevantCode = e.retrieveRelevantCode(ctx)
 		return fmt.Sprintf("Goal: %s\nWhat should I do first?", goal), nil
 	}
 
 	return "No goal set. Waiting for instructions.", nil
 }
 
+// retrieveRelevantCode refreshes the embedding index and returns the chunks
+// most relevant to the current goal and task. Retrieval failures are reported
+// but never block the cycle.
+func (e *Engine) retrieveRelevantCode(ctx context.Context) string {
+	if e.index == nil {
+		return ""
+	}
+
+	stats, err := e.index.Update(ctx)
+	if err != nil {
+		e.sendUpdate(CycleUpdate{State: StateObserving, Message: fmt.Sprintf("Retrieval index update failed: %v", err)})
+		return ""
+	}
+	if stats.Changed() {
+		e.sendUpdate(CycleUpdate{State: StateObserving, Message: fmt.Sprintf("Indexed %d files (%d chunks, %d re-embedded)", stats.Files, stats.Chunks, stats.Embedded)})
+	}
+
+	results, err := e.index.Search(ctx, e.knowledgeQuery(), retrieval.DefaultTopK)
+	if err != nil {
+		e.sendUpdate(CycleUpdate{State: StateObserving, Message: fmt.Sprintf("Retrieval search failed: %v", err)})
+		return ""
+	}
+	return retrieval.FormatResults(results)
+}
+
 func (e *Engine) decide(ctx context.Context) (*ollama.ChatResponse, error) {
-	// Don't add extra prompts - just use what's in messages
+	// Pack the request into the context budget before sending
+	model := e.client.GetModel()
+	assembly := e.assembleRequest(model)
+	if assembly.Compacted() {
+		event := assembly.Event("pre_send")
+		e.budgetMgr.RecordEvent(event)
+		e.session.LogCompaction(event.Reason, event.TokensBefore, event.TokensAfter, event.CompactedItems)
+		e.sendUpdate(CycleUpdate{
+			State:   StateDeciding,
+			Message: fmt.Sprintf("Context packed: %s (%d → %d tokens)", event.CompactedItems, event.TokensBefore, event.TokensAfter),
+		})
+	}
+	e.budgetMgr.SetNextPromptTokens(assembly.TokensAfter)
+	sent := assembly.Messages()
+
 	// Stream the response WITHOUT tools to avoid entity too large
-	stream, err := e.client.ChatStream(ctx, e.messages, nil)
+	requestStart := time.Now()
+	stream, err := e.client.ChatStream(ctx, fromContextMessages(sent), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -914,6 +1232,12 @@ func (e *Engine) decide(ctx context.Context) (*ollama.ChatResponse, error) {
 			// Capture token metrics on final chunk
 			if chunk.Metrics != nil {
 				e.budgetMgr.UpdateMetrics(chunk.Metrics.PromptEvalCount, chunk.Metrics.EvalCount)
+				e.session.LogUsage(e.cycleCount, chunk.Metrics.PromptEvalCount, chunk.Metrics.EvalCount, chunk.Metrics.TotalDuration/int64(time.Millisecond))
+				metrics, wall := *chunk.Metrics, time.Since(requestStart).Seconds()
+				e.account(func(u *logs.Usage) {
+					u.AddRequest(metrics.PromptEvalCount, metrics.EvalCount, time.Duration(metrics.EvalDuration).Seconds(), wall)
+				})
+				e.tokenCounter.Calibrate(model, sent, chunk.Metrics.PromptEvalCount)
 			}
 		}
 	}
@@ -928,11 +1252,7 @@ func (e *Engine) decide(ctx context.Context) (*ollama.ChatResponse, error) {
 
 	// Log the response (content only, NOT thinking)
 	e.session.LogMessage("assistant", fullResponse.Message.Content, nil)
-
-	// Check if compaction is needed
-	if e.budgetMgr.NeedsCompaction() {
-		e.compactContext("high_watermark")
-	}
+	e.memoryMgr.LogMessage("assistant", fullResponse.Message.Content)
 
 	return &fullResponse, nil
 }
@@ -946,6 +1266,7 @@ func (e *Engine) executeToolCall(ctx context.Context, tc ollama.ToolCall) (*tool
 	result, err := e.tools.Execute(ctx, tc.Function.Name, tc.Function.Arguments)
 	if result != nil {
 		e.session.LogToolCall(tc.Function.Name, string(tc.Function.Arguments), result.Output, result.Duration, result.ExitCode, result.Error)
+		e.accountTool(result)
 	}
 
 	return result, err
@@ -960,7 +1281,17 @@ func (e *Engine) createCheckpoint(ctx context.Context, message string) error {
 		message = fmt.Sprintf("Checkpoint at cycle %d", e.cycleCount)
 	}
 
-	commitMsg := fmt.Sprintf("[brewol] %s\n\nCycle: %d\nObjective: %s", message, e.cycleCount, e.objective)
+	// Targeted tests give fast feedback; the full suite gates checkpoints
+	if err := e.verifyBeforeCheckpoint(ctx); err != nil {
+		e.sendUpdate(CycleUpdate{State: StateVerifying, Error: err, Message: err.Error()})
+		return err
+	}
+	if err := e.verifyCoverageIncrease(ctx); err != nil {
+		e.sendUpdate(CycleUpdate{State: StateVerifying, Error: err, Message: err.Error()})
+		return err
+	}
+
+	commitMsg := e.checkpointMessage(ctx, message)
 
 	result, err := e.tools.Execute(ctx, "git_commit", json.RawMessage(fmt.Sprintf(`{"message": %q}`, commitMsg)))
 	if err != nil {
@@ -968,38 +1299,34 @@ func (e *Engine) createCheckpoint(ctx context.Context, message string) error {
 	}
 
 	e.sendUpdate(CycleUpdate{State: StateCommitting, Message: "Checkpoint: " + result.Output})
-	e.session.LogCheckpoint("", message)
+	head := tools.GetHeadCommit(e.project.Root)
+	e.session.LogCheckpoint(head, message)
+	if head != "" {
+		// Keep the checkpoint's diff with the session for reports
+		if diff, err := tools.GetDiff(e.project.Root, head+"^!"); err == nil && diff != "" {
+			e.session.SavePatch("checkpoint-"+head, diff)
+		}
+	}
+	e.recordMeta(func(m *logs.Meta) { m.Checkpoints++ })
+
+	// Rescan TODOs: the checkpoint may have resolved or added some
+	if err := e.syncTODOTasks(); err != nil {
+		e.sendUpdate(CycleUpdate{State: StateCommitting, Error: err, Message: err.Error()})
+	}
+
+	// Refresh rolling memory after a checkpoint
+	e.memoryMgr.OnCheckpoint(ctx, head)
 
 	return nil
 }
 
-func (e *Engine) refreshBacklog(ctx context.Context) {
-	// Scan for TODOs
-	issues, _ := repo.ScanForTODOs(e.project.Root)
-	for _, issue := range issues {
-		e.addToBacklog(BacklogItem{
-			ID:          fmt.Sprintf("%s-%d", issue.Type, time.Now().UnixNano()),
-			Description: fmt.Sprintf("%s in %s: %s", issue.Type, issue.File, issue.Message),
-			Priority:    issue.Priority,
-			Source:      "scan",
-			CreatedAt:   time.Now(),
-		})
-	}
-
-	// Check for failing tests
-	testResult := e.verifier.RunTests(ctx)
-	if !testResult.Success {
-		failingTests := repo.GetFailingTests(testResult.Output, e.project.Type)
-		for _, test := range failingTests {
-			e.addToBacklog(BacklogItem{
-				ID:          fmt.Sprintf("failing-test-%d", time.Now().UnixNano()),
-				Description: fmt.Sprintf("Fix failing test: %s", test),
-				Priority:    1, // Highest priority
-				Source:      "test",
-				CreatedAt:   time.Now(),
-			})
-		}
+// logVerification records a verification result in the session log
+func (e *Engine) logVerification(kind string, result *repo.VerificationResult) {
+	if e.session == nil || result == nil || result.Command == "" {
+		return
 	}
+	e.session.LogVerification(kind, result.Command, result.Output, result.Success, result.Duration.Seconds(), result.ExitCode)
+	e.account(func(u *logs.Usage) { u.VerifySeconds += result.Duration.Seconds() })
 }
 
 func (e *Engine) addToBacklog(item BacklogItem) {
@@ -1096,14 +1423,8 @@ func (e *Engine) trimContext() {
 		return
 	}
 
-	// Convert to compactor Message format
-	var msgs []ctxmgr.Message
-	for _, m := range e.messages {
-		msgs = append(msgs, ctxmgr.Message{Role: m.Role, Content: m.Content})
-	}
-
 	// Compact transcript
-	compactedMsgs, _ := e.compactor.CompactTranscript(msgs, true)
+	compactedMsgs, _ := e.compactor.CompactTranscript(toContextMessages(e.messages), true)
 
 	// Convert back to ollama.Message
 	e.messages = make([]ollama.Message, 0, len(compactedMsgs))
@@ -1137,6 +1458,14 @@ func (e *Engine) recover(ctx context.Context) {
 	}
 }
 
+// recordMeta updates the session metadata, if there is a session
+func (e *Engine) recordMeta(update func(*logs.Meta)) {
+	if e.session == nil {
+		return
+	}
+	e.session.UpdateMeta(update)
+}
+
 func (e *Engine) setState(state State) {
 	e.mu.Lock()
 	e.state = state
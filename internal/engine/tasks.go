@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/repo"
+)
+
+// SelectTask makes a task the current objective. Any other in-progress task
+// goes back to pending so the task brief has a single current task. When no
+// goal is set yet, the task title becomes the goal so the agent starts on it.
+func (e *Engine) SelectTask(id string) error {
+	task, ok := e.taskStore.GetTask(id)
+	if !ok {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	for _, t := range e.taskStore.GetTasksByStatus(ctxmgr.TaskStatusInProgress) {
+		if t.ID == id {
+			continue
+		}
+		if err := e.taskStore.SetTaskStatus(t.ID, ctxmgr.TaskStatusPending); err != nil {
+			return fmt.Errorf("failed to release task %s: %w", t.ID, err)
+		}
+	}
+
+	err := e.taskStore.UpdateTask(id, func(t *ctxmgr.Task) {
+		t.Status = ctxmgr.TaskStatusInProgress
+		t.CompletedAt = nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to select task: %w", err)
+	}
+
+	e.mu.Lock()
+	e.objective = task.Title
+	if e.goal == "" {
+		e.goal = task.Title
+	}
+	e.mu.Unlock()
+	return nil
+}
+
+// todoTaskPrefix prefixes the IDs of tasks queued from TODO comments
+const todoTaskPrefix = "todo:"
+
+// syncTODOTasks scans the workspace for TODO comments and upserts a task per
+// comment, keyed by the comment's stable ID so rescans don't duplicate them.
+// Open tasks whose comment is gone are completed.
+func (e *Engine) syncTODOTasks() error {
+	cfg := e.config().Todo
+	if !cfg.Scan {
+		return nil
+	}
+	issues, err := repo.ScanForTODOs(e.project.Root, cfg.Ignore)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[string]bool, len(issues))
+	tasks := make([]*ctxmgr.Task, 0, len(issues))
+	for _, issue := range issues {
+		task := todoTask(issue)
+		found[task.ID] = true
+		tasks = append(tasks, task)
+	}
+	added, err := e.taskStore.UpsertTasks(tasks)
+	if err != nil {
+		return fmt.Errorf("failed to save TODO tasks: %w", err)
+	}
+
+	resolved := 0
+	for _, t := range e.taskStore.GetAllTasks() {
+		open := t.Status == ctxmgr.TaskStatusPending || t.Status == ctxmgr.TaskStatusInProgress
+		if strings.HasPrefix(t.ID, todoTaskPrefix) && open && !found[t.ID] {
+			if err := e.taskStore.SetTaskStatus(t.ID, ctxmgr.TaskStatusCompleted); err != nil {
+				return fmt.Errorf("failed to resolve TODO task: %w", err)
+			}
+			resolved++
+		}
+	}
+
+	e.sendUpdate(CycleUpdate{State: StateObserving, Message: fmt.Sprintf("TODO scan: %d comments, %d new tasks, %d resolved", len(issues), added, resolved)})
+	return nil
+}
+
+// todoTask builds the task for a TODO comment
+func todoTask(issue repo.Issue) *ctxmgr.Task {
+	location := fmt.Sprintf("%s:%d", issue.File, issue.Line)
+	title := issue.Type + " in " + issue.File
+	if issue.Message != "" {
+		title = issue.Type + ": " + truncateString(issue.Message, 80)
+	}
+
+	details := []string{fmt.Sprintf("%s at %s", issue.Type, location)}
+	if issue.Message != "" {
+		details = append(details, "Comment: "+issue.Message)
+	}
+	if issue.Owner != "" {
+		details = append(details, "Owner: "+issue.Owner)
+	}
+	if issue.Ref != "" {
+		details = append(details, "Issue: "+issue.Ref)
+	}
+	if issue.Author != "" {
+		details = append(details, fmt.Sprintf("Written by %s, %d days ago", issue.Author, int(issue.Age().Hours()/24)))
+	}
+
+	category := ctxmgr.TaskCategoryTodo
+	if issue.Type == "FIXME" || issue.Type == "HACK" {
+		category = ctxmgr.TaskCategoryFixme
+	}
+	return &ctxmgr.Task{
+		ID:          todoTaskPrefix + issue.ID,
+		Title:       title,
+		Description: strings.Join(details, "\n"),
+		Priority:    ctxmgr.TaskPriority(issue.Priority),
+		Category:    category,
+		Files:       []string{issue.File},
+		NextAction:  "Resolve the comment at " + location + ", then remove it",
+		Source:      "scan",
+	}
+}
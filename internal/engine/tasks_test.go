@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/repo"
+)
+
+func TestSelectTask(t *testing.T) {
+	store, err := ctxmgr.NewTaskStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := &Engine{taskStore: store}
+
+	store.AddTask(&ctxmgr.Task{ID: "a", Title: "Fix build", Priority: ctxmgr.TaskPriorityCritical, Status: ctxmgr.TaskStatusInProgress})
+	store.AddTask(&ctxmgr.Task{ID: "b", Title: "Write docs", Priority: ctxmgr.TaskPriorityLow})
+	store.AddTask(&ctxmgr.Task{ID: "c", Title: "Old work", Priority: ctxmgr.TaskPriorityMedium})
+	store.SetTaskStatus("c", ctxmgr.TaskStatusSkipped)
+
+	if err := e.SelectTask("b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if current := store.GetCurrentTask(); current == nil || current.ID != "b" {
+		t.Errorf("expected b to be current, got %+v", current)
+	}
+	if a, _ := store.GetTask("a"); a.Status != ctxmgr.TaskStatusPending {
+		t.Errorf("expected previous current task to return to pending, got %s", a.Status)
+	}
+	if e.GetObjective() != "Write docs" || e.goal != "Write docs" {
+		t.Errorf("expected objective and empty goal to follow the task, got %q / %q", e.GetObjective(), e.goal)
+	}
+
+	// Selecting a skipped task reopens it; an existing goal is kept
+	if err := e.SelectTask("c"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c, _ := store.GetTask("c"); c.Status != ctxmgr.TaskStatusInProgress || c.CompletedAt != nil {
+		t.Errorf("expected skipped task to be reopened, got %s", c.Status)
+	}
+	if e.goal != "Write docs" {
+		t.Errorf("expected goal to be kept, got %q", e.goal)
+	}
+
+	if err := e.SelectTask("missing"); err == nil {
+		t.Error("expected error selecting a missing task")
+	}
+}
+
+func TestSyncTODOTasks(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\n// FIXME(bob): leaks memory\n// TODO: add flags\n"), 0644)
+	store, err := ctxmgr.NewTaskStore(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := &Engine{project: &repo.Project{Root: root}, taskStore: store, updates: make(chan CycleUpdate, 100)}
+
+	if err := e.syncTODOTasks(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks := store.GetAllTasks()
+	if len(tasks) != 2 || tasks[0].Title != "FIXME: leaks memory" || tasks[0].Category != ctxmgr.TaskCategoryFixme || !strings.Contains(tasks[0].Description, "Owner: bob") {
+		t.Fatalf("unexpected tasks: %+v", tasks)
+	}
+
+	// Rescans update tasks in place, keeping their status
+	store.SetTaskStatus(tasks[0].ID, ctxmgr.TaskStatusInProgress)
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\n\n// FIXME(bob): leaks memory\n"), 0644)
+	if err := e.syncTODOTasks(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.GetAllTasks()) != 2 {
+		t.Fatalf("expected no duplicate tasks, got %d", len(store.GetAllTasks()))
+	}
+	fixme, _ := store.GetTask(tasks[0].ID)
+	if fixme.Status != ctxmgr.TaskStatusInProgress || !strings.Contains(fixme.Description, "main.go:4") {
+		t.Errorf("expected the task to keep its status and follow the line, got %+v", fixme)
+	}
+	if todo, _ := store.GetTask(tasks[1].ID); todo.Status != ctxmgr.TaskStatusCompleted {
+		t.Errorf("expected the removed TODO's task to be completed, got %s", todo.Status)
+	}
+}
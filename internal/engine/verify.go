@@ -0,0 +1,275 @@
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/ollama"
+	"github.com/ai/brewol/internal/repo"
+	"github.com/ai/brewol/internal/tools"
+)
+
+const (
+	maxVerifyFeedback = 4000 // Bytes of verification output fed back to the model
+	maxLintFeedback   = 30   // Lint findings fed back to the model
+)
+
+// verifyChanges runs the post-edit lint pipeline and the tests affected by
+// the uncommitted changes, and feeds the results back to the model. Each
+// distinct change is checked once.
+func (e *Engine) verifyChanges(ctx context.Context) {
+	cfg := e.config()
+	if !cfg.Verify.Targeted && !cfg.Lint.Enabled {
+		return
+	}
+	files := tools.GetDirtyFiles(e.project.Root)
+	if len(files) == 0 {
+		return
+	}
+	if changeFingerprint(e.project.Root, files) == e.verifiedChange {
+		return
+	}
+	e.releaseFixedFlakyTests()
+
+	if cfg.Lint.Enabled {
+		e.lintChanges(ctx, files)
+		files = tools.GetDirtyFiles(e.project.Root) // Formatters and fixers rewrite files
+	}
+	fingerprint := changeFingerprint(e.project.Root, files)
+	if cfg.Verify.Targeted {
+		e.runTargetedTests(ctx, files)
+	}
+	if ctx.Err() == nil {
+		e.verifiedChange = fingerprint // A cancelled run checks the change again
+	}
+}
+
+// lintChanges formats and lint-fixes the changed files, then tells the model
+// what changed and which findings remain in its files
+func (e *Engine) lintChanges(ctx context.Context, files []string) {
+	e.setState(StateVerifying)
+	e.sendUpdate(CycleUpdate{State: StateVerifying, Message: fmt.Sprintf("Formatting and linting %d changed files...", len(files))})
+
+	cfg := e.config().Lint
+	report := e.verifier.LintChanges(ctx, files, repo.LintOptions{Format: cfg.Format, Fix: cfg.Fix})
+	if report.Result != nil {
+		e.logVerification("lint", report.Result)
+	}
+	if len(report.Commands) == 0 && len(report.Diagnostics) == 0 {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(toolOutputPrefix + "\n")
+	if len(report.Commands) > 0 {
+		sb.WriteString("Formatted and applied safe lint fixes; re-read files before editing them:\n")
+		for _, command := range report.Commands {
+			sb.WriteString("  " + command + "\n")
+		}
+	}
+	if len(report.Diagnostics) > 0 {
+		sb.WriteString(fmt.Sprintf("Lint findings in changed files (%d):\n", len(report.Diagnostics)))
+		for i, d := range report.Diagnostics {
+			if i == maxLintFeedback {
+				sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(report.Diagnostics)-i))
+				break
+			}
+			sb.WriteString("  " + d.String() + "\n")
+		}
+	} else if report.Result != nil {
+		sb.WriteString("Lint: no findings in changed files\n")
+	}
+	if report.Other > 0 {
+		sb.WriteString(fmt.Sprintf("(%d findings in other files not shown)\n", report.Other))
+	}
+
+	e.sendUpdate(CycleUpdate{State: StateVerifying, Message: fmt.Sprintf("Lint: %d commands run, %d findings in changed files", len(report.Commands), len(report.Diagnostics))})
+	e.messages = append(e.messages, ollama.Message{Role: "user", Content: strings.TrimRight(sb.String(), "\n")})
+}
+
+// runTargetedTests runs the tests affected by the changed files
+func (e *Engine) runTargetedTests(ctx context.Context, files []string) {
+	e.setState(StateVerifying)
+	e.sendUpdate(CycleUpdate{State: StateVerifying, Message: fmt.Sprintf("Running tests affected by %d changed files...", len(files))})
+
+	result := e.verifier.TargetedCheck(ctx, files)
+	if ctx.Err() != nil {
+		return
+	}
+	e.setVerifyOK(result.Success)
+	e.queueFlakyTasks(result)
+	if result.Command != "" {
+		e.logVerification("targeted", result)
+		e.sendUpdate(CycleUpdate{State: StateVerifying, Message: fmt.Sprintf("Targeted tests %s: %s", verdict(result.Success), result.Command)})
+
+		output := result.Output
+		if len(output) > maxVerifyFeedback {
+			output = output[len(output)-maxVerifyFeedback:] // Failures are usually at the end
+		}
+		e.messages = append(e.messages, ollama.Message{
+			Role:    "user",
+			Content: fmt.Sprintf("%s\nTargeted verification (%s): %s%s\n%s", toolOutputPrefix, result.Command, verdict(result.Success), e.baselineDelta(result), output),
+		})
+	}
+}
+
+// verifyBeforeCheckpoint runs the full verification of the projects touched
+// by the uncommitted changes and returns an error if a required step fails
+// with anything but the failures it had at baseline
+func (e *Engine) verifyBeforeCheckpoint(ctx context.Context) error {
+	if !e.config().Verify.BeforeCheckpoint {
+		return nil
+	}
+	files := tools.GetDirtyFiles(e.project.Root)
+	if len(files) == 0 {
+		return nil
+	}
+
+	e.releaseFixedFlakyTests()
+	e.setState(StateVerifying)
+	e.sendUpdate(CycleUpdate{State: StateVerifying, Message: "Running full verification before checkpoint..."})
+
+	results, ok := e.verifier.VerifyFiles(ctx, files)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	e.setVerifyOK(ok)
+	for _, result := range results {
+		e.logVerification("full", result)
+	}
+	e.queueFlakyTasks(results...)
+	e.checkpointRuns = results
+	if e.baseline != nil {
+		e.checkpointDiff = e.baseline.Compare(results).String()
+	}
+	if !ok {
+		failed := results[len(results)-1]
+		return fmt.Errorf("checkpoint blocked: %s failed (%s)", failed.Name, failed.Command)
+	}
+	for _, result := range results {
+		if e.baseline.Preexisting(result) {
+			e.sendUpdate(CycleUpdate{State: StateVerifying, Message: fmt.Sprintf("%s still fails as it did at session start; not blocking the checkpoint", result.Name)})
+		}
+	}
+	return nil
+}
+
+// setVerifyOK records the outcome of a completed verification
+func (e *Engine) setVerifyOK(ok bool) {
+	e.mu.Lock()
+	e.lastVerifyOK = ok
+	e.mu.Unlock()
+}
+
+// changeFingerprint identifies the current uncommitted change by the dirty
+// files' names, sizes and modification times
+func changeFingerprint(root string, files []string) string {
+	h := sha256.New()
+	for _, file := range files {
+		parts := strings.Split(file, " -> ")
+		fmt.Fprintf(h, "%s\n", file)
+		if info, err := os.Stat(filepath.Join(root, parts[len(parts)-1])); err == nil {
+			fmt.Fprintf(h, "%d %d\n", info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// verdict labels a verification outcome
+func verdict(success bool) string {
+	if success {
+		return "PASSED"
+	}
+	return "FAILED"
+}
+
+// takeBaseline verifies the whole workspace once at session start, so later
+// verifications can report only what changed
+func (e *Engine) takeBaseline(ctx context.Context) {
+	if !e.config().Verify.Baseline {
+		return
+	}
+
+	e.setState(StateVerifying)
+	e.sendUpdate(CycleUpdate{State: StateVerifying, Message: "Verifying the workspace for a baseline..."})
+	baseline, results := e.verifier.Snapshot(ctx)
+	for _, result := range results {
+		e.logVerification("baseline", result)
+	}
+	e.queueFlakyTasks(results...)
+	e.baseline = baseline
+	e.verifier.SetBaseline(baseline) // Pre-existing failures don't block checkpoints
+
+	failing := 0
+	for _, passed := range baseline.Steps {
+		if !passed {
+			failing++
+		}
+	}
+	e.sendUpdate(CycleUpdate{State: StateVerifying, Message: fmt.Sprintf("Baseline: %d of %d steps failing, %d failing tests, %d lint findings", failing, len(baseline.Steps), len(baseline.Tests), len(baseline.Findings))})
+}
+
+// baselineDelta describes how a result differs from the baseline, as a
+// suffix for verification feedback
+func (e *Engine) baselineDelta(result *repo.VerificationResult) string {
+	if e.baseline == nil {
+		return ""
+	}
+	return " (compared to the session baseline: " + e.baseline.Compare([]*repo.VerificationResult{result}).String() + ")"
+}
+
+// flakyTaskPrefix prefixes the IDs of tasks to fix flaky tests
+const flakyTaskPrefix = "flaky:"
+
+// queueFlakyTasks queues a task to fix each flaky test found by verification,
+// unless one is already open
+func (e *Engine) queueFlakyTasks(results ...*repo.VerificationResult) {
+	for _, result := range results {
+		for _, name := range result.Flaky {
+			id := flakyTaskPrefix + name
+			if t, ok := e.taskStore.GetTask(id); ok && (t.Status == ctxmgr.TaskStatusPending || t.Status == ctxmgr.TaskStatusInProgress) {
+				continue
+			}
+			err := e.taskStore.AddTask(&ctxmgr.Task{
+				ID:          id,
+				Title:       "Fix flaky test " + name,
+				Description: fmt.Sprintf("%s fails intermittently (%s). It is quarantined and does not block checkpoints until fixed.", name, result.Command),
+				Priority:    ctxmgr.TaskPriorityLow,
+				Category:    ctxmgr.TaskCategoryTest,
+				NextAction:  "Find the timing or ordering dependency and make the test deterministic",
+				Source:      "flaky",
+			})
+			if err != nil {
+				e.sendUpdate(CycleUpdate{State: StateVerifying, Error: err, Message: fmt.Sprintf("Failed to queue flaky test task: %v", err)})
+				return
+			}
+			e.sendUpdate(CycleUpdate{State: StateVerifying, Message: "Flaky test quarantined: " + name})
+		}
+	}
+}
+
+// releaseFixedFlakyTests lifts the quarantine of tests whose fix task was
+// completed after they last flaked, so their failures block again
+func (e *Engine) releaseFixedFlakyTests() {
+	tracker := e.verifier.FlakyTracker()
+	if tracker == nil || e.taskStore == nil {
+		return
+	}
+	for _, test := range tracker.Tests() {
+		task, ok := e.taskStore.GetTask(flakyTaskPrefix + test.Name)
+		if !ok || task.Status != ctxmgr.TaskStatusCompleted || task.CompletedAt == nil || !task.CompletedAt.After(test.LastFlaky) {
+			continue
+		}
+		if err := tracker.Release(test.Name); err != nil {
+			e.sendUpdate(CycleUpdate{State: StateVerifying, Error: err, Message: err.Error()})
+			return
+		}
+		e.sendUpdate(CycleUpdate{State: StateVerifying, Message: "Flaky test fixed, quarantine lifted: " + test.Name})
+	}
+}
@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ai/brewol/internal/config"
+	ctxmgr "github.com/ai/brewol/internal/context"
+	"github.com/ai/brewol/internal/repo"
+)
+
+// newVerifyEngine returns an engine for a git repository with a Makefile
+// project whose test target succeeds
+func newVerifyEngine(t *testing.T) (*Engine, string) {
+	t.Helper()
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "Makefile"), []byte("test:\n\t@echo tests ok\n"), 0644)
+	for _, args := range [][]string{{"init", "-q"}, {"add", "-A"}, {"-c", "user.email=t@t", "-c", "user.name=t", "commit", "-qm", "init"}} {
+		if out, err := exec.Command("git", append([]string{"-C", root}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	project := repo.DetectProject(root)
+	return &Engine{
+		project:  project,
+		verifier: repo.NewVerifier(project),
+		updates:  make(chan CycleUpdate, 100),
+	}, root
+}
+
+func TestVerifyChanges(t *testing.T) {
+	e, root := newVerifyEngine(t)
+
+	// Nothing changed: nothing to verify
+	e.verifyChanges(context.Background())
+	if len(e.messages) != 0 {
+		t.Fatalf("expected no verification without changes, got %v", e.messages)
+	}
+
+	// A cancelled run doesn't count as verifying the change
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("x"), 0644)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	e.verifyChanges(cancelled)
+	if e.verifiedChange != "" || e.lastVerifyOK {
+		t.Fatalf("expected a cancelled run not to be recorded, got %q %v", e.verifiedChange, e.lastVerifyOK)
+	}
+
+	e.verifyChanges(context.Background())
+	if len(e.messages) != 1 || !strings.Contains(e.messages[0].Content, "Targeted verification (make test): PASSED") {
+		t.Fatalf("expected targeted verification feedback, got %v", e.messages)
+	}
+	if !e.lastVerifyOK {
+		t.Error("expected the passing run to be reported")
+	}
+
+	// The same change is only checked once
+	e.verifyChanges(context.Background())
+	if len(e.messages) != 1 {
+		t.Errorf("expected unchanged files not to be re-verified, got %d messages", len(e.messages))
+	}
+
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("longer"), 0644)
+	e.verifyChanges(context.Background())
+	if len(e.messages) != 2 {
+		t.Errorf("expected a new change to be verified, got %d messages", len(e.messages))
+	}
+}
+
+func TestVerifyBeforeCheckpoint(t *testing.T) {
+	e, root := newVerifyEngine(t)
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("x"), 0644)
+
+	if err := e.verifyBeforeCheckpoint(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e.project.TestCommand = "exit 1"
+	err := e.verifyBeforeCheckpoint(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "checkpoint blocked: test failed") {
+		t.Errorf("expected failing tests to block the checkpoint, got %v", err)
+	}
+	if e.lastVerifyOK {
+		t.Error("expected the failing run to be recorded")
+	}
+
+	e.settings = config.Default()
+	e.settings.Verify.BeforeCheckpoint = false
+	if err := e.verifyBeforeCheckpoint(context.Background()); err != nil {
+		t.Errorf("expected disabled verification to allow the checkpoint, got %v", err)
+	}
+}
+
+func TestVerifyBeforeCheckpoint_FlakyTests(t *testing.T) {
+	e, root := newVerifyEngine(t)
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("x"), 0644)
+	store, err := ctxmgr.NewTaskStore(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tracker, err := repo.NewFlakyTracker(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e.taskStore = store
+	e.verifier.SetFlakyTracker(tracker, 2)
+
+	// A Makefile project's output can't be attributed to tests, so use a Go parser
+	e.project.Type = repo.ProjectTypeGo
+	e.project.TestCommand = `if [ ! -f ran ]; then touch ran; echo "--- FAIL: TestTiming (0.01s)"; exit 1; fi`
+	if err := e.verifyBeforeCheckpoint(context.Background()); err != nil {
+		t.Fatalf("expected a flaky failure not to block the checkpoint, got %v", err)
+	}
+
+	task, ok := store.GetTask("flaky:TestTiming")
+	if !ok || task.Category != ctxmgr.TaskCategoryTest || task.Status != ctxmgr.TaskStatusPending {
+		t.Fatalf("expected a task to fix the flaky test, got %+v", task)
+	}
+
+	// Completing the fix task lifts the quarantine, so failures block again
+	if !tracker.Quarantined("TestTiming") {
+		t.Fatal("expected TestTiming to be quarantined")
+	}
+	if err := store.SetTaskStatus("flaky:TestTiming", ctxmgr.TaskStatusCompleted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e.project.TestCommand = `echo "--- FAIL: TestTiming (0.01s)"; exit 1`
+	if err := e.verifyBeforeCheckpoint(context.Background()); err == nil {
+		t.Error("expected a failure after the fix to block the checkpoint")
+	}
+	if tracker.Quarantined("TestTiming") {
+		t.Error("expected the quarantine to be lifted")
+	}
+}
+
+func TestVerificationBaseline(t *testing.T) {
+	e, root := newVerifyEngine(t)
+	e.project.Type = repo.ProjectTypeGo
+	e.project.SetSteps([]repo.Step{{Name: "test", Kind: repo.StepTest, Command: "echo '--- FAIL: TestOld (0.00s)'; exit 1", Required: true}})
+
+	e.takeBaseline(context.Background())
+	if e.baseline == nil || e.baseline.Tests["TestOld"] == "" {
+		t.Fatalf("expected TestOld in the baseline, got %+v", e.baseline)
+	}
+
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("x"), 0644)
+	e.verifyChanges(context.Background())
+	if len(e.messages) != 1 || !strings.Contains(e.messages[0].Content, "FAILED (compared to the session baseline: no change from baseline)") {
+		t.Fatalf("expected a pre-existing failure to be reported as such, got %v", e.messages)
+	}
+
+	// Failures from before the session don't block checkpoints; new ones do
+	if err := e.verifyBeforeCheckpoint(context.Background()); err != nil {
+		t.Fatalf("expected a pre-existing failure not to block the checkpoint, got %v", err)
+	}
+	e.project.SetSteps([]repo.Step{{Name: "test", Kind: repo.StepTest, Command: "echo '--- FAIL: TestOld (0.00s)'; echo '--- FAIL: TestNew (0.00s)'; exit 1", Required: true}})
+	if err := e.verifyBeforeCheckpoint(context.Background()); err == nil || !strings.Contains(err.Error(), "checkpoint blocked: test failed") {
+		t.Fatalf("expected a new failure to block the checkpoint, got %v", err)
+	}
+
+	e.project.SetSteps([]repo.Step{{Name: "test", Kind: repo.StepTest, Command: "true", Required: true}})
+	if err := e.verifyBeforeCheckpoint(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.checkpointDiff != "steps now passing (1): test; fixed tests (1): TestOld" {
+		t.Errorf("unexpected checkpoint delta: %q", e.checkpointDiff)
+	}
+}
+
+func TestVerifyChanges_Lint(t *testing.T) {
+	e, root := newVerifyEngine(t)
+	e.project.LintCommand = "echo './notes.txt:1:1: trailing whitespace'; exit 1"
+
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("x "), 0644)
+	e.verifyChanges(context.Background())
+	if len(e.messages) != 2 || !strings.Contains(e.messages[0].Content, "Lint findings in changed files (1):\n  notes.txt:1:1: trailing whitespace") {
+		t.Fatalf("expected lint findings before the targeted tests, got %v", e.messages)
+	}
+
+	e.settings = config.Default()
+	e.settings.Lint.Enabled = false
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("xy "), 0644)
+	e.verifyChanges(context.Background())
+	if len(e.messages) != 3 || strings.Contains(e.messages[2].Content, "Lint") {
+		t.Errorf("expected only targeted tests with lint disabled, got %v", e.messages[2:])
+	}
+}
@@ -0,0 +1,353 @@
+// Package export turns an agent branch of small checkpoint commits into a
+// reviewable series: checkpoints are grouped by the tasks they complete and
+// squashed into one commit per group, written to a branch or as patches.
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ai/brewol/internal/checkpoint"
+	"github.com/ai/brewol/internal/display"
+	"github.com/ai/brewol/internal/repo"
+)
+
+const summaryFile = "SUMMARY.md"
+
+// genericSubject matches checkpoint subjects that say nothing about the change
+var genericSubject = regexp.MustCompile(`^(?:Manual checkpoint|Checkpoint at cycle \d+)$`)
+
+// Commit is a commit on the agent branch and its parsed message
+type Commit struct {
+	SHA string
+	checkpoint.Message
+}
+
+// Group is consecutive commits squashed into one logical commit: those up to
+// and including the commit that completed the group's tasks, or those made
+// for one objective
+type Group struct {
+	Commits []Commit
+	Tasks   []string
+	Changes []repo.FileChange // Files changed by the whole group
+	Message string
+	SHA     string // Squashed commit, once created
+}
+
+// Series is an agent branch grouped into logical commits on top of its base
+type Series struct {
+	Root   string
+	Branch string
+	Base   string // Commit the branch started from
+	Groups []Group
+	Head   string // Last squashed commit, once created
+}
+
+// Plan reads the commits of branch since base and groups them: a group ends
+// at a checkpoint that completed tasks or where the objective changes. An
+// empty branch means the current one, which must not be a detached HEAD; an
+// empty base means the commit the branch forked from, found as its oldest
+// commit not on any other local branch.
+func Plan(root, branch, base string) (*Series, error) {
+	var err error
+	if branch == "" {
+		if branch, err = git(root, "rev-parse", "--abbrev-ref", "HEAD"); err != nil {
+			return nil, err
+		}
+		if branch == "HEAD" {
+			return nil, fmt.Errorf("HEAD is detached: check out the agent branch or name it")
+		}
+	}
+	if base == "" {
+		if base, err = forkPoint(root, branch); err != nil {
+			return nil, err
+		}
+	} else if base, err = git(root, "rev-parse", "--verify", base+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown base %s", base)
+	}
+
+	out, err := git(root, "log", "--reverse", "--format=%H%x00%B%x1e", base+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		sha, message, ok := strings.Cut(strings.TrimSpace(record), "\x00")
+		if ok {
+			commits = append(commits, Commit{SHA: sha, Message: checkpoint.Parse(message)})
+		}
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits on %s since %s", branch, display.ShortCommit(base))
+	}
+
+	s := &Series{Root: root, Branch: branch, Base: base}
+	var current Group
+	objective := ""
+	for _, c := range commits {
+		// Checkpoints from before task lists are told apart by their objective
+		if c.Objective != "" && objective != "" && c.Objective != objective && len(current.Commits) > 0 {
+			s.Groups = append(s.Groups, current)
+			current = Group{}
+		}
+		if c.Objective != "" {
+			objective = c.Objective
+		}
+		current.Commits = append(current.Commits, c)
+		current.Tasks = append(current.Tasks, c.Tasks...)
+		if len(c.Tasks) > 0 {
+			s.Groups = append(s.Groups, current)
+			current = Group{}
+		}
+	}
+	if len(current.Commits) > 0 {
+		s.Groups = append(s.Groups, current)
+	}
+
+	from := base
+	for i := range s.Groups {
+		g := &s.Groups[i]
+		to := g.Commits[len(g.Commits)-1].SHA
+		diff, err := git(root, "diff", "--no-color", "--no-ext-diff", "--find-renames", from, to)
+		if err != nil {
+			return nil, err
+		}
+		g.Changes = repo.SummarizeDiff(diff)
+		g.Message = g.message()
+		from = to
+	}
+	return s, nil
+}
+
+// forkPoint returns the parent of the oldest commit on branch that no other
+// local branch contains
+func forkPoint(root, branch string) (string, error) {
+	ref, err := git(root, "rev-parse", "--symbolic-full-name", branch)
+	if err != nil {
+		return "", err
+	}
+	refs, err := git(root, "for-each-ref", "--format=%(refname)", "refs/heads/")
+	if err != nil {
+		return "", err
+	}
+	args := []string{"rev-list", "--reverse", branch, "--not"}
+	others := 0
+	for _, other := range strings.Fields(refs) {
+		if other != ref {
+			args = append(args, other)
+			others++
+		}
+	}
+	if others == 0 {
+		return "", fmt.Errorf("can't tell where %s started: no other branches (use --base)", branch)
+	}
+	out, err := git(root, args...)
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return "", fmt.Errorf("no commits on %s that aren't on other branches", branch)
+	}
+	oldest := strings.Fields(out)[0]
+	base, err := git(root, "rev-parse", "--verify", oldest+"^")
+	if err != nil {
+		return "", fmt.Errorf("%s starts at the root commit (use --base)", branch)
+	}
+	return base, nil
+}
+
+// message generates the squashed commit's message from its commits
+func (g *Group) message() string {
+	last := g.Commits[len(g.Commits)-1]
+	subject := g.describe()
+	switch {
+	case len(g.Tasks) == 1:
+		subject = g.Tasks[0]
+	case len(g.Tasks) > 1:
+		subject = strings.Join(g.Tasks, "; ")
+		if len(subject) > checkpoint.MaxSubjectLength {
+			subject = fmt.Sprintf("%s and %d more tasks", g.Tasks[0], len(g.Tasks)-1)
+		}
+	}
+
+	var notes []string
+	for _, c := range g.Commits {
+		switch {
+		case c.Checkpoint && c.Summary != "":
+			notes = append(notes, c.Summary)
+		case len(g.Commits) > 1 && !genericSubject.MatchString(c.Subject):
+			notes = append(notes, "- "+c.Subject)
+		}
+	}
+
+	msg := checkpoint.Message{
+		Prefix:       last.Prefix,
+		Subject:      subject,
+		Summary:      joinNotes(notes),
+		Tasks:        g.Tasks,
+		Verification: last.Verification,
+	}
+	for _, change := range g.Changes {
+		msg.Files = append(msg.Files, change.String())
+	}
+	for _, c := range g.Commits {
+		msg.Squashed = append(msg.Squashed, display.ShortCommit(c.SHA))
+	}
+	return msg.String()
+}
+
+// describe returns the latest specific commit subject in the group, or
+// names the files changed when every subject is generic
+func (g *Group) describe() string {
+	for i := len(g.Commits) - 1; i >= 0; i-- {
+		if subject := g.Commits[i].Subject; !genericSubject.MatchString(subject) {
+			return subject
+		}
+	}
+	var names []string
+	for _, change := range g.Changes {
+		names = append(names, filepath.Base(change.Path))
+	}
+	switch {
+	case len(names) == 0:
+		return g.Commits[len(g.Commits)-1].Subject
+	case len(names) > 3:
+		return fmt.Sprintf("Update %s and %d more files", strings.Join(names[:3], ", "), len(names)-3)
+	}
+	return "Update " + strings.Join(names, ", ")
+}
+
+// joinNotes separates paragraphs with blank lines and keeps bullets together
+func joinNotes(notes []string) string {
+	var sb strings.Builder
+	for i, note := range notes {
+		if i > 0 {
+			if strings.HasPrefix(note, "- ") && strings.HasPrefix(notes[i-1], "- ") {
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString("\n\n")
+			}
+		}
+		sb.WriteString(note)
+	}
+	return sb.String()
+}
+
+// Squash creates one commit per group with the tree of the group's last
+// commit, without touching the working tree or any branch
+func (s *Series) Squash() error {
+	parent := s.Base
+	for i := range s.Groups {
+		g := &s.Groups[i]
+		last := g.Commits[len(g.Commits)-1].SHA
+		cmd := exec.Command("git", "commit-tree", last+"^{tree}", "-p", parent, "-F", "-")
+		cmd.Dir = s.Root
+		cmd.Stdin = strings.NewReader(g.Message)
+		// Keep the authorship of the group's last commit
+		if info, err := git(s.Root, "log", "-1", "--format=%an%x00%ae%x00%aI", last); err == nil {
+			if parts := strings.Split(info, "\x00"); len(parts) == 3 {
+				cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME="+parts[0], "GIT_AUTHOR_EMAIL="+parts[1], "GIT_AUTHOR_DATE="+parts[2])
+			}
+		}
+		out, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("failed to squash commits: %w", gitError(err))
+		}
+		g.SHA = strings.TrimSpace(string(out))
+		parent = g.SHA
+	}
+	s.Head = parent
+	return nil
+}
+
+// WriteBranch points a new branch at the squashed series. An existing
+// branch is only replaced when force is set.
+func (s *Series) WriteBranch(name string, force bool) error {
+	if s.Head == "" {
+		return fmt.Errorf("series not squashed")
+	}
+	args := []string{"branch", name, s.Head}
+	if force {
+		args = []string{"branch", "-f", name, s.Head}
+	}
+	if _, err := git(s.Root, args...); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// WritePatches writes the squashed series to dir as a git format-patch
+// series plus a summary file, returning the paths written
+func (s *Series) WritePatches(dir string) ([]string, error) {
+	if s.Head == "" {
+		return nil, fmt.Errorf("series not squashed")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	out, err := git(s.Root, "format-patch", "--no-color", "-o", abs, s.Base+".."+s.Head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write patches: %w", err)
+	}
+	paths := strings.Fields(out)
+
+	summary := filepath.Join(abs, summaryFile)
+	if err := os.WriteFile(summary, []byte(s.Summary()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write summary: %w", err)
+	}
+	return append(paths, summary), nil
+}
+
+// Summary describes the series in Markdown for reviewers
+func (s *Series) Summary() string {
+	var sb strings.Builder
+	checkpoints := 0
+	for _, g := range s.Groups {
+		checkpoints += len(g.Commits)
+	}
+	fmt.Fprintf(&sb, "# %s\n\n", s.Branch)
+	fmt.Fprintf(&sb, "%d commits squashed into %d on top of `%s`.\n", checkpoints, len(s.Groups), display.ShortCommit(s.Base))
+
+	for i, g := range s.Groups {
+		subject, body, _ := strings.Cut(g.Message, "\n")
+		fmt.Fprintf(&sb, "\n## %d. %s\n", i+1, subject)
+		if g.SHA != "" {
+			fmt.Fprintf(&sb, "\nCommit `%s`, from %d commits.\n", display.ShortCommit(g.SHA), len(g.Commits))
+		}
+		if body = strings.TrimSpace(body); body != "" {
+			sb.WriteString("\n" + body + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// git runs a git command in root and returns its trimmed output
+func git(root string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", gitError(err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// gitError includes git's stderr in an error
+func gitError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if msg := string(bytes.TrimSpace(exitErr.Stderr)); msg != "" {
+			return fmt.Errorf("git: %s", msg)
+		}
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	root := t.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(file, content, message string) {
+		os.WriteFile(filepath.Join(root, file), []byte(content), 0644)
+		run("add", "-A")
+		run("commit", "-q", "-m", message)
+	}
+
+	run("init", "-q", "-b", "main")
+	run("config", "user.email", "a@example.com")
+	run("config", "user.name", "a")
+	commit("main.go", "package main\n", "Initial commit")
+	run("checkout", "-q", "-b", "agent/20250101-120000")
+	commit("main.go", "package main\n\nfunc serve() {}\n", "[brewol] Manual checkpoint\n\nCycle: 1\nObjective: Serve")
+	commit("main.go", "package main\n\nfunc serve() {\n\tlisten()\n}\n", "fix: start listening in serve\n\nServe now listens.\n\nFiles:\n- M main.go (+3 -1): serve\n\nTasks completed:\n- Fix failing test: TestServe\n\nCycle: 2\nObjective: Serve\nVerification: test PASSED")
+	commit("README.md", "# App\n", "Add readme")
+	commit("README.md", "# App\n\nServes.\n", "[brewol] Checkpoint at cycle 4\n\nCycle: 4\nObjective: Serve")
+
+	series, err := Plan(root, "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if series.Branch != "agent/20250101-120000" || len(series.Groups) != 2 {
+		t.Fatalf("unexpected series: %+v", series)
+	}
+	want := "fix: fix failing test: TestServe\n\nServe now listens.\n\nFiles:\n- M main.go (+4 -0): serve\n\nTasks completed:\n- Fix failing test: TestServe\n\nSquashed: "
+	if !strings.HasPrefix(series.Groups[0].Message, want) || !strings.HasSuffix(series.Groups[0].Message, "\nVerification: test PASSED") {
+		t.Errorf("unexpected first message:\n%s", series.Groups[0].Message)
+	}
+	if subject, _, _ := strings.Cut(series.Groups[1].Message, "\n"); subject != "Add readme" {
+		t.Errorf("unexpected second subject: %q", subject)
+	}
+
+	if err := series.Squash(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := series.WriteBranch("review/x", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if log := run("log", "--format=%s", "main..review/x"); log != "Add readme\nfix: fix failing test: TestServe" {
+		t.Errorf("unexpected review branch:\n%s", log)
+	}
+	if diff := run("diff", "review/x", "agent/20250101-120000"); diff != "" {
+		t.Errorf("expected the review branch to match the agent branch, got:\n%s", diff)
+	}
+	if run("rev-parse", "--abbrev-ref", "HEAD") != "agent/20250101-120000" {
+		t.Error("expected the checkout to be left alone")
+	}
+	if err := series.WriteBranch("review/x", false); err == nil {
+		t.Error("expected an existing branch not to be replaced without force")
+	}
+
+	paths, err := series.WritePatches(filepath.Join(t.TempDir(), "patches"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) != 3 || !strings.HasSuffix(paths[0], "0001-fix-fix-failing-test-TestServe.patch") || filepath.Base(paths[2]) != "SUMMARY.md" {
+		t.Fatalf("unexpected patches: %v", paths)
+	}
+	summary, _ := os.ReadFile(paths[2])
+	if !strings.Contains(string(summary), "4 commits squashed into 2") || !strings.Contains(string(summary), "## 2. Add readme") {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+}
+
+func TestPlan_NoBase(t *testing.T) {
+	root := t.TempDir()
+	cmd := exec.Command("sh", "-c", "git init -q -b agent/x && git -c user.email=a@b -c user.name=a commit -q --allow-empty -m init")
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git failed: %v\n%s", err, out)
+	}
+	if _, err := Plan(root, "", ""); err == nil || !strings.Contains(err.Error(), "--base") {
+		t.Errorf("expected an error asking for a base, got %v", err)
+	}
+}
+
+func TestPlan_LegacyCheckpoints(t *testing.T) {
+	root := t.TempDir()
+	script := `git init -q -b main && git config user.email a@b && git config user.name a &&
+git commit -q --allow-empty -m init && git checkout -q -b agent/x &&
+echo a > a.txt && git add -A && git commit -q -m "[brewol] Checkpoint at cycle 1" -m "Cycle: 1
+Objective: Add a" &&
+echo aa > a.txt && git add -A && git commit -q -m "[brewol] Checkpoint at cycle 2" -m "Cycle: 2
+Objective: Add a" &&
+echo b > b.txt && git add -A && git commit -q -m "[brewol] Manual checkpoint" -m "Cycle: 3
+Objective: Add b"`
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git failed: %v\n%s", err, out)
+	}
+
+	series, err := Plan(root, "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(series.Groups) != 2 || len(series.Groups[0].Commits) != 2 || len(series.Groups[1].Commits) != 1 {
+		t.Fatalf("expected checkpoints grouped by objective, got %+v", series.Groups)
+	}
+	if subject, _, _ := strings.Cut(series.Groups[1].Message, "\n"); subject != "Update b.txt" {
+		t.Errorf("unexpected second subject: %q", subject)
+	}
+}
+
+func TestPlan_DetachedHead(t *testing.T) {
+	root := t.TempDir()
+	cmd := exec.Command("sh", "-c", "git init -q -b main && git -c user.email=a@b -c user.name=a commit -q --allow-empty -m init && git checkout -q --detach")
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git failed: %v\n%s", err, out)
+	}
+	if _, err := Plan(root, "", ""); err == nil || !strings.Contains(err.Error(), "detached") {
+		t.Errorf("expected a detached HEAD error, got %v", err)
+	}
+}
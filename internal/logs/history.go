@@ -0,0 +1,258 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ai/brewol/internal/display"
+)
+
+// Timeline sources
+const (
+	SourceTranscript = "transcript"
+	SourceTools      = "tools"
+	SourceThinking   = "thinking"
+)
+
+// SessionSummary describes a past session for browsing
+type SessionSummary struct {
+	Meta
+	Dir       string
+	Duration  time.Duration
+	ToolCalls int
+	Failures  int // Tool calls with a non-zero exit code or an error
+}
+
+// TimelineEntry is a log entry tagged with the file it came from
+type TimelineEntry struct {
+	Entry
+	Source string
+}
+
+// Title returns a one-line description of the entry
+func (t TimelineEntry) Title() string {
+	switch t.Source {
+	case SourceTools:
+		name := strings.TrimPrefix(t.Type, "tool:")
+		args, _ := t.Metadata["args"].(string)
+		return fmt.Sprintf("%s %s (exit %d, %.2fs)", name, display.OneLine(args), t.ExitCode(), t.Duration())
+	case SourceThinking:
+		return "thinking: " + display.OneLine(t.Content)
+	default:
+		return t.Type + ": " + display.OneLine(t.Content)
+	}
+}
+
+// ExitCode returns the exit code of a tool entry
+func (t TimelineEntry) ExitCode() int {
+	code, _ := t.Metadata["exit_code"].(float64)
+	return int(code)
+}
+
+// Duration returns the duration in seconds of a tool entry
+func (t TimelineEntry) Duration() float64 {
+	d, _ := t.Metadata["duration"].(float64)
+	return d
+}
+
+// Failed reports whether a tool entry failed
+func (t TimelineEntry) Failed() bool {
+	if t.Source != SourceTools {
+		return false
+	}
+	_, hasErr := t.Metadata["error"]
+	return hasErr || t.ExitCode() != 0
+}
+
+// ReadToolLog reads all entries from a session's tool log
+func ReadToolLog(sessionDir string) ([]Entry, error) {
+	return readEntries(filepath.Join(sessionDir, "tools.jsonl"))
+}
+
+// ReadThinking reads all entries from a session's thinking log
+func ReadThinking(sessionDir string) ([]Entry, error) {
+	return readEntries(filepath.Join(sessionDir, "thinking.jsonl"))
+}
+
+// ReadTimeline merges a session's transcript, tool and thinking logs in
+// timestamp order. Missing log files are treated as empty.
+func ReadTimeline(sessionDir string) ([]TimelineEntry, error) {
+	if _, err := os.Stat(sessionDir); err != nil {
+		return nil, err
+	}
+
+	var timeline []TimelineEntry
+	for _, source := range []string{SourceTranscript, SourceTools, SourceThinking} {
+		entries, err := readEntries(filepath.Join(sessionDir, source+".jsonl"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, e := range entries {
+			timeline = append(timeline, TimelineEntry{Entry: e, Source: source})
+		}
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Timestamp.Before(timeline[j].Timestamp)
+	})
+	return timeline, nil
+}
+
+// SearchTimeline returns the indices of entries whose type, content or
+// arguments contain query, case-insensitively
+func SearchTimeline(timeline []TimelineEntry, query string) []int {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	var matches []int
+	for i, t := range timeline {
+		args, _ := t.Metadata["args"].(string)
+		text := strings.ToLower(t.Type + "\n" + t.Content + "\n" + args)
+		if strings.Contains(text, query) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// SummarizeSession builds the summary of a session directory. Sessions
+// without metadata are summarised from their logs.
+func SummarizeSession(sessionDir string) (*SessionSummary, error) {
+	timeline, err := ReadTimeline(sessionDir)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &SessionSummary{Dir: sessionDir}
+	if meta, err := ReadMeta(sessionDir); err == nil {
+		summary.Meta = *meta
+	} else {
+		summary.ID = filepath.Base(sessionDir)
+		summary.Outcome = OutcomeUnknown
+		if start, err := time.ParseInLocation("20060102-150405", summary.ID, time.Local); err == nil {
+			summary.StartTime = start
+		}
+		maxCycle := -1
+		for _, t := range timeline {
+			switch {
+			case t.Type == "checkpoint":
+				summary.Checkpoints++
+			case t.Type == "objective" && summary.Goal == "":
+				summary.Goal = t.Content
+			case t.Source == SourceThinking, t.Type == "cycle":
+				if c, ok := t.Metadata["cycle_id"].(float64); ok && int(c) > maxCycle {
+					maxCycle = int(c)
+				}
+			}
+		}
+		summary.Cycles = maxCycle + 1
+	}
+
+	for _, t := range timeline {
+		if t.Source == SourceTools {
+			summary.ToolCalls++
+			if t.Failed() {
+				summary.Failures++
+			}
+		}
+	}
+
+	end := summary.EndTime
+	if end.IsZero() && len(timeline) > 0 {
+		end = timeline[len(timeline)-1].Timestamp
+	}
+	if !end.IsZero() && !summary.StartTime.IsZero() && end.After(summary.StartTime) {
+		summary.Duration = end.Sub(summary.StartTime)
+	}
+	return summary, nil
+}
+
+// ListSessionSummaries summarises all sessions of a workspace, newest first
+func ListSessionSummaries(baseDir string) ([]SessionSummary, error) {
+	ids, err := ListSessions(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []SessionSummary
+	for _, id := range ids {
+		summary, err := SummarizeSession(filepath.Join(GetDefaultLogDir(baseDir), id))
+		if err != nil {
+			continue
+		}
+		summaries = append(summaries, *summary)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].ID > summaries[j].ID
+	})
+	return summaries, nil
+}
+
+// readEntries reads a JSONL log file, skipping malformed lines
+func readEntries(path string) ([]Entry, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, line := range splitLines(string(content)) {
+		if line == "" {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Patch is a patch file saved with SavePatch
+type Patch struct {
+	Name    string // Name given to SavePatch
+	Path    string
+	Time    time.Time
+	Content string
+}
+
+// ReadPatches reads a session's saved patches in the order they were saved
+func ReadPatches(sessionDir string) ([]Patch, error) {
+	files, err := filepath.Glob(filepath.Join(sessionDir, "patches", "*.patch"))
+	if err != nil {
+		return nil, err
+	}
+
+	var patches []Patch
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		patch := Patch{Path: path, Content: string(content)}
+		base := strings.TrimSuffix(filepath.Base(path), ".patch")
+		patch.Name = base
+		if i := strings.LastIndex(base, "-"); i > 0 {
+			if nanos, err := strconv.ParseInt(base[i+1:], 10, 64); err == nil {
+				patch.Name = base[:i]
+				patch.Time = time.Unix(0, nanos)
+			}
+		}
+		patches = append(patches, patch)
+	}
+
+	sort.SliceStable(patches, func(i, j int) bool {
+		return patches[i].Time.Before(patches[j].Time)
+	})
+	return patches, nil
+}
@@ -0,0 +1,189 @@
+package logs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLog writes entries as JSONL into a session directory
+func writeLog(t *testing.T, dir, name string, entries ...Entry) {
+	t.Helper()
+	var b strings.Builder
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(b.String()), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSession_UpdateMeta(t *testing.T) {
+	s, err := NewSession(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	meta, err := ReadMeta(s.LogDir)
+	if err != nil {
+		t.Fatalf("expected metadata on creation: %v", err)
+	}
+	if meta.ID != s.ID || meta.Outcome != OutcomeRunning {
+		t.Errorf("unexpected initial metadata: %+v", meta)
+	}
+
+	s.UpdateMeta(func(m *Meta) {
+		m.Model = "llama3"
+		m.Cycles = 4
+		m.Outcome = OutcomeStopped
+	})
+
+	meta, err = ReadMeta(s.LogDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.Model != "llama3" || meta.Cycles != 4 || meta.Outcome != OutcomeStopped {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+	if s.Meta().Cycles != 4 {
+		t.Errorf("expected in-memory metadata to match, got %+v", s.Meta())
+	}
+}
+
+func TestReadTimeline_Interleaves(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	writeLog(t, dir, "transcript.jsonl",
+		Entry{Timestamp: base, Type: "objective", Content: "Fix tests"},
+		Entry{Timestamp: base.Add(3 * time.Second), Type: "assistant", Content: "Running go test"},
+	)
+	writeLog(t, dir, "tools.jsonl",
+		Entry{Timestamp: base.Add(4 * time.Second), Type: "tool:shell", Content: "FAIL", Metadata: map[string]interface{}{"args": "go test ./...", "exit_code": 1, "duration": 1.5}},
+	)
+	writeLog(t, dir, "thinking.jsonl",
+		Entry{Timestamp: base.Add(2 * time.Second), Type: "thinking", Content: "Tests\nfail", Metadata: map[string]interface{}{"cycle_id": 0}},
+	)
+
+	timeline, err := ReadTimeline(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(timeline) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(timeline))
+	}
+
+	sources := []string{SourceTranscript, SourceThinking, SourceTranscript, SourceTools}
+	for i, want := range sources {
+		if timeline[i].Source != want {
+			t.Errorf("entry %d: expected source %s, got %s", i, want, timeline[i].Source)
+		}
+	}
+
+	if title := timeline[1].Title(); title != "thinking: Tests fail" {
+		t.Errorf("unexpected thinking title: %q", title)
+	}
+	if title := timeline[3].Title(); title != "shell go test ./... (exit 1, 1.50s)" {
+		t.Errorf("unexpected tool title: %q", title)
+	}
+	if !timeline[3].Failed() || timeline[0].Failed() {
+		t.Error("expected only the failing tool call to be marked failed")
+	}
+
+	if matches := SearchTimeline(timeline, "GO TEST"); len(matches) != 2 || matches[0] != 2 || matches[1] != 3 {
+		t.Errorf("expected matches [2 3], got %v", matches)
+	}
+	if matches := SearchTimeline(timeline, "  "); matches != nil {
+		t.Errorf("expected no matches for blank query, got %v", matches)
+	}
+}
+
+func TestSummarizeSession_WithoutMeta(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "20240501-100000")
+	os.MkdirAll(dir, 0755)
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+
+	writeLog(t, dir, "transcript.jsonl",
+		Entry{Timestamp: start.Add(time.Second), Type: "objective", Content: "Fix tests"},
+		Entry{Timestamp: start.Add(time.Minute), Type: "checkpoint", Content: "Tests pass"},
+	)
+	writeLog(t, dir, "tools.jsonl",
+		Entry{Timestamp: start.Add(10 * time.Second), Type: "tool:shell", Metadata: map[string]interface{}{"exit_code": 1}},
+		Entry{Timestamp: start.Add(20 * time.Second), Type: "tool:shell", Metadata: map[string]interface{}{"exit_code": 0}},
+	)
+	writeLog(t, dir, "thinking.jsonl",
+		Entry{Timestamp: start.Add(5 * time.Second), Type: "thinking", Metadata: map[string]interface{}{"cycle_id": 2}},
+	)
+
+	summary, err := SummarizeSession(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.ID != "20240501-100000" || summary.Goal != "Fix tests" {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if summary.Cycles != 3 || summary.Checkpoints != 1 {
+		t.Errorf("expected 3 cycles and 1 checkpoint, got %d and %d", summary.Cycles, summary.Checkpoints)
+	}
+	if summary.ToolCalls != 2 || summary.Failures != 1 {
+		t.Errorf("expected 2 tool calls with 1 failure, got %d and %d", summary.ToolCalls, summary.Failures)
+	}
+	if summary.Duration != time.Minute {
+		t.Errorf("expected duration 1m, got %v", summary.Duration)
+	}
+}
+
+func TestListSessionSummaries_NewestFirst(t *testing.T) {
+	root := t.TempDir()
+	for _, id := range []string{"20240501-100000", "20240601-100000", "20240515-100000"} {
+		dir := filepath.Join(GetDefaultLogDir(root), id)
+		os.MkdirAll(dir, 0755)
+		writeMeta(dir, &Meta{ID: id, Outcome: OutcomeStopped})
+	}
+
+	summaries, err := ListSessionSummaries(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(summaries) != 3 {
+		t.Fatalf("expected 3 sessions, got %d", len(summaries))
+	}
+	if summaries[0].ID != "20240601-100000" || summaries[2].ID != "20240501-100000" {
+		t.Errorf("expected newest first, got %s .. %s", summaries[0].ID, summaries[2].ID)
+	}
+}
+
+func TestReadPatches(t *testing.T) {
+	s, err := NewSession(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	if patches, err := ReadPatches(s.LogDir); err != nil || len(patches) != 0 {
+		t.Fatalf("expected no patches, got %v (%v)", patches, err)
+	}
+
+	s.SavePatch("checkpoint-abc123", "+first\n")
+	s.SavePatch("checkpoint-def456", "+second\n")
+
+	patches, err := ReadPatches(s.LogDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(patches) != 2 || patches[0].Name != "checkpoint-abc123" || patches[1].Content != "+second\n" {
+		t.Errorf("unexpected patches: %+v", patches)
+	}
+	if patches[0].Time.IsZero() {
+		t.Error("expected patch time from the file name")
+	}
+}
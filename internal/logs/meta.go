@@ -0,0 +1,78 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// metaFile is the session metadata file inside the log directory
+const metaFile = "meta.json"
+
+// Session outcomes
+const (
+	OutcomeRunning   = "running"   // Still running, or the process died
+	OutcomeStopped   = "stopped"   // Stopped by the user
+	OutcomeCompleted = "completed" // Reached its cycle limit
+	OutcomeUnknown   = "unknown"   // Session predates metadata
+)
+
+// Meta is the summary of a session, kept up to date while it runs
+type Meta struct {
+	ID          string    `json:"id"`
+	Model       string    `json:"model,omitempty"`
+	Goal        string    `json:"goal,omitempty"`
+	Branch      string    `json:"branch,omitempty"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time,omitempty"`
+	Cycles      int       `json:"cycles"`
+	Checkpoints int       `json:"checkpoints"`
+	Outcome     string    `json:"outcome"`
+	ResumedFrom string    `json:"resumed_from,omitempty"` // Session this one continues
+}
+
+// UpdateMeta applies update to the session metadata and writes it to disk
+func (s *Session) UpdateMeta(update func(*Meta)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update(&s.meta)
+	return writeMeta(s.LogDir, &s.meta)
+}
+
+// Meta returns a copy of the session metadata
+func (s *Session) Meta() Meta {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.meta
+}
+
+// ReadMeta reads the metadata of a session directory
+func ReadMeta(sessionDir string) (*Meta, error) {
+	data, err := os.ReadFile(filepath.Join(sessionDir, metaFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var meta Meta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse session metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// writeMeta writes metadata atomically
+func writeMeta(sessionDir string, meta *Meta) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(sessionDir, metaFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write session metadata: %w", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,170 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// metricsFile is the session accounting file inside the log directory
+const metricsFile = "metrics.json"
+
+// Usage is cumulative token and time accounting. Times are in seconds.
+type Usage struct {
+	Requests      int     `json:"requests"`
+	PromptTokens  int     `json:"prompt_tokens"`
+	EvalTokens    int     `json:"eval_tokens"`
+	EvalSeconds   float64 `json:"eval_seconds"`   // Time spent generating tokens
+	ModelSeconds  float64 `json:"model_seconds"`  // Wall time waiting on the model
+	ToolSeconds   float64 `json:"tool_seconds"`   // Wall time in tool calls
+	VerifySeconds float64 `json:"verify_seconds"` // Wall time in verification commands
+	Cycles        int     `json:"cycles"`
+}
+
+// TokensPerSec returns the average generation throughput
+func (u Usage) TokensPerSec() float64 {
+	if u.EvalSeconds <= 0 {
+		return 0
+	}
+	return float64(u.EvalTokens) / u.EvalSeconds
+}
+
+// TotalTokens returns prompt plus generated tokens
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.EvalTokens
+}
+
+// AddRequest records one model request
+func (u *Usage) AddRequest(promptTokens, evalTokens int, evalSeconds, wallSeconds float64) {
+	u.Requests++
+	u.PromptTokens += promptTokens
+	u.EvalTokens += evalTokens
+	u.EvalSeconds += evalSeconds
+	u.ModelSeconds += wallSeconds
+}
+
+// TaskUsage is the accounting of a single task
+type TaskUsage struct {
+	Usage
+	Title     string `json:"title"`
+	Completed bool   `json:"completed"`
+}
+
+// Metrics is the accounting of a session, overall and per task
+type Metrics struct {
+	Session Usage                 `json:"session"`
+	Tasks   map[string]*TaskUsage `json:"tasks,omitempty"`
+}
+
+// Task returns the accounting of a task, creating it if needed
+func (m *Metrics) Task(id, title string) *TaskUsage {
+	if m.Tasks == nil {
+		m.Tasks = make(map[string]*TaskUsage)
+	}
+	t, ok := m.Tasks[id]
+	if !ok {
+		t = &TaskUsage{}
+		m.Tasks[id] = t
+	}
+	if title != "" {
+		t.Title = title
+	}
+	return t
+}
+
+// CompletedTasks returns the number of completed tasks
+func (m Metrics) CompletedTasks() int {
+	n := 0
+	for _, t := range m.Tasks {
+		if t.Completed {
+			n++
+		}
+	}
+	return n
+}
+
+// CyclesPerCompletedTask returns the average number of cycles spent on the
+// tasks that were completed, or 0 if none were
+func (m Metrics) CyclesPerCompletedTask() float64 {
+	cycles, completed := 0, 0
+	for _, t := range m.Tasks {
+		if t.Completed {
+			cycles += t.Cycles
+			completed++
+		}
+	}
+	if completed == 0 {
+		return 0
+	}
+	return float64(cycles) / float64(completed)
+}
+
+// TaskIDs returns the IDs of accounted tasks, most tokens first
+func (m Metrics) TaskIDs() []string {
+	ids := make([]string, 0, len(m.Tasks))
+	for id := range m.Tasks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := m.Tasks[ids[i]].TotalTokens(), m.Tasks[ids[j]].TotalTokens()
+		if a != b {
+			return a > b
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// copy returns a deep copy of the metrics
+func (m Metrics) copy() Metrics {
+	c := Metrics{Session: m.Session}
+	if m.Tasks != nil {
+		c.Tasks = make(map[string]*TaskUsage, len(m.Tasks))
+		for id, t := range m.Tasks {
+			task := *t
+			c.Tasks[id] = &task
+		}
+	}
+	return c
+}
+
+// UpdateMetrics applies update to the session accounting and writes it to disk
+func (s *Session) UpdateMetrics(update func(*Metrics)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update(&s.metrics)
+	data, err := json.MarshalIndent(s.metrics, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(s.LogDir, metricsFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write session metrics: %w", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Metrics returns a copy of the session accounting
+func (s *Session) Metrics() Metrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.metrics.copy()
+}
+
+// ReadMetrics reads the accounting of a session directory
+func ReadMetrics(sessionDir string) (*Metrics, error) {
+	data, err := os.ReadFile(filepath.Join(sessionDir, metricsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics Metrics
+	if err := json.Unmarshal(data, &metrics); err != nil {
+		return nil, fmt.Errorf("failed to parse session metrics: %w", err)
+	}
+	return &metrics, nil
+}
@@ -0,0 +1,45 @@
+package logs
+
+import (
+	"testing"
+)
+
+func TestSession_UpdateMetrics(t *testing.T) {
+	s, err := NewSession(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	s.UpdateMetrics(func(m *Metrics) {
+		m.Session.AddRequest(1000, 200, 4, 5)
+		m.Task("a", "Fix build").AddRequest(1000, 200, 4, 5)
+		m.Task("a", "").Cycles = 3
+		m.Task("a", "").Completed = true
+		m.Task("b", "Write docs").Cycles = 1
+	})
+
+	metrics, err := ReadMetrics(s.LogDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if metrics.Session.TotalTokens() != 1200 || metrics.Session.TokensPerSec() != 50 || metrics.Session.ModelSeconds != 5 {
+		t.Errorf("unexpected session usage: %+v", metrics.Session)
+	}
+	if metrics.Tasks["a"].Title != "Fix build" {
+		t.Errorf("expected task title to be kept, got %q", metrics.Tasks["a"].Title)
+	}
+	if metrics.CompletedTasks() != 1 || metrics.CyclesPerCompletedTask() != 3 {
+		t.Errorf("expected 1 completed task at 3 cycles, got %d at %.1f", metrics.CompletedTasks(), metrics.CyclesPerCompletedTask())
+	}
+	if ids := metrics.TaskIDs(); len(ids) != 2 || ids[0] != "a" {
+		t.Errorf("expected tasks ordered by tokens, got %v", ids)
+	}
+
+	// Metrics returns a copy
+	copied := s.Metrics()
+	copied.Tasks["a"].Cycles = 99
+	if s.Metrics().Tasks["a"].Cycles != 3 {
+		t.Error("expected Metrics to return a deep copy")
+	}
+}
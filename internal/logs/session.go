@@ -18,6 +18,8 @@ type Session struct {
 	transcript  *os.File
 	toolLog     *os.File
 	thinkingLog *os.File
+	meta        Meta
+	metrics     Metrics
 	mu          sync.Mutex
 }
 
@@ -57,14 +59,21 @@ func NewSession(baseDir string) (*Session, error) {
 		return nil, fmt.Errorf("failed to create thinking log file: %w", err)
 	}
 
-	return &Session{
+	s := &Session{
 		ID:          sessionID,
 		StartTime:   now,
 		LogDir:      logDir,
 		transcript:  transcript,
 		toolLog:     toolLog,
 		thinkingLog: thinkingLog,
-	}, nil
+		meta:        Meta{ID: sessionID, StartTime: now, Outcome: OutcomeRunning},
+	}
+	if err := writeMeta(logDir, &s.meta); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
 }
 
 // LogMessage logs a conversation message
@@ -157,6 +166,43 @@ func (s *Session) LogObjective(objective, status string) error {
 	})
 }
 
+// LogCycle marks the start of an autonomy cycle
+func (s *Session) LogCycle(cycleID int, goal string) error {
+	return s.LogMessage("cycle", goal, map[string]interface{}{
+		"cycle_id": cycleID,
+	})
+}
+
+// LogUsage logs the token usage of a model request
+func (s *Session) LogUsage(cycleID, promptTokens, evalTokens int, durationMs int64) error {
+	return s.LogMessage("usage", fmt.Sprintf("%d prompt + %d output tokens", promptTokens, evalTokens), map[string]interface{}{
+		"cycle_id":      cycleID,
+		"prompt_tokens": promptTokens,
+		"eval_tokens":   evalTokens,
+		"duration_ms":   durationMs,
+	})
+}
+
+// LogCompaction logs a context compaction event
+func (s *Session) LogCompaction(reason string, tokensBefore, tokensAfter int, items string) error {
+	return s.LogMessage("compaction", items, map[string]interface{}{
+		"reason":        reason,
+		"tokens_before": tokensBefore,
+		"tokens_after":  tokensAfter,
+	})
+}
+
+// LogVerification logs the result of a verification command (build, test, ...)
+func (s *Session) LogVerification(kind, command, output string, success bool, duration float64, exitCode int) error {
+	return s.LogMessage("verification", output, map[string]interface{}{
+		"kind":      kind,
+		"command":   command,
+		"success":   success,
+		"duration":  duration,
+		"exit_code": exitCode,
+	})
+}
+
 // SavePatch saves a patch file
 func (s *Session) SavePatch(name, content string) error {
 	patchDir := filepath.Join(s.LogDir, "patches")
@@ -228,24 +274,7 @@ func ListSessions(baseDir string) ([]string, error) {
 
 // ReadTranscript reads all entries from a session's transcript
 func ReadTranscript(sessionDir string) ([]Entry, error) {
-	transcriptPath := filepath.Join(sessionDir, "transcript.jsonl")
-	content, err := os.ReadFile(transcriptPath)
-	if err != nil {
-		return nil, err
-	}
-
-	var entries []Entry
-	for _, line := range splitLines(string(content)) {
-		if line == "" {
-			continue
-		}
-		var entry Entry
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
-			continue
-		}
-		entries = append(entries, entry)
-	}
-	return entries, nil
+	return readEntries(filepath.Join(sessionDir, "transcript.jsonl"))
 }
 
 func splitLines(s string) []string {
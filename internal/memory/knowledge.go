@@ -0,0 +1,273 @@
+package memory
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FactKind categorizes a knowledge fact
+type FactKind string
+
+const (
+	FactKindCommand        FactKind = "command"         // How to build/run/test something
+	FactKindOwnership      FactKind = "ownership"       // Which package/module owns what
+	FactKindFailedApproach FactKind = "failed_approach" // Something that was tried and did not work
+	FactKindNote           FactKind = "note"            // Any other durable fact
+)
+
+// Fact is a durable piece of project knowledge learned during a session
+type Fact struct {
+	ID           string    `json:"id"`
+	Kind         FactKind  `json:"kind"`
+	Text         string    `json:"text"`
+	Keywords     []string  `json:"keywords,omitempty"`
+	Source       string    `json:"source"` // Session ID that recorded the fact
+	Confidence   float64   `json:"confidence"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastVerified time.Time `json:"last_verified"`
+}
+
+// KnowledgeStore is an append-only store of facts shared across sessions.
+// Every Add appends a record to facts.jsonl; re-adding a known fact appends a
+// verification record and the latest record for an ID wins on load.
+type KnowledgeStore struct {
+	path  string
+	facts map[string]*Fact
+	order []string
+	mu    sync.RWMutex
+}
+
+// NewKnowledgeStore opens (or creates) the knowledge store for a workspace
+func NewKnowledgeStore(workspaceRoot string) (*KnowledgeStore, error) {
+	dir := filepath.Join(workspaceRoot, ".brewol", "knowledge")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create knowledge directory: %w", err)
+	}
+
+	ks := &KnowledgeStore{
+		path:  filepath.Join(dir, "facts.jsonl"),
+		facts: make(map[string]*Fact),
+	}
+	if err := ks.load(); err != nil {
+		return nil, fmt.Errorf("failed to load knowledge: %w", err)
+	}
+	return ks, nil
+}
+
+// load replays the fact log
+func (ks *KnowledgeStore) load() error {
+	f, err := os.Open(ks.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var fact Fact
+		if err := json.Unmarshal(scanner.Bytes(), &fact); err != nil || fact.ID == "" {
+			continue // Skip corrupt lines rather than losing the whole store
+		}
+		ks.apply(&fact)
+	}
+	return scanner.Err()
+}
+
+// apply merges a record into the in-memory view. Caller must hold ks.mu.
+func (ks *KnowledgeStore) apply(fact *Fact) {
+	if _, ok := ks.facts[fact.ID]; !ok {
+		ks.order = append(ks.order, fact.ID)
+	}
+	ks.facts[fact.ID] = fact
+}
+
+// Add records a fact. A fact with the same kind and text as an existing one
+// re-verifies it: LastVerified is bumped and the higher confidence is kept.
+func (ks *KnowledgeStore) Add(fact Fact) (Fact, error) {
+	fact.Text = strings.TrimSpace(fact.Text)
+	if fact.Text == "" {
+		return Fact{}, fmt.Errorf("fact text is empty")
+	}
+	if fact.Kind == "" {
+		fact.Kind = FactKindNote
+	}
+	if fact.Confidence <= 0 || fact.Confidence > 1 {
+		fact.Confidence = 0.5
+	}
+	fact.ID = factID(fact.Kind, fact.Text)
+	if len(fact.Keywords) == 0 {
+		fact.Keywords = keywords(fact.Text)
+	}
+
+	now := time.Now()
+	fact.LastVerified = now
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if existing, ok := ks.facts[fact.ID]; ok {
+		fact.CreatedAt = existing.CreatedAt
+		if existing.Confidence > fact.Confidence {
+			fact.Confidence = existing.Confidence
+		}
+		if fact.Source == "" {
+			fact.Source = existing.Source
+		}
+	} else {
+		fact.CreatedAt = now
+	}
+
+	if err := ks.appendRecord(&fact); err != nil {
+		return Fact{}, err
+	}
+	ks.apply(&fact)
+	return fact, nil
+}
+
+// appendRecord writes a record to the log. Caller must hold ks.mu.
+func (ks *KnowledgeStore) appendRecord(fact *Fact) error {
+	data, err := json.Marshal(fact)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(ks.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open knowledge file: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// All returns every known fact, oldest first
+func (ks *KnowledgeStore) All() []Fact {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	facts := make([]Fact, 0, len(ks.order))
+	for _, id := range ks.order {
+		facts = append(facts, *ks.facts[id])
+	}
+	return facts
+}
+
+// Count returns the number of known facts
+func (ks *KnowledgeStore) Count() int {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return len(ks.facts)
+}
+
+// Search returns up to limit facts matching the query's keywords, ranked by
+// keyword overlap weighted by confidence, most recently verified first on ties
+func (ks *KnowledgeStore) Search(query string, limit int) []Fact {
+	terms := keywords(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	type scored struct {
+		fact  Fact
+		score float64
+	}
+
+	ks.mu.RLock()
+	var results []scored
+	for _, id := range ks.order {
+		fact := ks.facts[id]
+		matches := 0
+		for _, term := range terms {
+			for _, kw := range fact.Keywords {
+				if kw == term {
+					matches++
+					break
+				}
+			}
+		}
+		if matches > 0 {
+			results = append(results, scored{fact: *fact, score: float64(matches) * fact.Confidence})
+		}
+	}
+	ks.mu.RUnlock()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].fact.LastVerified.After(results[j].fact.LastVerified)
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	facts := make([]Fact, len(results))
+	for i, r := range results {
+		facts[i] = r.fact
+	}
+	return facts
+}
+
+// FormatKnowledge renders facts for inclusion in the system prompt
+func FormatKnowledge(facts []Fact) string {
+	if len(facts) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## PROJECT KNOWLEDGE\n\n")
+	b.WriteString("Facts learned in previous sessions (verify before relying on low-confidence ones):\n")
+	for _, f := range facts {
+		b.WriteString(fmt.Sprintf("- [%s, %.0f%%, verified %s] %s\n",
+			f.Kind, f.Confidence*100, f.LastVerified.Format("2006-01-02"), f.Text))
+	}
+	return b.String()
+}
+
+// factID derives a stable ID from a fact's kind and normalized text
+func factID(kind FactKind, text string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	sum := sha256.Sum256([]byte(string(kind) + ":" + normalized))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// stopWords are ignored when extracting keywords
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true,
+	"from": true, "into": true, "are": true, "was": true, "not": true, "but": true,
+	"use": true, "when": true, "what": true, "how": true, "should": true, "does": true,
+}
+
+// keywords extracts lowercase search terms of three or more characters
+func keywords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.' || r == '/')
+	})
+
+	seen := make(map[string]bool)
+	var result []string
+	for _, f := range fields {
+		f = strings.Trim(f, "-./")
+		if len(f) < 3 || stopWords[f] || seen[f] {
+			continue
+		}
+		seen[f] = true
+		result = append(result, f)
+	}
+	return result
+}
@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKnowledgeStore_AddAndReload(t *testing.T) {
+	tempDir := t.TempDir()
+
+	ks, err := NewKnowledgeStore(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fact, err := ks.Add(Fact{
+		Kind:       FactKindCommand,
+		Text:       "Run TestWatcher with -count=1, it is flaky when cached",
+		Source:     "20240101-120000",
+		Confidence: 0.7,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fact.ID == "" {
+		t.Error("expected fact ID to be set")
+	}
+	if fact.CreatedAt.IsZero() || fact.LastVerified.IsZero() {
+		t.Error("expected timestamps to be set")
+	}
+
+	// Reopen: facts survive across sessions
+	reopened, err := NewKnowledgeStore(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	all := reopened.All()
+	if len(all) != 1 {
+		t.Fatalf("expected 1 fact, got %d", len(all))
+	}
+	if all[0].Source != "20240101-120000" {
+		t.Errorf("expected source session to persist, got %s", all[0].Source)
+	}
+}
+
+func TestKnowledgeStore_ReverifyAppends(t *testing.T) {
+	tempDir := t.TempDir()
+
+	ks, err := NewKnowledgeStore(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first, _ := ks.Add(Fact{Kind: FactKindOwnership, Text: "internal/tui owns all rendering", Source: "s1", Confidence: 0.9})
+	second, err := ks.Add(Fact{Kind: FactKindOwnership, Text: "internal/TUI owns  all rendering", Source: "s2", Confidence: 0.4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first.ID != second.ID {
+		t.Errorf("expected normalized text to yield the same ID, got %s and %s", first.ID, second.ID)
+	}
+	if second.Confidence != 0.9 {
+		t.Errorf("expected higher confidence to be kept, got %v", second.Confidence)
+	}
+	if !second.CreatedAt.Equal(first.CreatedAt) {
+		t.Error("expected CreatedAt to be preserved on re-verification")
+	}
+	if ks.Count() != 1 {
+		t.Errorf("expected 1 fact, got %d", ks.Count())
+	}
+
+	// The log is append-only: both records are on disk
+	data, err := os.ReadFile(filepath.Join(tempDir, ".brewol", "knowledge", "facts.jsonl"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("expected 2 records in log, got %d", lines)
+	}
+}
+
+func TestKnowledgeStore_Search(t *testing.T) {
+	ks, err := NewKnowledgeStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ks.Add(Fact{Kind: FactKindFailedApproach, Text: "Mocking the ollama client with interfaces broke streaming", Confidence: 0.8})
+	ks.Add(Fact{Kind: FactKindCommand, Text: "Regenerate golden files with go test ./internal/tui -update", Confidence: 0.9})
+	ks.Add(Fact{Kind: FactKindNote, Text: "The ollama client truncates long messages", Confidence: 0.9})
+
+	results := ks.Search("fix ollama client streaming", 5)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if !strings.Contains(results[0].Text, "Mocking") {
+		t.Errorf("expected best keyword match first, got %q", results[0].Text)
+	}
+
+	if got := ks.Search("ollama", 1); len(got) != 1 || got[0].Confidence != 0.9 {
+		t.Errorf("expected limit and confidence ranking, got %+v", got)
+	}
+	if got := ks.Search("the and", 5); got != nil {
+		t.Errorf("expected no results for stop words, got %+v", got)
+	}
+}
+
+func TestKnowledgeStore_AddValidation(t *testing.T) {
+	ks, err := NewKnowledgeStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := ks.Add(Fact{Text: "   "}); err == nil {
+		t.Error("expected error for empty fact")
+	}
+
+	fact, err := ks.Add(Fact{Text: "something durable", Confidence: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fact.Kind != FactKindNote {
+		t.Errorf("expected default kind note, got %s", fact.Kind)
+	}
+	if fact.Confidence != 0.5 {
+		t.Errorf("expected out-of-range confidence to default to 0.5, got %v", fact.Confidence)
+	}
+}
+
+func TestParseKnowledge(t *testing.T) {
+	reply := `{"build_command": "make", "knowledge": [
+		{"kind": "command", "text": "use make check", "confidence": 0.8},
+		{"kind": "bogus", "text": "unknown kinds become notes"},
+		{"kind": "note", "text": ""}
+	]}`
+
+	facts := ParseKnowledge(reply)
+	if len(facts) != 2 {
+		t.Fatalf("expected 2 facts, got %d", len(facts))
+	}
+	if facts[0].Kind != FactKindCommand || facts[0].Confidence != 0.8 {
+		t.Errorf("unexpected first fact: %+v", facts[0])
+	}
+	if facts[1].Kind != FactKindNote {
+		t.Errorf("expected unknown kind to map to note, got %s", facts[1].Kind)
+	}
+
+	if ParseKnowledge("no json here") != nil {
+		t.Error("expected nil for reply without JSON")
+	}
+}
+
+func TestManager_KnowledgeFromSummarizer(t *testing.T) {
+	tempDir := t.TempDir()
+
+	m, err := NewManager(Config{WorkspaceRoot: tempDir, UpdateInterval: 5, SessionID: "sess-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer m.Close()
+
+	m.SetSummarizer(func(ctx context.Context, model, prompt string) (string, error) {
+		return `{"test_command": "go test ./...", "knowledge": [{"kind": "ownership", "text": "internal/engine owns the autonomy loop", "confidence": 0.8}]}`, nil
+	})
+	m.LogMessage("assistant", "reading internal/engine/state.go")
+	m.TriggerUpdate("checkpoint")
+
+	facts := m.Knowledge().All()
+	if len(facts) != 1 {
+		t.Fatalf("expected 1 fact, got %d", len(facts))
+	}
+	if facts[0].Source != "sess-1" {
+		t.Errorf("expected source session sess-1, got %s", facts[0].Source)
+	}
+
+	text := m.GetRelevantKnowledgeText("refactor the engine loop")
+	if !strings.Contains(text, "PROJECT KNOWLEDGE") || !strings.Contains(text, "autonomy loop") {
+		t.Errorf("expected relevant knowledge in prompt text, got %q", text)
+	}
+	if m.GetRelevantKnowledgeText("update readme badges") != "" {
+		t.Error("expected no knowledge for unrelated query")
+	}
+
+	// Reset keeps knowledge
+	m.Reset()
+	if m.Knowledge().Count() != 1 {
+		t.Error("expected knowledge to survive memory reset")
+	}
+}
@@ -2,6 +2,7 @@
 package memory
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -11,12 +12,23 @@ import (
 	"time"
 )
 
+// Summary limits applied when merging summarizer output into working memory
+const (
+	MaxSummaryItems     = 5  // Max items per array field
+	MaxSummaryItemChars = 50 // Max characters per array entry
+	MaxPromptFacts      = 5  // Max knowledge facts injected into the prompt
+	maxRecentEntries    = 40 // Transcript entries kept for the next summarization
+	maxEntryChars       = 400
+)
+
 // Config holds memory manager configuration
 type Config struct {
 	WorkspaceRoot   string
-	UpdateInterval  int    // Update memory every N cycles (default 5)
-	MaxContextTurns int    // Max turns to keep in active context (default 10)
-	SummaryModel    string // Model to use for summarization (empty = use main model)
+	UpdateInterval  int           // Update memory every N cycles (default 5)
+	MaxContextTurns int           // Max turns to keep in active context (default 10)
+	SummaryModel    string        // Model to use for summarization (empty = use main model)
+	SummaryTimeout  time.Duration // Timeout for a single summarization call (default 60s)
+	SessionID       string        // Recorded as the source of learned knowledge
 }
 
 // DefaultConfig returns default configuration
@@ -25,15 +37,21 @@ func DefaultConfig(workspaceRoot string) Config {
 		WorkspaceRoot:   workspaceRoot,
 		UpdateInterval:  5,
 		MaxContextTurns: 10,
+		SummaryTimeout:  60 * time.Second,
 	}
 }
 
+// SummarizeFunc sends a summarizer prompt to the given model and returns the raw reply.
+// An empty model means the caller's main model should be used.
+type SummarizeFunc func(ctx context.Context, model, prompt string) (string, error)
+
 // WorkingMemory represents the compact memory blob fed to the model
 type WorkingMemory struct {
 	// Project info
 	ProjectType    string   `json:"project_type"`
 	BuildCommand   string   `json:"build_command"`
 	TestCommand    string   `json:"test_command"`
+	VerifySteps    []string `json:"verify_steps,omitempty"`
 	KeyDirectories []string `json:"key_directories"`
 	KeyModules     []string `json:"key_modules"`
 
@@ -63,6 +81,10 @@ type Manager struct {
 	transcriptFile    *os.File
 	fullLogFile       *os.File
 	cyclesSinceUpdate int
+	summarize         SummarizeFunc
+	recent            []LogEntry // Entries logged since the last summarization
+	dirtyFiles        []string   // Uncommitted files, used by the deterministic fallback
+	knowledge         *KnowledgeStore
 	mu                sync.RWMutex
 }
 
@@ -76,6 +98,10 @@ func NewManager(cfg Config) (*Manager, error) {
 
 	memoryFile := filepath.Join(memDir, "working_memory.json")
 
+	if cfg.SummaryTimeout <= 0 {
+		cfg.SummaryTimeout = 60 * time.Second
+	}
+
 	// Create transcript file with timestamp
 	timestamp := time.Now().Format("20060102-150405")
 	transcriptPath := filepath.Join(memDir, fmt.Sprintf("transcript_%s.jsonl", timestamp))
@@ -92,11 +118,19 @@ func NewManager(cfg Config) (*Manager, error) {
 		return nil, fmt.Errorf("failed to create full log file: %w", err)
 	}
 
+	knowledge, err := NewKnowledgeStore(cfg.WorkspaceRoot)
+	if err != nil {
+		transcriptFile.Close()
+		fullLogFile.Close()
+		return nil, err
+	}
+
 	m := &Manager{
 		config:         cfg,
 		memoryFile:     memoryFile,
 		transcriptFile: transcriptFile,
 		fullLogFile:    fullLogFile,
+		knowledge:      knowledge,
 	}
 
 	// Try to load existing memory
@@ -144,7 +178,7 @@ func (m *Manager) GetWorkingMemoryText() string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	if m.memory.ProjectType == "" && m.memory.BuildCommand == "" {
+	if m.memory.ProjectType == "" && m.memory.BuildCommand == "" && len(m.memory.VerifySteps) == 0 {
 		return "" // No memory yet
 	}
 
@@ -160,6 +194,12 @@ func (m *Manager) GetWorkingMemoryText() string {
 	if m.memory.TestCommand != "" {
 		b.WriteString(fmt.Sprintf("**Test Command:** `%s`\n", m.memory.TestCommand))
 	}
+	if len(m.memory.VerifySteps) > 0 {
+		b.WriteString("**Verification Steps:**\n")
+		for _, step := range m.memory.VerifySteps {
+			b.WriteString(fmt.Sprintf("- %s\n", step))
+		}
+	}
 
 	if len(m.memory.KeyDirectories) > 0 {
 		b.WriteString(fmt.Sprintf("**Key Directories:** %s\n", strings.Join(m.memory.KeyDirectories, ", ")))
@@ -219,6 +259,13 @@ func (m *Manager) SetProjectInfo(projectType, buildCmd, testCmd string) {
 	m.memory.TestCommand = testCmd
 }
 
+// SetVerifySteps sets the descriptions of the project's verification steps
+func (m *Manager) SetVerifySteps(steps []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.memory.VerifySteps = steps
+}
+
 // SetKeyDirectories sets key directories
 func (m *Manager) SetKeyDirectories(dirs []string) {
 	m.mu.Lock()
@@ -310,6 +357,7 @@ func (m *Manager) LogMessage(role, content string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.recordRecent(entry)
 	_, err = m.fullLogFile.Write(append(data, '\n'))
 	return err
 }
@@ -335,12 +383,38 @@ func (m *Manager) LogToolCall(name, args, output string, exitCode int, duration
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.recordRecent(entry)
 	_, err = m.fullLogFile.Write(append(data, '\n'))
 	return err
 }
 
-// OnCycleComplete is called after each agent cycle
-func (m *Manager) OnCycleComplete(cycleNum int) bool {
+// recordRecent keeps a bounded window of entries for the summarizer.
+// Caller must hold m.mu.
+func (m *Manager) recordRecent(entry LogEntry) {
+	m.recent = append(m.recent, entry)
+	if len(m.recent) > maxRecentEntries {
+		m.recent = m.recent[len(m.recent)-maxRecentEntries:]
+	}
+}
+
+// SetSummarizer sets the function used to call the summary model.
+// Without a summarizer, updates only persist the current memory.
+func (m *Manager) SetSummarizer(fn SummarizeFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.summarize = fn
+}
+
+// SetDirtyFiles records the uncommitted files used by the deterministic fallback
+func (m *Manager) SetDirtyFiles(files []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dirtyFiles = files
+}
+
+// OnCycleComplete is called after each agent cycle; a periodic update is
+// cancelled with ctx
+func (m *Manager) OnCycleComplete(ctx context.Context, cycleNum int) bool {
 	m.mu.Lock()
 	m.cyclesSinceUpdate++
 	m.memory.CycleCount = cycleNum
@@ -348,31 +422,69 @@ func (m *Manager) OnCycleComplete(cycleNum int) bool {
 	m.mu.Unlock()
 
 	if shouldUpdate {
-		m.TriggerUpdate("periodic")
+		m.TriggerUpdateContext(ctx, "periodic")
 	}
 
 	return shouldUpdate
 }
 
 // OnCheckpoint is called after a successful checkpoint commit
-func (m *Manager) OnCheckpoint(commitSHA string) {
+func (m *Manager) OnCheckpoint(ctx context.Context, commitSHA string) {
 	m.mu.Lock()
 	m.memory.LastGoodCommit = commitSHA
 	m.mu.Unlock()
 
-	m.TriggerUpdate("checkpoint")
+	m.TriggerUpdateContext(ctx, "checkpoint")
 }
 
 // OnSignificantFailure is called after a significant failure
-func (m *Manager) OnSignificantFailure(reason string) {
-	m.TriggerUpdate("failure: " + reason)
+func (m *Manager) OnSignificantFailure(ctx context.Context, reason string) {
+	m.TriggerUpdateContext(ctx, "failure: "+reason)
 }
 
 // TriggerUpdate triggers a memory update
 func (m *Manager) TriggerUpdate(reason string) {
+	m.TriggerUpdateContext(context.Background(), reason)
+}
+
+// TriggerUpdateContext triggers a memory update. When a summarizer is set, recent
+// transcript entries are sent to the summary model and the validated result is
+// merged into working memory; if the reply cannot be parsed, a deterministic
+// summary built from project and git state is merged instead.
+func (m *Manager) TriggerUpdateContext(ctx context.Context, reason string) {
+	m.mu.Lock()
+	summarize := m.summarize
+	recent := m.recent
+	m.recent = nil
+	m.mu.Unlock()
+
+	var update *WorkingMemory
+	if summarize != nil && len(recent) > 0 {
+		ctx, cancel := context.WithTimeout(ctx, m.config.SummaryTimeout)
+		reply, err := summarize(ctx, m.config.SummaryModel, SummarizerPrompt(formatActivity(recent)))
+		cancel()
+		if err == nil {
+			for _, fact := range ParseKnowledge(reply) {
+				m.RememberFact(fact)
+			}
+			update, err = ParseSummary(reply)
+		}
+		if err != nil {
+			m.mu.RLock()
+			fallback := BuildDeterministicSummary(m.memory.ProjectType, m.memory.BuildCommand,
+				m.memory.TestCommand, m.memory.CurrentBranch, m.dirtyFiles)
+			m.mu.RUnlock()
+			update = &fallback
+			reason += " (deterministic-fallback)"
+		}
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if update != nil {
+		m.memory = MergeSummary(m.memory, *update)
+	}
 	m.memory.UpdateReason = reason
 	m.memory.LastUpdated = time.Now()
 	m.cyclesSinceUpdate = 0
@@ -381,7 +493,25 @@ func (m *Manager) TriggerUpdate(reason string) {
 	m.saveMemory()
 }
 
-// Reset clears the working memory (keeps logs on disk)
+// Knowledge returns the cross-session knowledge store
+func (m *Manager) Knowledge() *KnowledgeStore {
+	return m.knowledge
+}
+
+// RememberFact records a fact in the knowledge store, attributed to this session
+func (m *Manager) RememberFact(fact Fact) (Fact, error) {
+	if fact.Source == "" {
+		fact.Source = m.config.SessionID
+	}
+	return m.knowledge.Add(fact)
+}
+
+// GetRelevantKnowledgeText returns the facts relevant to query formatted for the prompt
+func (m *Manager) GetRelevantKnowledgeText(query string) string {
+	return FormatKnowledge(m.knowledge.Search(query, MaxPromptFacts))
+}
+
+// Reset clears the working memory (keeps logs and knowledge on disk)
 func (m *Manager) Reset() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -394,6 +524,18 @@ func (m *Manager) Reset() {
 	m.saveMemory()
 }
 
+// Restore replaces the working memory with a snapshot, e.g. from a resumed session
+func (m *Manager) Restore(mem WorkingMemory) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.memory = mem
+	m.memory.LastUpdated = time.Now()
+	m.memory.UpdateReason = "restored"
+	m.cyclesSinceUpdate = 0
+	m.saveMemory()
+}
+
 // Close closes the memory manager
 func (m *Manager) Close() error {
 	m.mu.Lock()
@@ -443,14 +585,148 @@ OUTPUT FORMAT (JSON):
   "key_modules": ["mod1", "mod2"],
   "conventions": ["convention1"],
   "constraints": ["constraint1"],
-  "backlog_summary": ["item1", "item2"]
+  "backlog_summary": ["item1", "item2"],
+  "knowledge": [
+    {"kind": "command|ownership|failed_approach|note", "text": "fact worth keeping across sessions", "confidence": 0.8}
+  ]
 }
 
 RULES:
 - Only include fields with confirmed information
 - Keep entries concise (max 50 chars each)
 - Max 5 items per array
-- Focus on durable truths, not temporary state`, recentActivity)
+- Focus on durable truths, not temporary state
+- "knowledge" is kept across sessions: how to run things (e.g. a flaky test),
+  which package owns what, and approaches that were tried and failed`, recentActivity)
+}
+
+// formatActivity renders transcript entries as plain text for the summarizer
+func formatActivity(entries []LogEntry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		content := strings.TrimSpace(e.Content)
+		if len(content) > maxEntryChars {
+			content = content[:maxEntryChars] + "..."
+		}
+		if exitCode, ok := e.Metadata["exit_code"]; ok {
+			b.WriteString(fmt.Sprintf("[%s] %v (exit %v) %s\n", e.Type, e.Metadata["args"], exitCode, content))
+		} else {
+			b.WriteString(fmt.Sprintf("[%s] %s\n", e.Type, content))
+		}
+	}
+	return b.String()
+}
+
+// ParseSummary extracts and validates the summarizer's JSON reply.
+// The reply may wrap the object in prose or a code fence.
+func ParseSummary(reply string) (*WorkingMemory, error) {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start == -1 || end <= start {
+		return nil, fmt.Errorf("no JSON object in summarizer reply")
+	}
+
+	var mem WorkingMemory
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &mem); err != nil {
+		return nil, fmt.Errorf("invalid summarizer JSON: %w", err)
+	}
+
+	if mem.ProjectType == "" && mem.BuildCommand == "" && mem.TestCommand == "" &&
+		len(mem.KeyDirectories) == 0 && len(mem.KeyModules) == 0 &&
+		len(mem.Conventions) == 0 && len(mem.Constraints) == 0 && len(mem.BacklogSummary) == 0 {
+		return nil, fmt.Errorf("summarizer reply contains no facts")
+	}
+
+	return &mem, nil
+}
+
+// ParseKnowledge extracts knowledge facts from the summarizer's reply.
+// Invalid or missing entries are ignored.
+func ParseKnowledge(reply string) []Fact {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start == -1 || end <= start {
+		return nil
+	}
+
+	var parsed struct {
+		Knowledge []Fact `json:"knowledge"`
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &parsed); err != nil {
+		return nil
+	}
+
+	var facts []Fact
+	for _, f := range parsed.Knowledge {
+		if strings.TrimSpace(f.Text) == "" {
+			continue
+		}
+		switch f.Kind {
+		case FactKindCommand, FactKindOwnership, FactKindFailedApproach, FactKindNote:
+		default:
+			f.Kind = FactKindNote
+		}
+		facts = append(facts, Fact{Kind: f.Kind, Text: f.Text, Confidence: f.Confidence})
+	}
+	return facts
+}
+
+// MergeSummary merges a summary into existing memory. Non-empty scalar fields
+// replace existing values; array fields keep the newest entries first, drop
+// duplicates, and are limited to MaxSummaryItems entries of MaxSummaryItemChars.
+// The backlog summary describes current state, so it is replaced rather than merged.
+func MergeSummary(existing, update WorkingMemory) WorkingMemory {
+	merged := existing
+
+	if update.ProjectType != "" {
+		merged.ProjectType = update.ProjectType
+	}
+	if update.BuildCommand != "" {
+		merged.BuildCommand = update.BuildCommand
+	}
+	if update.TestCommand != "" {
+		merged.TestCommand = update.TestCommand
+	}
+	if update.CurrentBranch != "" {
+		merged.CurrentBranch = update.CurrentBranch
+	}
+
+	merged.KeyDirectories = mergeItems(update.KeyDirectories, existing.KeyDirectories)
+	merged.KeyModules = mergeItems(update.KeyModules, existing.KeyModules)
+	merged.Conventions = mergeItems(update.Conventions, existing.Conventions)
+	merged.Constraints = mergeItems(update.Constraints, existing.Constraints)
+
+	if len(update.BacklogSummary) > 0 {
+		merged.BacklogSummary = mergeItems(update.BacklogSummary, nil)
+	}
+
+	return merged
+}
+
+// mergeItems combines two lists under the summary limits, preferring items from first
+func mergeItems(first, second []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+
+	for _, list := range [][]string{first, second} {
+		for _, item := range list {
+			item = strings.TrimSpace(item)
+			if runes := []rune(item); len(runes) > MaxSummaryItemChars {
+				item = strings.TrimSpace(string(runes[:MaxSummaryItemChars]))
+			}
+			key := strings.ToLower(item)
+			if item == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, item)
+			if len(result) >= MaxSummaryItems {
+				return result
+			}
+		}
+	}
+
+	return result
 }
 
 // BuildDeterministicSummary builds a fallback summary from tool logs and git state
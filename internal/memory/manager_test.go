@@ -1,12 +1,14 @@
 package memory
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -233,6 +235,7 @@ func TestManager_GetWorkingMemoryText_WithData(t *testing.T) {
 	defer m.Close()
 
 	m.SetProjectInfo("go", "go build ./...", "go test ./...")
+	m.SetVerifySteps([]string{"lint: `task lint` (advisory)"})
 	m.SetKeyDirectories([]string{"cmd/", "internal/"})
 	m.SetKeyModules([]string{"engine", "tools"})
 	m.AddConvention("Use gofmt")
@@ -256,6 +259,9 @@ func TestManager_GetWorkingMemoryText_WithData(t *testing.T) {
 	if !strings.Contains(text, "go test ./...") {
 		t.Error("expected test command")
 	}
+	if !strings.Contains(text, "- lint: `task lint` (advisory)") {
+		t.Error("expected verification steps")
+	}
 	if !strings.Contains(text, "cmd/") {
 		t.Error("expected key directories")
 	}
@@ -384,14 +390,14 @@ func TestManager_OnCycleComplete(t *testing.T) {
 	defer m.Close()
 
 	// First 2 cycles should not trigger update
-	if m.OnCycleComplete(1) {
+	if m.OnCycleComplete(context.Background(), 1) {
 		t.Error("should not update on cycle 1")
 	}
-	if m.OnCycleComplete(2) {
+	if m.OnCycleComplete(context.Background(), 2) {
 		t.Error("should not update on cycle 2")
 	}
 	// Third cycle should trigger update
-	if !m.OnCycleComplete(3) {
+	if !m.OnCycleComplete(context.Background(), 3) {
 		t.Error("should update on cycle 3")
 	}
 
@@ -412,7 +418,7 @@ func TestManager_OnCheckpoint(t *testing.T) {
 	}
 	defer m.Close()
 
-	m.OnCheckpoint("abc123def")
+	m.OnCheckpoint(context.Background(), "abc123def")
 
 	mem := m.GetWorkingMemory()
 	if mem.LastGoodCommit != "abc123def" {
@@ -431,7 +437,7 @@ func TestManager_OnSignificantFailure(t *testing.T) {
 	}
 	defer m.Close()
 
-	m.OnSignificantFailure("test failed")
+	m.OnSignificantFailure(context.Background(), "test failed")
 
 	mem := m.GetWorkingMemory()
 	if mem.UpdateReason != "failure: test failed" {
@@ -584,3 +590,201 @@ func TestBuildDeterministicSummary_ManyDirtyFiles(t *testing.T) {
 		t.Errorf("expected max 5 backlog items, got %d", len(mem.BacklogSummary))
 	}
 }
+
+func TestParseSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		wantErr bool
+		check   func(*WorkingMemory) bool
+	}{
+		{
+			name:  "plain json",
+			reply: `{"project_type": "go", "conventions": ["tabs"]}`,
+			check: func(wm *WorkingMemory) bool { return wm.ProjectType == "go" && len(wm.Conventions) == 1 },
+		},
+		{
+			name:  "fenced json with prose",
+			reply: "Here is the summary:\n```json\n{\"test_command\": \"go test ./...\"}\n```",
+			check: func(wm *WorkingMemory) bool { return wm.TestCommand == "go test ./..." },
+		},
+		{name: "no json", reply: "nothing to report", wantErr: true},
+		{name: "invalid json", reply: "{not json}", wantErr: true},
+		{name: "empty object", reply: "{}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wm, err := ParseSummary(tt.reply)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.check(wm) {
+				t.Errorf("unexpected summary: %+v", wm)
+			}
+		})
+	}
+}
+
+func TestMergeSummary(t *testing.T) {
+	existing := WorkingMemory{
+		ProjectType:   "go",
+		TestCommand:   "go test ./...",
+		Conventions:   []string{"tabs", "short names"},
+		CurrentBranch: "main",
+	}
+	update := WorkingMemory{
+		TestCommand: "go test -race ./...",
+		Conventions: []string{"Tabs", "errors wrapped with %w", "one", "two", "three",
+			strings.Repeat("x", 80)},
+	}
+
+	merged := MergeSummary(existing, update)
+
+	if merged.ProjectType != "go" {
+		t.Errorf("expected project type to be kept, got %s", merged.ProjectType)
+	}
+	if merged.TestCommand != "go test -race ./..." {
+		t.Errorf("expected test command to be overridden, got %s", merged.TestCommand)
+	}
+	if merged.CurrentBranch != "main" {
+		t.Errorf("expected branch to be kept, got %s", merged.CurrentBranch)
+	}
+	if len(merged.Conventions) != MaxSummaryItems {
+		t.Fatalf("expected %d conventions, got %d: %v", MaxSummaryItems, len(merged.Conventions), merged.Conventions)
+	}
+	if merged.Conventions[0] != "Tabs" {
+		t.Errorf("expected newest entries first, got %v", merged.Conventions)
+	}
+	for _, c := range merged.Conventions {
+		if len(c) > MaxSummaryItemChars {
+			t.Errorf("entry exceeds %d chars: %q", MaxSummaryItemChars, c)
+		}
+		if c == "tabs" {
+			t.Errorf("expected case-insensitive dedupe, got %v", merged.Conventions)
+		}
+	}
+}
+
+func TestMergeItems_TruncatesByRunes(t *testing.T) {
+	item := strings.Repeat("é", MaxSummaryItemChars+10)
+	merged := mergeItems([]string{item}, nil)
+	if len(merged) != 1 {
+		t.Fatalf("expected 1 item, got %v", merged)
+	}
+	if !utf8.ValidString(merged[0]) {
+		t.Errorf("expected valid UTF-8, got %q", merged[0])
+	}
+	if n := utf8.RuneCountInString(merged[0]); n != MaxSummaryItemChars {
+		t.Errorf("expected %d characters, got %d", MaxSummaryItemChars, n)
+	}
+}
+
+func TestManager_TriggerUpdate_Summarizer(t *testing.T) {
+	tempDir := t.TempDir()
+
+	m, err := NewManager(Config{WorkspaceRoot: tempDir, UpdateInterval: 5, SummaryModel: "small"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer m.Close()
+
+	var gotModel, gotPrompt string
+	m.SetSummarizer(func(ctx context.Context, model, prompt string) (string, error) {
+		gotModel, gotPrompt = model, prompt
+		return `{"build_command": "make", "key_modules": ["internal/engine"]}`, nil
+	})
+
+	m.LogToolCall("shell", "make test", "ok", 0, 1.0)
+	m.TriggerUpdate("checkpoint")
+
+	if gotModel != "small" {
+		t.Errorf("expected summary model 'small', got %q", gotModel)
+	}
+	if !strings.Contains(gotPrompt, "make test") {
+		t.Error("prompt should contain recent activity")
+	}
+
+	wm := m.GetWorkingMemory()
+	if wm.BuildCommand != "make" {
+		t.Errorf("expected build command 'make', got %s", wm.BuildCommand)
+	}
+	if len(wm.KeyModules) != 1 || wm.KeyModules[0] != "internal/engine" {
+		t.Errorf("unexpected key modules: %v", wm.KeyModules)
+	}
+	if wm.UpdateReason != "checkpoint" {
+		t.Errorf("expected reason 'checkpoint', got %s", wm.UpdateReason)
+	}
+
+	// Activity is consumed; nothing new means no summarizer call
+	gotPrompt = ""
+	m.TriggerUpdate("periodic")
+	if gotPrompt != "" {
+		t.Error("summarizer should not be called without new activity")
+	}
+}
+
+func TestManager_OnCheckpoint_Cancelled(t *testing.T) {
+	m, err := NewManager(Config{WorkspaceRoot: t.TempDir(), UpdateInterval: 5, SummaryTimeout: time.Minute})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer m.Close()
+
+	// The summarizer waits for its context like a slow model would
+	m.SetSummarizer(func(ctx context.Context, model, prompt string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	m.LogMessage("assistant", "editing main.go")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	m.OnCheckpoint(ctx, "abc123")
+	if time.Since(start) > 5*time.Second {
+		t.Error("expected a cancelled engine not to wait for the summary model")
+	}
+	if wm := m.GetWorkingMemory(); !strings.Contains(wm.UpdateReason, "deterministic-fallback") {
+		t.Errorf("expected the deterministic fallback, got %s", wm.UpdateReason)
+	}
+}
+
+func TestManager_TriggerUpdate_Fallback(t *testing.T) {
+	tempDir := t.TempDir()
+
+	m, err := NewManager(Config{WorkspaceRoot: tempDir, UpdateInterval: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer m.Close()
+
+	m.SetProjectInfo("go", "go build ./...", "go test ./...")
+	m.SetDirtyFiles([]string{"main.go"})
+	m.SetSummarizer(func(ctx context.Context, model, prompt string) (string, error) {
+		return "I could not find anything durable.", nil
+	})
+
+	m.LogMessage("assistant", "editing main.go")
+	m.TriggerUpdate("periodic")
+
+	wm := m.GetWorkingMemory()
+	if !strings.Contains(wm.UpdateReason, "deterministic-fallback") {
+		t.Errorf("expected fallback reason, got %s", wm.UpdateReason)
+	}
+	found := false
+	for _, item := range wm.BacklogSummary {
+		if strings.Contains(item, "main.go") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected dirty files in backlog summary, got %v", wm.BacklogSummary)
+	}
+}
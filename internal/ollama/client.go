@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -20,8 +21,12 @@ const (
 	DefaultLocalBaseURL = "http://localhost:11434"
 	DefaultCloudBaseURL = "https://ollama.com"
 	DefaultTimeout      = 120 * time.Second
+	MaxRequestBytes     = 50000 // Streamed requests larger than this are rejected
 )
 
+// ErrTokenizeUnsupported is returned when the server has no tokenize endpoint
+var ErrTokenizeUnsupported = errors.New("tokenize endpoint not supported")
+
 // Known model context sizes (in tokens)
 // These are defaults when the model info API doesn't provide context size
 var knownModelContextSizes = map[string]int{
@@ -170,6 +175,29 @@ type TagsResponse struct {
 	Models []ModelInfo `json:"models"`
 }
 
+// TokenizeRequest represents an /api/tokenize request
+type TokenizeRequest struct {
+	Model   string `json:"model"`
+	Content string `json:"content"`
+}
+
+// TokenizeResponse represents the response from /api/tokenize
+type TokenizeResponse struct {
+	Tokens []int `json:"tokens"`
+}
+
+// EmbedRequest represents an /api/embed request
+type EmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbedResponse represents the response from /api/embed
+type EmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
 // TokenMetrics contains token usage metrics from a response
 type TokenMetrics struct {
 	PromptEvalCount    int     // Number of tokens in the prompt
@@ -273,7 +301,7 @@ func (c *Client) GetModelContextSize() int {
 }
 
 // LookupModelContextSize returns the context size for a model name
-// It checks exact matches first, then prefix matches
+// It checks exact matches first, then the longest prefix match
 func LookupModelContextSize(model string) int {
 	if model == "" {
 		return 8192 // default fallback
@@ -291,12 +319,20 @@ func LookupModelContextSize(model string) int {
 		return size
 	}
 
-	// Check prefix matches (e.g., "gemini-3-flash-preview" matches "gemini")
+	// Check prefix matches (e.g., "gemini-3-flash-preview" matches "gemini").
+	// The longest matching pattern (the most specific model) wins, so the
+	// result does not depend on map iteration order.
+	bestLen := 0
+	bestSize := 0
 	for pattern, size := range knownModelContextSizes {
-		if strings.HasPrefix(baseName, pattern) {
-			return size
+		if strings.HasPrefix(baseName, pattern) && len(pattern) > bestLen {
+			bestLen = len(pattern)
+			bestSize = size
 		}
 	}
+	if bestLen > 0 {
+		return bestSize
+	}
 
 	// Check if it contains "cloud" tag - likely a large context cloud model
 	if strings.Contains(strings.ToLower(model), ":cloud") {
@@ -433,53 +469,12 @@ func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
 
 // ChatStream sends a chat request and returns a channel for streaming responses
 func (c *Client) ChatStream(ctx context.Context, messages []Message, tools []Tool) (<-chan StreamChunk, error) {
-	c.mu.RLock()
-	model := c.model
-	numCtx := c.numCtx
-	c.mu.RUnlock()
-
-	if model == "" {
-		return nil, fmt.Errorf("no model selected; use SetModel() or set OLLAMA_MODEL")
-	}
-
-	// Keep messages minimal - truncate content if too long
-	// IMPORTANT: Do NOT include thinking field in outgoing messages
-	var trimmedMessages []Message
-	for _, m := range messages {
-		content := m.Content
-		if len(content) > 2000 {
-			content = content[:2000] + "..."
-		}
-		trimmedMessages = append(trimmedMessages, Message{
-			Role:    m.Role,
-			Content: content,
-			// Thinking is intentionally omitted - it's UI-only
-		})
-	}
-
-	chatReq := ChatRequest{
-		Model:    model,
-		Messages: trimmedMessages,
-		Stream:   true,
-	}
-
-	// Add options if context size is set
-	if numCtx > 0 {
-		chatReq.Options = &ChatOptions{
-			NumCtx: numCtx,
-		}
-	}
-
-	// Add think field based on thinkMode
-	chatReq.Think = c.buildThinkValue()
-
-	body, err := json.Marshal(chatReq)
+	// The caller is responsible for fitting messages to the context window
+	body, err := c.streamRequestBody(messages)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
-
-	// Log request size for debugging
-	if len(body) > 50000 {
+	if len(body) > MaxRequestBytes {
 		return nil, fmt.Errorf("request too large (%d bytes), reduce context", len(body))
 	}
 
@@ -587,10 +582,76 @@ func (c *Client) ChatStream(ctx context.Context, messages []Message, tools []Too
 	return ch, nil
 }
 
+// streamRequestBody encodes the request ChatStream sends for messages
+func (c *Client) streamRequestBody(messages []Message) ([]byte, error) {
+	c.mu.RLock()
+	model := c.model
+	numCtx := c.numCtx
+	c.mu.RUnlock()
+
+	if model == "" {
+		return nil, fmt.Errorf("no model selected; use SetModel() or set OLLAMA_MODEL")
+	}
+
+	chatReq := ChatRequest{
+		Model:    model,
+		Messages: OutgoingMessages(messages),
+		Stream:   true,
+	}
+
+	// Add options if context size is set
+	if numCtx > 0 {
+		chatReq.Options = &ChatOptions{
+			NumCtx: numCtx,
+		}
+	}
+
+	// Add think field based on thinkMode
+	chatReq.Think = c.buildThinkValue()
+
+	body, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return body, nil
+}
+
+// RequestSize returns the encoded size in bytes of the request ChatStream
+// would send for messages, including JSON escaping
+func (c *Client) RequestSize(messages []Message) int {
+	body, err := c.streamRequestBody(messages)
+	if err != nil {
+		return 0
+	}
+	return len(body)
+}
+
+// OutgoingMessages returns the messages as ChatStream sends them
+func OutgoingMessages(messages []Message) []Message {
+	// IMPORTANT: Do NOT include thinking field in outgoing messages
+	outgoing := make([]Message, 0, len(messages))
+	for _, m := range messages {
+		outgoing = append(outgoing, Message{
+			Role:    m.Role,
+			Content: m.Content,
+			// Thinking is intentionally omitted - it's UI-only
+		})
+	}
+	return outgoing
+}
+
 // Chat sends a non-streaming chat request
 func (c *Client) Chat(ctx context.Context, messages []Message, tools []Tool) (*ChatResponse, error) {
+	return c.ChatWithModel(ctx, "", messages, tools)
+}
+
+// ChatWithModel sends a non-streaming chat request to a specific model.
+// An empty model uses the client's current model.
+func (c *Client) ChatWithModel(ctx context.Context, model string, messages []Message, tools []Tool) (*ChatResponse, error) {
 	c.mu.RLock()
-	model := c.model
+	if model == "" {
+		model = c.model
+	}
 	numCtx := c.numCtx
 	c.mu.RUnlock()
 
@@ -667,6 +728,97 @@ func (c *Client) Chat(ctx context.Context, messages []Message, tools []Tool) (*C
 	return &chatResp, nil
 }
 
+// Tokenize returns the number of tokens the model's tokenizer produces for content.
+// Servers without a tokenize endpoint yield ErrTokenizeUnsupported.
+func (c *Client) Tokenize(ctx context.Context, model, content string) (int, error) {
+	if model == "" {
+		return 0, fmt.Errorf("no model specified")
+	}
+
+	body, err := json.Marshal(TokenizeRequest{Model: model, Content: content})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/tokenize", bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return 0, ErrTokenizeUnsupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var tokResp TokenizeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokResp); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return len(tokResp.Tokens), nil
+}
+
+// Embed returns one embedding vector per input using the given embedding model
+func (c *Client) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	if model == "" {
+		return nil, fmt.Errorf("no embedding model specified")
+	}
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(EmbedRequest{Model: model, Input: inputs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var embedResp EmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(embedResp.Embeddings) != len(inputs) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(inputs), len(embedResp.Embeddings))
+	}
+
+	return embedResp.Embeddings, nil
+}
+
 // IsAvailable checks if Ollama is reachable
 func (c *Client) IsAvailable(ctx context.Context) bool {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
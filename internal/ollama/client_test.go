@@ -3,9 +3,11 @@ package ollama
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -391,7 +393,7 @@ func TestLookupModelContextSize(t *testing.T) {
 
 		// Prefix matches
 		{"gemini-2.5-flash-exp", 1048576},
-		{"deepseek-r1-lite", 65536}, // matches "deepseek" prefix (not "deepseek-r1")
+		{"deepseek-r1-lite", 131072}, // matches "deepseek-r1", the longest prefix
 
 		// Cloud tag default
 		{"unknown-model:cloud", 131072},
@@ -411,6 +413,24 @@ func TestLookupModelContextSize(t *testing.T) {
 	}
 }
 
+func TestLookupModelContextSize_LongestPrefixWins(t *testing.T) {
+	// Each of these matches several prefixes; the longest (the most specific
+	// model) wins on every lookup, whatever the map iteration order
+	tests := map[string]int{
+		"deepseek-r1-lite": 131072, // deepseek, deepseek-r1
+		"qwen2.5-coder":    131072, // qwen, qwen2, qwen2.5
+		"llama3.1-custom":  131072, // llama3, llama3.1
+		"mistral-nemo":     32768,  // mistral
+	}
+	for model, want := range tests {
+		for i := 0; i < 50; i++ {
+			if got := LookupModelContextSize(model); got != want {
+				t.Fatalf("LookupModelContextSize(%q) = %d, want %d", model, got, want)
+			}
+		}
+	}
+}
+
 func TestClient_GetModelContextSize(t *testing.T) {
 	os.Unsetenv("OLLAMA_MODEL")
 	c := NewClient()
@@ -903,3 +923,85 @@ func TestMessage_ThinkingFieldNotSentToAPI(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestClient_Embed(t *testing.T) {
+	server := newMockServer(mockResponse{})
+	defer server.Close()
+
+	os.Setenv("OLLAMA_HOST", server.URL)
+	defer os.Unsetenv("OLLAMA_HOST")
+
+	c := NewClient()
+
+	t.Run("one vector per input", func(t *testing.T) {
+		vectors, err := c.Embed(context.Background(), "nomic-embed-text", []string{"a", "bcd"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(vectors) != 2 {
+			t.Fatalf("expected 2 vectors, got %d", len(vectors))
+		}
+		if vectors[1][0] != 3 || vectors[1][1] != 1 {
+			t.Errorf("unexpected vector: %v", vectors[1])
+		}
+	})
+
+	t.Run("no model", func(t *testing.T) {
+		if _, err := c.Embed(context.Background(), "", []string{"a"}); err == nil {
+			t.Error("expected error without model")
+		}
+	})
+
+	t.Run("no inputs", func(t *testing.T) {
+		vectors, err := c.Embed(context.Background(), "nomic-embed-text", nil)
+		if err != nil || vectors != nil {
+			t.Errorf("expected nil result, got %v, %v", vectors, err)
+		}
+	})
+}
+
+func TestClient_Tokenize(t *testing.T) {
+	t.Run("counts tokens", func(t *testing.T) {
+		server := newMockServer(mockResponse{})
+		defer server.Close()
+
+		os.Setenv("OLLAMA_HOST", server.URL)
+		defer os.Unsetenv("OLLAMA_HOST")
+
+		n, err := NewClient().Tokenize(context.Background(), "test-model", "one two three")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != 3 {
+			t.Errorf("expected 3 tokens, got %d", n)
+		}
+	})
+
+	t.Run("unsupported endpoint", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		os.Setenv("OLLAMA_HOST", server.URL)
+		defer os.Unsetenv("OLLAMA_HOST")
+
+		_, err := NewClient().Tokenize(context.Background(), "test-model", "text")
+		if !errors.Is(err, ErrTokenizeUnsupported) {
+			t.Errorf("expected ErrTokenizeUnsupported, got %v", err)
+		}
+	})
+}
+
+func TestOutgoingMessages(t *testing.T) {
+	long := strings.Repeat("a", 5000)
+	msgs := OutgoingMessages([]Message{
+		{Role: "system", Content: "short", Thinking: "hidden"},
+		{Role: "user", Content: long},
+	})
+
+	if msgs[0].Thinking != "" {
+		t.Error("thinking should be dropped")
+	}
+	if msgs[1].Content != long {
+		t.Errorf("expected content to be sent unchanged, got %d chars", len(msgs[1].Content))
+	}
+}
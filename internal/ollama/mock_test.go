@@ -32,6 +32,16 @@ func newMockServer(response mockResponse) *httptest.Server {
 			return
 		}
 
+		if r.URL.Path == "/api/tokenize" {
+			handleTokenizeMock(w, r)
+			return
+		}
+
+		if r.URL.Path == "/api/embed" {
+			handleEmbedMock(w, r)
+			return
+		}
+
 		http.NotFound(w, r)
 	}))
 }
@@ -47,6 +57,38 @@ func handleTagsMock(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// handleTokenizeMock handles /api/tokenize endpoint, returning one token per word
+func handleTokenizeMock(w http.ResponseWriter, r *http.Request) {
+	var req TokenizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := TokenizeResponse{}
+	for i := range strings.Fields(req.Content) {
+		resp.Tokens = append(resp.Tokens, i)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleEmbedMock handles /api/embed endpoint, returning [len(input), index] per input
+func handleEmbedMock(w http.ResponseWriter, r *http.Request) {
+	var req EmbedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := EmbedResponse{Model: req.Model}
+	for i, input := range req.Input {
+		resp.Embeddings = append(resp.Embeddings, []float32{float32(len(input)), float32(i)})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
 // handleChatMock handles /api/chat endpoint
 func handleChatMock(w http.ResponseWriter, r *http.Request, response mockResponse) {
 	// Use a generic map to avoid ThinkValue unmarshaling issues in tests
@@ -82,7 +82,7 @@ func NewManager(appName, workspaceRoot, projectType string) *Manager {
 
 // buildBasePrompt creates the default system prompt
 func (m *Manager) buildBasePrompt() string {
-	return fmt.Sprintf(`You are an autonomous coding agent working in %s (%s).
+	base := fmt.Sprintf(`You are an autonomous coding agent working in %s (%s).
 
 ## CORE PRINCIPLES
 
@@ -133,6 +133,41 @@ I will run those commands and show you the output. Be concise. Do one thing at a
 		m.workspaceRoot,
 		m.projectType,
 		"```", "```")
+
+	if hints := ecosystemHints(m.projectType); len(hints) > 0 {
+		base += "\n\n## ECOSYSTEM NOTES\n\n" + strings.Join(hints, "\n")
+	}
+	return base
+}
+
+// ecosystemNotes holds tooling conventions for project types whose workflow
+// differs most from the common defaults
+var ecosystemNotes = map[string]string{
+	"python": "- Python: commands already run in the project's virtualenv or through its package manager (`uv run`, `poetry run`, `pipenv run`); add dependencies with that manager (`uv add`, `poetry add`, `pipenv install`) rather than a global `pip install`.",
+	"dotnet": "- .NET: pass the solution file to `dotnet build`/`dotnet test`; run one test with `dotnet test --filter FullyQualifiedName~Name`; restore packages with `dotnet restore`; never edit bin/ or obj/.",
+	"ruby":   "- Ruby: run tools through `bundle exec`; run one spec with `bundle exec rspec path/to/file_spec.rb:LINE` or one Minitest file with `bundle exec ruby -Itest path/to/file_test.rb`; add gems with `bundle add`, never edit Gemfile.lock by hand.",
+	"php":    "- PHP: install dependencies with `composer install` and run tools from vendor/bin; run one test with `vendor/bin/phpunit --filter testName`; add packages with `composer require`, never edit composer.lock or vendor/ by hand.",
+	"elixir": "- Elixir: run one test with `mix test path/to/file_test.exs:LINE`; fetch dependencies with `mix deps.get`; keep code `mix format`ted; never edit _build/ or deps/.",
+	"swift":  "- Swift: run one test with `swift test --filter Module.TestCase/testName`; resolve dependencies with `swift package resolve`; declare new targets in Package.swift; never edit .build/.",
+	"cpp":    "- C/C++: builds are configured once (CMake into build/, Meson into builddir/) and then incremental; run one test with `ctest --test-dir build -R name` or `meson test -C builddir name`; register new sources and tests in CMakeLists.txt or meson.build; never edit generated files in the build directory.",
+}
+
+// ecosystemHints returns the notes for the project types in a type summary
+// such as "go, web (node)"
+func ecosystemHints(summary string) []string {
+	var hints []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(summary, ", ") {
+		projectType := part
+		if start := strings.LastIndex(part, " ("); start >= 0 && strings.HasSuffix(part, ")") {
+			projectType = part[start+2 : len(part)-1]
+		}
+		if note, ok := ecosystemNotes[projectType]; ok && !seen[projectType] {
+			seen[projectType] = true
+			hints = append(hints, note)
+		}
+	}
+	return hints
 }
 
 // loadRepoInstructions loads instructions from the repository
@@ -429,3 +429,24 @@ func TestManager_ReloadInstructions(t *testing.T) {
 		t.Errorf("expected 'Updated content' after reload, got %q", layers[1].Content)
 	}
 }
+
+func TestManager_EcosystemNotes(t *testing.T) {
+	tempDir := t.TempDir()
+
+	m := NewManager("testapp", tempDir, "go")
+	if strings.Contains(m.GetEffectivePrompt(), "ECOSYSTEM NOTES") {
+		t.Error("expected no ecosystem notes for a Go project")
+	}
+
+	m = NewManager("testapp", tempDir, "go, services/billing (dotnet), web (ruby), api (dotnet)")
+	prompt := m.GetEffectivePrompt()
+	if !strings.Contains(prompt, "## ECOSYSTEM NOTES") {
+		t.Fatal("expected ecosystem notes for sub-project types")
+	}
+	if strings.Count(prompt, "- .NET:") != 1 {
+		t.Error("expected the .NET note exactly once")
+	}
+	if !strings.Contains(prompt, "bundle exec rspec") {
+		t.Error("expected the Ruby note")
+	}
+}
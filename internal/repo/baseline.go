@@ -0,0 +1,226 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Baseline is the verification state of the workspace before any changes,
+// so later runs can tell new breakage from pre-existing failures
+type Baseline struct {
+	Steps    map[string]bool   // Step name -> passed
+	Tests    map[string]string // Failing test -> step name
+	Findings map[string]string // Lint finding -> step name
+}
+
+// Snapshot runs every verification step of every project except formatters,
+// without stopping at failures, and records the outcome as a baseline
+func (v *Verifier) Snapshot(ctx context.Context) (*Baseline, []*VerificationResult) {
+	var results []*VerificationResult
+	for _, project := range v.project.Affected(nil) {
+		for _, step := range project.VerifySteps() {
+			if step.Kind == StepFormat {
+				continue
+			}
+			result := v.runTestStep(ctx, project, step)
+			if project.Dir != "" {
+				result.Name = project.Dir + ": " + result.Name
+			}
+			results = append(results, result)
+		}
+	}
+	return NewBaseline(results), results
+}
+
+// NewBaseline records the outcome of verification results as a baseline
+func NewBaseline(results []*VerificationResult) *Baseline {
+	b := &Baseline{
+		Steps:    make(map[string]bool),
+		Tests:    make(map[string]string),
+		Findings: make(map[string]string),
+	}
+	for _, r := range results {
+		b.Steps[r.Name] = r.Success
+		for _, test := range r.Failures {
+			b.Tests[test] = r.Name
+		}
+		for _, finding := range r.Findings {
+			b.Findings[finding] = r.Name
+		}
+	}
+	return b
+}
+
+// Summary describes the baseline for the model
+func (b *Baseline) Summary() string {
+	var failed []string
+	for name, passed := range b.Steps {
+		if !passed {
+			failed = append(failed, name)
+		}
+	}
+	if len(failed) == 0 {
+		return "Verification baseline (session start): all steps passed. Any failure is caused by changes made since."
+	}
+	sort.Strings(failed)
+
+	var sb strings.Builder
+	sb.WriteString("Verification baseline (session start): these failures pre-date your changes.\n")
+	sb.WriteString("Failing steps: " + strings.Join(failed, ", ") + "\n")
+	if tests := sortedKeys(b.Tests); len(tests) > 0 {
+		sb.WriteString(fmt.Sprintf("Failing tests (%d): %s\n", len(tests), truncateList(tests, 20)))
+	}
+	if len(b.Findings) > 0 {
+		sb.WriteString(fmt.Sprintf("Lint findings: %d\n", len(b.Findings)))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// Preexisting reports whether a failed result only repeats failures recorded
+// at baseline: its step failed then too and it has no new failing tests or
+// lint findings. A step whose output can't be attributed to tests or findings
+// must fail the same unattributed way it did at baseline.
+func (b *Baseline) Preexisting(r *VerificationResult) bool {
+	if b == nil || r.Success {
+		return false
+	}
+	if passed, ok := b.Steps[r.Name]; !ok || passed {
+		return false
+	}
+	for _, test := range r.Failures {
+		if _, ok := b.Tests[test]; !ok {
+			return false
+		}
+	}
+	for _, finding := range r.Findings {
+		if _, ok := b.Findings[finding]; !ok {
+			return false
+		}
+	}
+	if len(r.Failures) == 0 && len(r.Findings) == 0 {
+		return !b.attributed(r.Name)
+	}
+	return true
+}
+
+// attributed reports whether the baseline recorded failing tests or findings
+// for a step
+func (b *Baseline) attributed(step string) bool {
+	for _, name := range b.Tests {
+		if name == step {
+			return true
+		}
+	}
+	for _, name := range b.Findings {
+		if name == step {
+			return true
+		}
+	}
+	return false
+}
+
+// Delta is how verification results differ from the baseline
+type Delta struct {
+	NewFailures   []string // Tests failing now that did not fail at baseline
+	Fixed         []string // Tests failing at baseline that pass now
+	Broken        []string // Steps passing at baseline that fail now
+	Repaired      []string // Steps failing at baseline that pass now
+	NewFindings   []string // Lint findings not present at baseline
+	FixedFindings []string // Lint findings at baseline that are gone
+}
+
+// Compare returns the delta of results against the baseline. Pre-existing
+// failures are only reported fixed when the step that had them ran again,
+// so targeted runs report new failures but never fixes.
+func (b *Baseline) Compare(results []*VerificationResult) Delta {
+	var d Delta
+	ran := make(map[string]bool)
+	failing := make(map[string]bool)
+	findings := make(map[string]bool)
+	for _, r := range results {
+		ran[r.Name] = true
+		if passed, ok := b.Steps[r.Name]; ok && passed != r.Success {
+			if r.Success {
+				d.Repaired = append(d.Repaired, r.Name)
+			} else {
+				d.Broken = append(d.Broken, r.Name)
+			}
+		}
+		for _, test := range r.Failures {
+			failing[test] = true
+			if _, ok := b.Tests[test]; !ok {
+				d.NewFailures = append(d.NewFailures, test)
+			}
+		}
+		for _, finding := range r.Findings {
+			findings[finding] = true
+			if _, ok := b.Findings[finding]; !ok {
+				d.NewFindings = append(d.NewFindings, finding)
+			}
+		}
+	}
+	for test, step := range b.Tests {
+		if ran[step] && !failing[test] {
+			d.Fixed = append(d.Fixed, test)
+		}
+	}
+	for finding, step := range b.Findings {
+		if ran[step] && !findings[finding] {
+			d.FixedFindings = append(d.FixedFindings, finding)
+		}
+	}
+
+	for _, list := range [][]string{d.NewFailures, d.Fixed, d.Broken, d.Repaired, d.NewFindings, d.FixedFindings} {
+		sort.Strings(list)
+	}
+	return d
+}
+
+// Empty reports whether results match the baseline
+func (d Delta) Empty() bool {
+	return len(d.NewFailures)+len(d.Fixed)+len(d.Broken)+len(d.Repaired)+len(d.NewFindings)+len(d.FixedFindings) == 0
+}
+
+// String describes the delta in one line, e.g. "new failing tests (1): TestA; fixed tests (1): TestB"
+func (d Delta) String() string {
+	if d.Empty() {
+		return "no change from baseline"
+	}
+	var parts []string
+	for _, item := range []struct {
+		label string
+		names []string
+	}{
+		{"newly failing steps", d.Broken},
+		{"new failing tests", d.NewFailures},
+		{"new lint findings", d.NewFindings},
+		{"steps now passing", d.Repaired},
+		{"fixed tests", d.Fixed},
+		{"fixed lint findings", d.FixedFindings},
+	} {
+		if len(item.names) > 0 {
+			parts = append(parts, fmt.Sprintf("%s (%d): %s", item.label, len(item.names), truncateList(item.names, 10)))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// truncateList joins names, listing at most limit of them
+func truncateList(names []string, limit int) string {
+	if len(names) <= limit {
+		return strings.Join(names, ", ")
+	}
+	return strings.Join(names[:limit], ", ") + fmt.Sprintf(", ... (%d more)", len(names)-limit)
+}
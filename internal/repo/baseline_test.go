@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifier_Snapshot(t *testing.T) {
+	root := t.TempDir()
+	p := &Project{Root: root, Type: ProjectTypeGo}
+	p.SetSteps([]Step{
+		{Name: "format", Kind: StepFormat, Command: "touch formatted"},
+		{Name: "lint", Kind: StepLint, Command: "echo './calc.go:3:1: exported Add should have comment'; exit 1"},
+		{Name: "test", Kind: StepTest, Command: "echo '--- FAIL: TestOld (0.00s)'; exit 1", Required: true},
+		{Name: "build", Kind: StepBuild, Command: "true", Required: true},
+	})
+
+	baseline, results := NewVerifier(p).Snapshot(context.Background())
+	if len(results) != 3 {
+		t.Fatalf("expected every step but the formatter to run, got %d results", len(results))
+	}
+	if _, err := os.Stat(filepath.Join(root, "formatted")); err == nil {
+		t.Error("expected the formatter not to run")
+	}
+	if baseline.Tests["TestOld"] != "test" || baseline.Findings["calc.go: exported Add should have comment"] != "lint" {
+		t.Errorf("unexpected baseline: %+v", baseline)
+	}
+	if summary := baseline.Summary(); !strings.Contains(summary, "Failing steps: lint, test") || !strings.Contains(summary, "TestOld") {
+		t.Errorf("unexpected summary: %s", summary)
+	}
+
+	// The lint finding moved, TestOld was fixed and TestNew broke
+	p.SetSteps([]Step{
+		{Name: "lint", Kind: StepLint, Command: "echo './calc.go:9:1: exported Add should have comment'; exit 1"},
+		{Name: "test", Kind: StepTest, Command: "echo '--- FAIL: TestNew (0.00s)'; exit 1", Required: true},
+	})
+	results, _ = NewVerifier(p).VerifyFiles(context.Background(), nil)
+	delta := baseline.Compare(results)
+	if got := delta.String(); got != "new failing tests (1): TestNew; fixed tests (1): TestOld" {
+		t.Errorf("unexpected delta: %s", got)
+	}
+
+	// Targeted runs report new failures but not fixes of tests they didn't run
+	targeted := &VerificationResult{Name: "targeted tests", Kind: StepTest, Failures: []string{"TestNew"}}
+	if got := baseline.Compare([]*VerificationResult{targeted}).String(); got != "new failing tests (1): TestNew" {
+		t.Errorf("unexpected targeted delta: %s", got)
+	}
+	if got := baseline.Compare(nil).String(); got != "no change from baseline" {
+		t.Errorf("unexpected empty delta: %s", got)
+	}
+}
+
+func TestBaseline_Preexisting(t *testing.T) {
+	baseline := NewBaseline([]*VerificationResult{
+		{Name: "test", Failures: []string{"TestOld"}},
+		{Name: "lint", Findings: []string{"calc.go: unused x"}},
+		{Name: "build"},
+		{Name: "vet", Success: true},
+	})
+
+	tests := []struct {
+		name   string
+		result *VerificationResult
+		want   bool
+	}{
+		{"same failing test", &VerificationResult{Name: "test", Failures: []string{"TestOld"}}, true},
+		{"new failing test", &VerificationResult{Name: "test", Failures: []string{"TestOld", "TestNew"}}, false},
+		{"test step failing without tests", &VerificationResult{Name: "test"}, false},
+		{"same finding", &VerificationResult{Name: "lint", Findings: []string{"calc.go: unused x"}}, true},
+		{"new finding", &VerificationResult{Name: "lint", Findings: []string{"calc.go: unused y"}}, false},
+		{"unattributed step failing again", &VerificationResult{Name: "build"}, true},
+		{"step passing at baseline", &VerificationResult{Name: "vet"}, false},
+		{"step not in baseline", &VerificationResult{Name: "e2e"}, false},
+		{"passing result", &VerificationResult{Name: "test", Success: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := baseline.Preexisting(tt.result); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	var none *Baseline
+	if none.Preexisting(&VerificationResult{Name: "test"}) {
+		t.Error("expected no pre-existing failures without a baseline")
+	}
+}
@@ -0,0 +1,417 @@
+package repo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CoverageUnit is a function, or a file where functions are not reported,
+// with code not covered by the tests
+type CoverageUnit struct {
+	File       string // Path relative to the project root
+	Name       string // Function name ("" for a whole file)
+	Line       int    // First line (0 if unknown)
+	Statements int    // Statements, or lines, in the unit
+	Uncovered  int    // Statements not covered
+	Lines      []int  // Uncovered lines, where reported
+	Churn      int    // Recent commits touching the file
+}
+
+// Key identifies the unit across coverage runs
+func (u CoverageUnit) Key() string {
+	if u.Name == "" {
+		return u.File
+	}
+	return u.File + ":" + u.Name
+}
+
+// Percent returns the unit's covered share of statements
+func (u CoverageUnit) Percent() float64 {
+	if u.Statements == 0 {
+		return 100
+	}
+	return 100 * float64(u.Statements-u.Uncovered) / float64(u.Statements)
+}
+
+// Describe returns a one-line description of the unit
+func (u CoverageUnit) Describe() string {
+	loc := u.File
+	if u.Line > 0 {
+		loc += ":" + strconv.Itoa(u.Line)
+	}
+	if u.Name != "" {
+		loc += " " + u.Name
+	}
+	return fmt.Sprintf("%s (%.0f%% covered, %d of %d statements uncovered)", loc, u.Percent(), u.Uncovered, u.Statements)
+}
+
+// CoverageReport is the outcome of running the tests with coverage
+type CoverageReport struct {
+	Command string
+	Percent float64
+	Units   []CoverageUnit      // Under-tested units, most valuable first
+	Result  *VerificationResult // The test run
+}
+
+// Unit returns the under-tested unit with a key, if any
+func (r *CoverageReport) Unit(key string) (CoverageUnit, bool) {
+	for _, u := range r.Units {
+		if u.Key() == key {
+			return u, true
+		}
+	}
+	return CoverageUnit{}, false
+}
+
+// Coverage runs the project's tests with coverage: go test -coverprofile,
+// pytest --cov or cargo llvm-cov when installed. Units are ranked by
+// uncovered statements weighted by how often their file changes. An error
+// is returned when coverage is unsupported or the tests fail.
+func (v *Verifier) Coverage(ctx context.Context) (*CoverageReport, error) {
+	profile, err := os.CreateTemp("", "brewol-coverage-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create coverage profile: %w", err)
+	}
+	profile.Close()
+	defer os.Remove(profile.Name())
+
+	var command string
+	var parse func(path string) (float64, []CoverageUnit, error)
+	switch v.project.Type {
+	case ProjectTypeGo:
+		command = "go test -coverprofile=" + profile.Name() + " ./..."
+		if strings.HasPrefix(v.project.TestCommand, "go test") && len(v.project.Steps) == 0 {
+			command = strings.Replace(v.project.TestCommand, "go test", "go test -coverprofile="+profile.Name(), 1)
+		}
+		parse = func(path string) (float64, []CoverageUnit, error) {
+			return parseGoCoverage(ctx, v.project, path)
+		}
+	case ProjectTypePython:
+		pytest := v.project.TestCommand
+		if !strings.HasSuffix(pytest, "pytest") {
+			pytest = "pytest" // tox or nox; run pytest in the virtualenv
+		}
+		command = pytest + " --cov --cov-report=json:" + profile.Name()
+		parse = parsePytestCoverage
+	case ProjectTypeRust:
+		if _, err := exec.LookPath("cargo-llvm-cov"); err != nil {
+			return nil, fmt.Errorf("coverage needs cargo-llvm-cov (cargo install cargo-llvm-cov)")
+		}
+		command = "cargo llvm-cov --json --output-path " + profile.Name()
+		parse = func(path string) (float64, []CoverageUnit, error) {
+			return parseLLVMCoverage(v.project.Root, path)
+		}
+	default:
+		return nil, fmt.Errorf("coverage is not supported for %s projects", v.project.Type)
+	}
+
+	result := v.runCommand(ctx, command)
+	report := &CoverageReport{Command: command, Result: result}
+	if !result.Success {
+		if strings.Contains(result.Output, "unrecognized arguments: --cov") {
+			return report, fmt.Errorf("coverage needs pytest-cov in the test environment")
+		}
+		return report, fmt.Errorf("tests failed (exit code %d)", result.ExitCode)
+	}
+
+	report.Percent, report.Units, err = parse(profile.Name())
+	if err != nil {
+		return report, fmt.Errorf("failed to parse coverage: %w", err)
+	}
+
+	churn := fileChurn(v.project.Root)
+	for i := range report.Units {
+		report.Units[i].Churn = churn[report.Units[i].File]
+	}
+	sort.SliceStable(report.Units, func(i, j int) bool {
+		a, b := report.Units[i], report.Units[j]
+		if sa, sb := a.Uncovered*(1+a.Churn), b.Uncovered*(1+b.Churn); sa != sb {
+			return sa > sb
+		}
+		return a.Key() < b.Key()
+	})
+	return report, nil
+}
+
+// coverBlock is a block of a Go coverage profile
+type coverBlock struct {
+	startLine, endLine int
+	statements         int
+	covered            bool
+}
+
+// parseGoCoverage reads a Go coverage profile, attributing blocks to the
+// functions listed by go tool cover -func
+func parseGoCoverage(ctx context.Context, project *Project, path string) (float64, []CoverageUnit, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Blocks appear once per test binary that compiled them
+	blocks := make(map[string]map[string]*coverBlock) // File -> position -> block
+	for _, line := range strings.Split(string(content), "\n") {
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// file.go:12.34,15.2 3 1
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return 0, nil, fmt.Errorf("malformed profile line %q", line)
+		}
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return 0, nil, fmt.Errorf("malformed profile line %q", line)
+		}
+		file := goRelativePath(project, line[:colon])
+		var block coverBlock
+		var startCol, endCol, count int
+		if _, err := fmt.Sscanf(fields[0], "%d.%d,%d.%d", &block.startLine, &startCol, &block.endLine, &endCol); err != nil {
+			return 0, nil, fmt.Errorf("malformed profile line %q", line)
+		}
+		block.statements, _ = strconv.Atoi(fields[1])
+		count, _ = strconv.Atoi(fields[2])
+		block.covered = count > 0
+
+		if blocks[file] == nil {
+			blocks[file] = make(map[string]*coverBlock)
+		}
+		if existing, ok := blocks[file][fields[0]]; ok {
+			existing.covered = existing.covered || block.covered
+		} else {
+			blocks[file][fields[0]] = &block
+		}
+	}
+
+	// Function start lines: "example.com/app/x.go:12:\tParse\t\t75.0%"
+	type function struct {
+		name string
+		line int
+	}
+	functions := make(map[string][]function)
+	cmd := exec.CommandContext(ctx, "go", "tool", "cover", "-func="+path)
+	cmd.Dir = project.Root
+	if output, err := cmd.Output(); err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 3 || fields[0] == "total:" {
+				continue
+			}
+			loc := strings.Split(strings.TrimSuffix(fields[0], ":"), ":")
+			if len(loc) != 2 {
+				continue
+			}
+			start, _ := strconv.Atoi(loc[1])
+			file := goRelativePath(project, loc[0])
+			functions[file] = append(functions[file], function{name: fields[1], line: start})
+		}
+	}
+
+	total, covered := 0, 0
+	units := make(map[string]*CoverageUnit)
+	for file, fileBlocks := range blocks {
+		funcs := functions[file]
+		sort.Slice(funcs, func(i, j int) bool { return funcs[i].line < funcs[j].line })
+		for _, block := range fileBlocks {
+			total += block.statements
+			if block.covered {
+				covered += block.statements
+			}
+
+			// The block belongs to the last function starting before it
+			unit := CoverageUnit{File: file}
+			for _, f := range funcs {
+				if f.line <= block.startLine {
+					unit.Name, unit.Line = f.name, f.line
+				}
+			}
+			u, ok := units[unit.Key()]
+			if !ok {
+				u = &unit
+				units[unit.Key()] = u
+			}
+			u.Statements += block.statements
+			if !block.covered {
+				u.Uncovered += block.statements
+				for line := block.startLine; line <= block.endLine; line++ {
+					u.Lines = append(u.Lines, line)
+				}
+			}
+		}
+	}
+	return percent(covered, total), underTested(units), nil
+}
+
+// goRelativePath converts a coverage profile's import path to a path
+// relative to the module root
+func goRelativePath(project *Project, file string) string {
+	if project.Name != "" {
+		if rel, ok := strings.CutPrefix(file, project.Name+"/"); ok {
+			return rel
+		}
+	}
+	return file
+}
+
+// parsePytestCoverage reads a coverage.py JSON report. Functions are used
+// when the report lists them (coverage.py 7.5+), otherwise whole files.
+func parsePytestCoverage(path string) (float64, []CoverageUnit, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	type region struct {
+		ExecutedLines []int `json:"executed_lines"`
+		MissingLines  []int `json:"missing_lines"`
+		Summary       struct {
+			NumStatements int `json:"num_statements"`
+			MissingLines  int `json:"missing_lines"`
+		} `json:"summary"`
+	}
+	var report struct {
+		Files map[string]struct {
+			region
+			Functions map[string]region `json:"functions"`
+		} `json:"files"`
+		Totals struct {
+			NumStatements int `json:"num_statements"`
+			CoveredLines  int `json:"covered_lines"`
+		} `json:"totals"`
+	}
+	if err := json.Unmarshal(content, &report); err != nil {
+		return 0, nil, err
+	}
+
+	units := make(map[string]*CoverageUnit)
+	add := func(file, name string, r region) {
+		unit := &CoverageUnit{
+			File:       filepath.ToSlash(file),
+			Name:       name,
+			Statements: r.Summary.NumStatements,
+			Uncovered:  r.Summary.MissingLines,
+			Lines:      r.MissingLines,
+		}
+		for _, line := range append(append([]int{}, r.ExecutedLines...), r.MissingLines...) {
+			if unit.Line == 0 || line < unit.Line {
+				unit.Line = line
+			}
+		}
+		units[unit.Key()] = unit
+	}
+	for file, f := range report.Files {
+		if isPytestFile(filepath.Base(file)) || filepath.Base(file) == "conftest.py" {
+			continue
+		}
+		if len(f.Functions) == 0 {
+			add(file, "", f.region)
+			continue
+		}
+		for name, fn := range f.Functions {
+			add(file, name, fn) // "" is the module-level code
+		}
+	}
+	return percent(report.Totals.CoveredLines, report.Totals.NumStatements), underTested(units), nil
+}
+
+// parseLLVMCoverage reads the llvm-cov JSON export written by cargo
+// llvm-cov. Function names are mangled, so units are whole files.
+func parseLLVMCoverage(root, path string) (float64, []CoverageUnit, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	type lines struct {
+		Count   int `json:"count"`
+		Covered int `json:"covered"`
+	}
+	var export struct {
+		Data []struct {
+			Files []struct {
+				Filename string `json:"filename"`
+				Summary  struct {
+					Lines lines `json:"lines"`
+				} `json:"summary"`
+			} `json:"files"`
+			Totals struct {
+				Lines lines `json:"lines"`
+			} `json:"totals"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(content, &export); err != nil {
+		return 0, nil, err
+	}
+	if len(export.Data) == 0 {
+		return 0, nil, fmt.Errorf("no coverage data")
+	}
+
+	units := make(map[string]*CoverageUnit)
+	for _, f := range export.Data[0].Files {
+		rel, err := filepath.Rel(root, f.Filename)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue // Dependencies
+		}
+		unit := &CoverageUnit{
+			File:       filepath.ToSlash(rel),
+			Statements: f.Summary.Lines.Count,
+			Uncovered:  f.Summary.Lines.Count - f.Summary.Lines.Covered,
+		}
+		units[unit.Key()] = unit
+	}
+	totals := export.Data[0].Totals.Lines
+	return percent(totals.Covered, totals.Count), underTested(units), nil
+}
+
+// underTested returns the units with uncovered statements
+func underTested(units map[string]*CoverageUnit) []CoverageUnit {
+	var result []CoverageUnit
+	for _, u := range units {
+		if u.Uncovered > 0 {
+			sort.Ints(u.Lines)
+			lines := u.Lines[:0]
+			for i, line := range u.Lines {
+				if i == 0 || line != u.Lines[i-1] {
+					lines = append(lines, line)
+				}
+			}
+			u.Lines = lines
+			result = append(result, *u)
+		}
+	}
+	return result
+}
+
+// percent returns covered as a percentage of total (100 with nothing to cover)
+func percent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(covered) / float64(total)
+}
+
+// fileChurn counts the recent commits touching each file, relative to root
+func fileChurn(root string) map[string]int {
+	churn := make(map[string]int)
+	cmd := exec.Command("git", "log", "--format=", "--name-only", "--relative", "-n", "500", "--", ".")
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return churn
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		if file := strings.TrimSpace(scanner.Text()); file != "" {
+			churn[file]++
+		}
+	}
+	return churn
+}
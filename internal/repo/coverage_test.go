@@ -0,0 +1,144 @@
+package repo
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestVerifier_Coverage_Go(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod": "module example.com/calc\n\ngo 1.21\n",
+		"calc.go": `package calc
+
+func Add(a, b int) int {
+	return a + b
+}
+
+func Clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+`,
+		"calc_test.go": `package calc
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if Add(1, 2) != 3 {
+		t.Fatal("wrong sum")
+	}
+}
+`,
+	})
+
+	report, err := NewVerifier(DetectProject(root)).Coverage(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Percent <= 0 || report.Percent >= 100 {
+		t.Errorf("expected partial coverage, got %.1f%%", report.Percent)
+	}
+	if len(report.Units) != 1 {
+		t.Fatalf("expected only Clamp to be under-tested, got %+v", report.Units)
+	}
+	clamp, ok := report.Unit("calc.go:Clamp")
+	if !ok {
+		t.Fatalf("expected a calc.go:Clamp unit, got %+v", report.Units)
+	}
+	if clamp.Line != 7 || clamp.Uncovered != clamp.Statements || len(clamp.Lines) == 0 {
+		t.Errorf("unexpected unit: %+v", clamp)
+	}
+}
+
+func TestVerifier_Coverage_Unsupported(t *testing.T) {
+	v := NewVerifier(&Project{Root: t.TempDir(), Type: ProjectTypeMake})
+	if _, err := v.Coverage(context.Background()); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("expected unsupported error, got %v", err)
+	}
+}
+
+func TestParsePytestCoverage(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"coverage.json": `{
+  "files": {
+    "app/parse.py": {
+      "executed_lines": [1, 2, 3],
+      "missing_lines": [5, 6],
+      "summary": {"num_statements": 5, "missing_lines": 2},
+      "functions": {
+        "parse": {"executed_lines": [2, 3], "missing_lines": [], "summary": {"num_statements": 2, "missing_lines": 0}},
+        "render": {"executed_lines": [], "missing_lines": [5, 6], "summary": {"num_statements": 2, "missing_lines": 2}},
+        "": {"executed_lines": [1], "missing_lines": [], "summary": {"num_statements": 1, "missing_lines": 0}}
+      }
+    },
+    "app/util.py": {
+      "executed_lines": [1],
+      "missing_lines": [3],
+      "summary": {"num_statements": 2, "missing_lines": 1}
+    },
+    "tests/test_parse.py": {
+      "executed_lines": [1],
+      "missing_lines": [4],
+      "summary": {"num_statements": 2, "missing_lines": 1}
+    }
+  },
+  "totals": {"num_statements": 9, "covered_lines": 6}
+}`,
+	})
+
+	pct, units, err := parsePytestCoverage(filepath.Join(root, "coverage.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if int(pct) != 66 {
+		t.Errorf("expected 66%% coverage, got %.1f", pct)
+	}
+
+	var keys []string
+	for _, u := range units {
+		keys = append(keys, u.Key())
+	}
+	sort.Strings(keys)
+	if got := strings.Join(keys, " "); got != "app/parse.py:render app/util.py" {
+		t.Fatalf("unexpected units: %s", got)
+	}
+}
+
+func TestParseLLVMCoverage(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"cov.json": `{"data": [{
+  "files": [
+    {"filename": "` + filepath.Join(root, "src", "lib.rs") + `", "summary": {"lines": {"count": 10, "covered": 7}}},
+    {"filename": "` + filepath.Join(root, "src", "main.rs") + `", "summary": {"lines": {"count": 4, "covered": 4}}},
+    {"filename": "/home/u/.cargo/registry/dep/src/lib.rs", "summary": {"lines": {"count": 50, "covered": 0}}}
+  ],
+  "totals": {"lines": {"count": 14, "covered": 11}}
+}]}`,
+	})
+
+	pct, units, err := parseLLVMCoverage(root, filepath.Join(root, "cov.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if int(pct) != 78 {
+		t.Errorf("expected 78%% coverage, got %.1f", pct)
+	}
+	if len(units) != 1 || units[0].File != "src/lib.rs" || units[0].Uncovered != 3 {
+		t.Errorf("unexpected units: %+v", units)
+	}
+}
@@ -2,9 +2,14 @@
 package repo
 
 import (
+	"context"
+	"encoding/json"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 )
 
 // ProjectType represents the detected project type
@@ -16,6 +21,12 @@ const (
 	ProjectTypePython  ProjectType = "python"
 	ProjectTypeRust    ProjectType = "rust"
 	ProjectTypeJava    ProjectType = "java"
+	ProjectTypeDotNet  ProjectType = "dotnet"
+	ProjectTypeRuby    ProjectType = "ruby"
+	ProjectTypePHP     ProjectType = "php"
+	ProjectTypeElixir  ProjectType = "elixir"
+	ProjectTypeSwift   ProjectType = "swift"
+	ProjectTypeCpp     ProjectType = "cpp"
 	ProjectTypeMake    ProjectType = "make"
 	ProjectTypeUnknown ProjectType = "unknown"
 )
@@ -30,10 +41,23 @@ type Project struct {
 	LintCommand    string
 	FormatCommand  string
 	PackageManager string
+	VirtualEnv     string     // Python virtualenv that commands run in ("" = none)
+	LintRequired   bool       // Whether the detected lint command blocks commits
+	Steps          []Step     // Verification steps declared by the repo (nil = derived from the commands)
+	Dir            string     // Path relative to the workspace root ("" for the root project)
+	SubProjects    []*Project // Nested projects of a monorepo, in path order
 }
 
-// DetectProject detects the project type and configuration
+// DetectProject detects the project type and configuration of the workspace
+// root, and the sub-projects nested below it
 func DetectProject(root string) *Project {
+	p := detectDir(root)
+	p.SubProjects = detectSubProjects(root)
+	return p
+}
+
+// detectDir detects the project type and configuration of a single directory
+func detectDir(root string) *Project {
 	p := &Project{
 		Root: root,
 		Type: ProjectTypeUnknown,
@@ -50,42 +74,13 @@ func DetectProject(root string) *Project {
 		return p
 	}
 
-	// Check for Node.js project
-	if fileExists(filepath.Join(root, "package.json")) {
-		p.Type = ProjectTypeNode
-		p.Name = getPackageJsonName(root)
-
-		// Determine package manager
-		if fileExists(filepath.Join(root, "pnpm-lock.yaml")) {
-			p.PackageManager = "pnpm"
-			p.TestCommand = "pnpm test"
-			p.BuildCommand = "pnpm build"
-			p.LintCommand = "pnpm lint"
-			p.FormatCommand = "pnpm format"
-		} else if fileExists(filepath.Join(root, "yarn.lock")) {
-			p.PackageManager = "yarn"
-			p.TestCommand = "yarn test"
-			p.BuildCommand = "yarn build"
-			p.LintCommand = "yarn lint"
-			p.FormatCommand = "yarn format"
-		} else {
-			p.PackageManager = "npm"
-			p.TestCommand = "npm test"
-			p.BuildCommand = "npm run build"
-			p.LintCommand = "npm run lint"
-			p.FormatCommand = "npm run format"
-		}
-		return p
-	}
-
 	// Check for Python project
 	if fileExists(filepath.Join(root, "pyproject.toml")) ||
 		fileExists(filepath.Join(root, "setup.py")) ||
 		fileExists(filepath.Join(root, "requirements.txt")) {
 		p.Type = ProjectTypePython
-		p.TestCommand = "pytest"
-		p.LintCommand = "ruff check ."
-		p.FormatCommand = "ruff format ."
+		manager := pythonPackageManager(root)
+		setPythonCommands(p, manager, findVirtualEnv(root, manager))
 
 		if fileExists(filepath.Join(root, "pyproject.toml")) {
 			p.Name = getPyprojectName(root)
@@ -119,6 +114,111 @@ func DetectProject(root string) *Project {
 		return p
 	}
 
+	// Check for .NET project. An explicit solution or project file keeps
+	// dotnet from refusing a directory that holds both.
+	if target := dotnetTarget(root); target != "" {
+		p.Type = ProjectTypeDotNet
+		p.Name = strings.TrimSuffix(target, filepath.Ext(target))
+		p.TestCommand = "dotnet test " + target
+		p.BuildCommand = "dotnet build " + target
+		p.LintCommand = "dotnet format " + target + " --verify-no-changes"
+		p.FormatCommand = "dotnet format " + target
+		return p
+	}
+
+	// Check for Ruby project
+	if fileExists(filepath.Join(root, "Gemfile")) {
+		p.Type = ProjectTypeRuby
+		p.Name = getGemspecName(root)
+		switch {
+		case dirExists(filepath.Join(root, "spec")) || fileContains(filepath.Join(root, "Gemfile"), "rspec"):
+			p.TestCommand = "bundle exec rspec"
+		case fileExists(filepath.Join(root, "Rakefile")):
+			p.TestCommand = "bundle exec rake test"
+		}
+		if fileContains(filepath.Join(root, "Gemfile"), "rubocop") {
+			p.LintCommand = "bundle exec rubocop"
+			p.FormatCommand = "bundle exec rubocop -a"
+		}
+		return p
+	}
+
+	// Check for PHP project
+	if fileExists(filepath.Join(root, "composer.json")) {
+		p.Type = ProjectTypePHP
+		p.Name = getComposerName(root)
+		composer := filepath.Join(root, "composer.json")
+		if fileContains(composer, "phpunit/phpunit") || fileExists(filepath.Join(root, "phpunit.xml")) || fileExists(filepath.Join(root, "phpunit.xml.dist")) {
+			p.TestCommand = "vendor/bin/phpunit"
+		}
+		if fileContains(composer, "phpstan/phpstan") {
+			p.LintCommand = "vendor/bin/phpstan analyse"
+		}
+		if fileContains(composer, "friendsofphp/php-cs-fixer") {
+			p.FormatCommand = "vendor/bin/php-cs-fixer fix"
+		}
+		return p
+	}
+
+	// Check for Elixir project
+	if fileExists(filepath.Join(root, "mix.exs")) {
+		p.Type = ProjectTypeElixir
+		p.Name = getMixAppName(root)
+		p.TestCommand = "mix test"
+		p.BuildCommand = "mix compile --warnings-as-errors"
+		p.FormatCommand = "mix format"
+		if fileContains(filepath.Join(root, "mix.exs"), ":credo") {
+			p.LintCommand = "mix credo"
+		}
+		return p
+	}
+
+	// Check for Swift package
+	if fileExists(filepath.Join(root, "Package.swift")) {
+		p.Type = ProjectTypeSwift
+		p.Name = getSwiftPackageName(root)
+		p.TestCommand = "swift test"
+		p.BuildCommand = "swift build"
+		if fileExists(filepath.Join(root, ".swiftlint.yml")) {
+			p.LintCommand = "swiftlint"
+		}
+		if fileExists(filepath.Join(root, ".swift-format")) {
+			p.FormatCommand = "swift format --in-place --recursive ."
+		}
+		return p
+	}
+
+	// Check for Node.js project. This comes after the other ecosystems'
+	// manifests because Rails, Laravel, Django and similar apps ship a
+	// package.json for their frontend tooling.
+	if fileExists(filepath.Join(root, "package.json")) {
+		p.Type = ProjectTypeNode
+		p.Name = getPackageJsonName(root)
+
+		setNodeCommands(p, nodePackageManager(root))
+		return p
+	}
+
+	// Check for CMake or Meson C/C++ project. Tests build first so that they
+	// never run stale binaries.
+	if fileExists(filepath.Join(root, "CMakeLists.txt")) {
+		p.Type = ProjectTypeCpp
+		p.Name = getBuildProjectName(filepath.Join(root, "CMakeLists.txt"))
+		p.BuildCommand = "cmake -S . -B build && cmake --build build"
+		p.TestCommand = p.BuildCommand + " && ctest --test-dir build --output-on-failure"
+		setClangFormat(p, root)
+		return p
+	}
+
+	if fileExists(filepath.Join(root, "meson.build")) {
+		p.Type = ProjectTypeCpp
+		p.Name = getBuildProjectName(filepath.Join(root, "meson.build"))
+		p.BuildCommand = "(test -d builddir || meson setup builddir) && meson compile -C builddir"
+		p.TestCommand = "(test -d builddir || meson setup builddir) && meson test -C builddir --print-errorlogs"
+		setClangFormat(p, root)
+		return p
+	}
+
 	// Check for Makefile
 	if fileExists(filepath.Join(root, "Makefile")) {
 		p.Type = ProjectTypeMake
@@ -140,12 +240,175 @@ func DetectProject(root string) *Project {
 	return p
 }
 
+// nodePackageManager determines the package manager from the lockfile in dir
+func nodePackageManager(dir string) string {
+	if fileExists(filepath.Join(dir, "pnpm-lock.yaml")) {
+		return "pnpm"
+	}
+	if fileExists(filepath.Join(dir, "yarn.lock")) {
+		return "yarn"
+	}
+	return "npm"
+}
+
+// pythonPackageManager determines the Python package manager from the
+// lockfiles in dir
+func pythonPackageManager(dir string) string {
+	switch {
+	case fileExists(filepath.Join(dir, "uv.lock")):
+		return "uv"
+	case fileExists(filepath.Join(dir, "poetry.lock")):
+		return "poetry"
+	case fileExists(filepath.Join(dir, "Pipfile")):
+		return "pipenv"
+	}
+	return "pip"
+}
+
+// setPythonCommands sets a Python project's commands to run in the
+// environment of a package manager and virtualenv
+func setPythonCommands(p *Project, manager, venv string) {
+	p.PackageManager = manager
+	p.VirtualEnv = venv
+
+	run := pythonRunner(manager)
+	p.TestCommand = run + "pytest"
+	p.LintCommand = run + "ruff check ."
+	p.FormatCommand = run + "ruff format ."
+
+	// tox and nox manage their own environments
+	if fileExists(filepath.Join(p.Root, "tox.ini")) {
+		p.TestCommand = run + "tox"
+	} else if fileExists(filepath.Join(p.Root, "noxfile.py")) {
+		p.TestCommand = run + "nox"
+	}
+}
+
+// pythonRunner returns the prefix that runs a command in the environment
+// of a Python package manager
+func pythonRunner(manager string) string {
+	switch manager {
+	case "uv", "poetry", "pipenv":
+		return manager + " run "
+	}
+	return ""
+}
+
+// venvLookupTimeout bounds asking poetry or pipenv for their virtualenv
+const venvLookupTimeout = 5 * time.Second
+
+// findVirtualEnv returns the virtualenv of a Python project: an in-project
+// .venv or venv, the activated one for poetry and pipenv projects, or else
+// the one poetry or pipenv manages elsewhere
+func findVirtualEnv(dir, manager string) string {
+	for _, name := range []string{".venv", "venv"} {
+		if fileExists(filepath.Join(dir, name, "pyvenv.cfg")) {
+			return filepath.Join(dir, name)
+		}
+	}
+
+	var args []string
+	switch manager {
+	case "poetry":
+		args = []string{"poetry", "env", "info", "--path"}
+	case "pipenv":
+		args = []string{"pipenv", "--venv"}
+	default:
+		return ""
+	}
+	if venv := os.Getenv("VIRTUAL_ENV"); venv != "" && fileExists(filepath.Join(venv, "pyvenv.cfg")) {
+		return venv
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), venvLookupTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	venv := strings.TrimSpace(string(output))
+	if !fileExists(filepath.Join(venv, "pyvenv.cfg")) {
+		return ""
+	}
+	return venv
+}
+
+// Env returns the environment variables that activate the project's
+// virtualenv, or nil if it has none
+func (p *Project) Env() []string {
+	if p.VirtualEnv == "" {
+		return nil
+	}
+	return []string{
+		"VIRTUAL_ENV=" + p.VirtualEnv,
+		"PATH=" + filepath.Join(p.VirtualEnv, "bin") + string(os.PathListSeparator) + os.Getenv("PATH"),
+	}
+}
+
+// setNodeCommands sets a Node.js project's commands for a package manager
+func setNodeCommands(p *Project, manager string) {
+	p.PackageManager = manager
+	switch manager {
+	case "pnpm":
+		p.TestCommand = "pnpm test"
+		p.BuildCommand = "pnpm build"
+		p.LintCommand = "pnpm lint"
+		p.FormatCommand = "pnpm format"
+	case "yarn":
+		p.TestCommand = "yarn test"
+		p.BuildCommand = "yarn build"
+		p.LintCommand = "yarn lint"
+		p.FormatCommand = "yarn format"
+	default:
+		p.TestCommand = "npm test"
+		p.BuildCommand = "npm run build"
+		p.LintCommand = "npm run lint"
+		p.FormatCommand = "npm run format"
+	}
+}
+
+// dotnetTarget returns the solution file in dir, or else its only project
+// file, or "" if there is neither
+func dotnetTarget(dir string) string {
+	for _, pattern := range []string{"*.sln", "*.csproj", "*.fsproj"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		if len(matches) == 1 || (len(matches) > 0 && pattern == "*.sln") {
+			return filepath.Base(matches[0])
+		}
+	}
+	return ""
+}
+
+// setClangFormat sets a C/C++ project's format command when it has a
+// .clang-format configuration
+func setClangFormat(p *Project, root string) {
+	if fileExists(filepath.Join(root, ".clang-format")) {
+		p.FormatCommand = "git ls-files '*.c' '*.cc' '*.cpp' '*.cxx' '*.h' '*.hh' '*.hpp' | xargs clang-format -i"
+	}
+}
+
 // fileExists checks if a file exists
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
 }
 
+// dirExists checks if a directory exists
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// fileContains checks if a file contains a substring
+func fileContains(path, substr string) bool {
+	content, err := os.ReadFile(path)
+	return err == nil && strings.Contains(string(content), substr)
+}
+
 // getGoModuleName extracts the module name from go.mod
 func getGoModuleName(root string) string {
 	content, err := os.ReadFile(filepath.Join(root, "go.mod"))
@@ -222,6 +485,77 @@ func getCargoName(root string) string {
 	return ""
 }
 
+// getGemspecName returns the name of the gemspec in root
+func getGemspecName(root string) string {
+	matches, _ := filepath.Glob(filepath.Join(root, "*.gemspec"))
+	if len(matches) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(filepath.Base(matches[0]), ".gemspec")
+}
+
+// getComposerName extracts the name from composer.json
+func getComposerName(root string) string {
+	content, err := os.ReadFile(filepath.Join(root, "composer.json"))
+	if err != nil {
+		return ""
+	}
+
+	var composer struct {
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(content, &composer) != nil {
+		return ""
+	}
+	return composer.Name
+}
+
+// mixAppPattern matches the application name in mix.exs
+var mixAppPattern = regexp.MustCompile(`app:\s*:(\w+)`)
+
+// getMixAppName extracts the application name from mix.exs
+func getMixAppName(root string) string {
+	content, err := os.ReadFile(filepath.Join(root, "mix.exs"))
+	if err != nil {
+		return ""
+	}
+	if m := mixAppPattern.FindSubmatch(content); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// swiftNamePattern matches the package name in Package.swift
+var swiftNamePattern = regexp.MustCompile(`name:\s*"([^"]+)"`)
+
+// getSwiftPackageName extracts the package name from Package.swift
+func getSwiftPackageName(root string) string {
+	content, err := os.ReadFile(filepath.Join(root, "Package.swift"))
+	if err != nil {
+		return ""
+	}
+	if m := swiftNamePattern.FindSubmatch(content); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// buildProjectPattern matches the first argument of project() in
+// CMakeLists.txt and meson.build
+var buildProjectPattern = regexp.MustCompile(`(?i)project\s*\(\s*['"]?([\w.+-]+)`)
+
+// getBuildProjectName extracts the project name from a CMake or Meson file
+func getBuildProjectName(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	if m := buildProjectPattern.FindSubmatch(content); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
 // makefileHasTarget checks if a Makefile has a specific target
 func makefileHasTarget(root, target string) bool {
 	content, err := os.ReadFile(filepath.Join(root, "Makefile"))
@@ -146,6 +146,76 @@ func TestDetectProject_Python_Requirements(t *testing.T) {
 	}
 }
 
+func TestDetectProject_Python_Environments(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       []string
+		wantManager string
+		wantTest    string
+		wantLint    string
+		wantVenv    string
+	}{
+		{"uv", []string{"pyproject.toml", "uv.lock"}, "uv", "uv run pytest", "uv run ruff check .", ""},
+		{"poetry in-project venv", []string{"pyproject.toml", "poetry.lock", ".venv/pyvenv.cfg"}, "poetry", "poetry run pytest", "poetry run ruff check .", ".venv"},
+		{"plain venv", []string{"requirements.txt", "venv/pyvenv.cfg"}, "pip", "pytest", "ruff check .", "venv"},
+		{"tox", []string{"setup.py", "tox.ini"}, "pip", "tox", "ruff check .", ""},
+		{"nox with uv", []string{"pyproject.toml", "uv.lock", "noxfile.py"}, "uv", "uv run nox", "uv run ruff check .", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			for _, name := range tt.files {
+				os.MkdirAll(filepath.Dir(filepath.Join(tempDir, name)), 0755)
+				os.WriteFile(filepath.Join(tempDir, name), []byte(""), 0644)
+			}
+
+			p := DetectProject(tempDir)
+
+			if p.PackageManager != tt.wantManager {
+				t.Errorf("expected package manager %s, got %s", tt.wantManager, p.PackageManager)
+			}
+			if p.TestCommand != tt.wantTest {
+				t.Errorf("unexpected TestCommand: %s", p.TestCommand)
+			}
+			if p.LintCommand != tt.wantLint {
+				t.Errorf("unexpected LintCommand: %s", p.LintCommand)
+			}
+			wantVenv := ""
+			if tt.wantVenv != "" {
+				wantVenv = filepath.Join(tempDir, tt.wantVenv)
+			}
+			if p.VirtualEnv != wantVenv {
+				t.Errorf("expected virtualenv %q, got %q", wantVenv, p.VirtualEnv)
+			}
+		})
+	}
+}
+
+func TestFindVirtualEnv_Activated(t *testing.T) {
+	dir := t.TempDir()
+	venv := filepath.Join(t.TempDir(), "env")
+	os.MkdirAll(venv, 0755)
+	os.WriteFile(filepath.Join(venv, "pyvenv.cfg"), []byte(""), 0644)
+
+	// A poetry that records being run
+	bin := t.TempDir()
+	marker := filepath.Join(bin, "ran")
+	os.WriteFile(filepath.Join(bin, "poetry"), []byte("#!/bin/sh\ntouch "+marker+"\n"), 0755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("VIRTUAL_ENV", venv)
+
+	if got := findVirtualEnv(dir, "poetry"); got != venv {
+		t.Errorf("expected the activated virtualenv %q, got %q", venv, got)
+	}
+	if fileExists(marker) {
+		t.Error("expected poetry not to be asked when a virtualenv is active")
+	}
+	if got := findVirtualEnv(dir, "pip"); got != "" {
+		t.Errorf("expected plain pip projects not to adopt the activated virtualenv, got %q", got)
+	}
+}
+
 func TestDetectProject_Rust(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -443,3 +513,119 @@ version = "0.1.0"
 		}
 	})
 }
+
+func TestDetectProject_Ecosystems(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		wantType   ProjectType
+		wantName   string
+		wantTest   string
+		wantBuild  string
+		wantLint   string
+		wantFormat string
+	}{
+		{
+			name:       "dotnet solution",
+			files:      map[string]string{"Shop.sln": "", "Shop.csproj": ""},
+			wantType:   ProjectTypeDotNet,
+			wantName:   "Shop",
+			wantTest:   "dotnet test Shop.sln",
+			wantBuild:  "dotnet build Shop.sln",
+			wantLint:   "dotnet format Shop.sln --verify-no-changes",
+			wantFormat: "dotnet format Shop.sln",
+		},
+		{
+			name:     "ruby rspec",
+			files:    map[string]string{"Gemfile": "gem 'rspec'\ngem 'rubocop'\n", "widget.gemspec": ""},
+			wantType: ProjectTypeRuby, wantName: "widget",
+			wantTest: "bundle exec rspec", wantLint: "bundle exec rubocop", wantFormat: "bundle exec rubocop -a",
+		},
+		{
+			name:     "ruby minitest",
+			files:    map[string]string{"Gemfile": "gem 'minitest'\n", "Rakefile": ""},
+			wantType: ProjectTypeRuby,
+			wantTest: "bundle exec rake test",
+		},
+		{
+			name: "rails with frontend tooling",
+			files: map[string]string{
+				"Gemfile": "gem 'rails'\ngem 'rspec-rails'\n", "Rakefile": "", "package.json": `{"name": "app", "scripts": {"build": "esbuild"}}`,
+			},
+			wantType: ProjectTypeRuby,
+			wantTest: "bundle exec rspec",
+		},
+		{
+			name: "laravel with frontend tooling",
+			files: map[string]string{
+				"composer.json": `{"name": "laravel/laravel", "require-dev": {"phpunit/phpunit": "^11"}}`, "phpunit.xml": "",
+				"package.json": `{"private": true, "scripts": {"dev": "vite", "build": "vite build"}}`, "package-lock.json": "",
+			},
+			wantType: ProjectTypePHP, wantName: "laravel/laravel",
+			wantTest: "vendor/bin/phpunit",
+		},
+		{
+			name:     "php",
+			files:    map[string]string{"composer.json": `{"name": "acme/api", "require-dev": {"phpunit/phpunit": "^10", "phpstan/phpstan": "^1"}}`},
+			wantType: ProjectTypePHP, wantName: "acme/api",
+			wantTest: "vendor/bin/phpunit", wantLint: "vendor/bin/phpstan analyse",
+		},
+		{
+			name:     "elixir",
+			files:    map[string]string{"mix.exs": "def project do\n  [app: :ledger, deps: [{:credo, \"~> 1.7\"}]]\nend\n"},
+			wantType: ProjectTypeElixir, wantName: "ledger",
+			wantTest: "mix test", wantBuild: "mix compile --warnings-as-errors", wantLint: "mix credo", wantFormat: "mix format",
+		},
+		{
+			name:     "swift",
+			files:    map[string]string{"Package.swift": "let package = Package(\n    name: \"Geometry\",\n)\n"},
+			wantType: ProjectTypeSwift, wantName: "Geometry",
+			wantTest: "swift test", wantBuild: "swift build",
+		},
+		{
+			name:     "cmake",
+			files:    map[string]string{"CMakeLists.txt": "cmake_minimum_required(VERSION 3.20)\nproject(codec CXX)\n"},
+			wantType: ProjectTypeCpp, wantName: "codec",
+			wantTest:  "cmake -S . -B build && cmake --build build && ctest --test-dir build --output-on-failure",
+			wantBuild: "cmake -S . -B build && cmake --build build",
+		},
+		{
+			name:     "meson",
+			files:    map[string]string{"meson.build": "project('codec', 'c')\n", ".clang-format": ""},
+			wantType: ProjectTypeCpp, wantName: "codec",
+			wantTest:   "(test -d builddir || meson setup builddir) && meson test -C builddir --print-errorlogs",
+			wantBuild:  "(test -d builddir || meson setup builddir) && meson compile -C builddir",
+			wantFormat: "git ls-files '*.c' '*.cc' '*.cpp' '*.cxx' '*.h' '*.hh' '*.hpp' | xargs clang-format -i",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			for name, content := range tt.files {
+				os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644)
+			}
+
+			p := DetectProject(tempDir)
+
+			if p.Type != tt.wantType {
+				t.Errorf("expected type %s, got %s", tt.wantType, p.Type)
+			}
+			if p.Name != tt.wantName {
+				t.Errorf("expected name %q, got %q", tt.wantName, p.Name)
+			}
+			if p.TestCommand != tt.wantTest {
+				t.Errorf("unexpected TestCommand: %s", p.TestCommand)
+			}
+			if p.BuildCommand != tt.wantBuild {
+				t.Errorf("unexpected BuildCommand: %s", p.BuildCommand)
+			}
+			if p.LintCommand != tt.wantLint {
+				t.Errorf("unexpected LintCommand: %s", p.LintCommand)
+			}
+			if p.FormatCommand != tt.wantFormat {
+				t.Errorf("unexpected FormatCommand: %s", p.FormatCommand)
+			}
+		})
+	}
+}
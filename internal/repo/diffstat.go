@@ -0,0 +1,124 @@
+package repo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxFileSymbols limits the symbols listed per changed file
+const maxFileSymbols = 8
+
+// symbolDefinition matches a line defining a function, method or type in
+// common languages (Go, Python, Rust, JS/TS, Ruby, Swift, Kotlin, ...)
+var symbolDefinition = regexp.MustCompile(`^\s*(?:(?:export|pub(?:\([^)]*\))?|public|private|protected|internal|static|async|abstract|final|default|override|open|unsafe|extern)\s+)*(?:func(?:\s+\([^)]*\))?|def|fn|class|struct|interface|trait|enum|type|module|function\*?|fun|protocol)\s+([A-Za-z_$][\w$]*)`)
+
+// FileChange is a file in a diff and the symbols its hunks touch
+type FileChange struct {
+	Path    string
+	OldPath string // Previous path of a renamed file
+	Status  string // A (added), M (modified), D (deleted) or R (renamed)
+	Added   int
+	Removed int
+	Binary  bool
+	Symbols []string // Functions and types defined on changed lines or enclosing hunks
+}
+
+// String formats the change as "M path (+3 -1): symbols"
+func (c FileChange) String() string {
+	path := c.Path
+	if c.Status == "R" {
+		path = c.OldPath + " -> " + c.Path
+	}
+	s := fmt.Sprintf("%s %s", c.Status, path)
+	switch {
+	case c.Binary:
+		s += " (binary)"
+	case c.Added > 0 || c.Removed > 0:
+		s += fmt.Sprintf(" (+%d -%d)", c.Added, c.Removed)
+	}
+	if len(c.Symbols) > 0 {
+		s += ": " + strings.Join(c.Symbols, ", ")
+	}
+	return s
+}
+
+// SummarizeDiff lists the files of a unified git diff with their line counts
+// and the symbols touched: those defined on added or removed lines, and for
+// other changed lines the nearest definition above them in the hunk or the
+// function git names in the hunk header
+func SummarizeDiff(diff string) []FileChange {
+	var changes []FileChange
+	var current *FileChange
+	inHunk := false
+	seen := make(map[string]bool)
+	addSymbol := func(name string) {
+		if name == "" || seen[name] || len(current.Symbols) >= maxFileSymbols {
+			return
+		}
+		seen[name] = true
+		current.Symbols = append(current.Symbols, name)
+	}
+	enclosing := "" // Last definition above the current line in the hunk
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			changes = append(changes, FileChange{Status: "M"})
+			current = &changes[len(changes)-1]
+			seen = make(map[string]bool)
+			inHunk = false
+			// "diff --git a/path b/path", used when no ---/+++ lines follow
+			if i := strings.LastIndex(line, " b/"); i >= 0 {
+				current.Path = line[i+3:]
+			}
+		case current == nil:
+		case strings.HasPrefix(line, "@@"):
+			// "@@ -1,2 +1,3 @@ func enclosing() {"
+			inHunk = true
+			enclosing = ""
+			if i := strings.Index(line[2:], "@@"); i >= 0 {
+				enclosing = definedSymbol(line[i+4:])
+			}
+		case inHunk && (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")):
+			if line[0] == '+' {
+				current.Added++
+			} else {
+				current.Removed++
+			}
+			if name := definedSymbol(line[1:]); name != "" {
+				addSymbol(name)
+			} else {
+				addSymbol(enclosing)
+			}
+		case inHunk:
+			if name := definedSymbol(strings.TrimPrefix(line, " ")); name != "" {
+				enclosing = name
+			}
+		case strings.HasPrefix(line, "new file mode"):
+			current.Status = "A"
+		case strings.HasPrefix(line, "deleted file mode"):
+			current.Status = "D"
+		case strings.HasPrefix(line, "rename from "):
+			current.Status = "R"
+			current.OldPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			current.Path = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "Binary files "):
+			current.Binary = true
+		case strings.HasPrefix(line, "--- a/") && current.Status == "D":
+			current.Path = strings.TrimPrefix(line, "--- a/")
+		case strings.HasPrefix(line, "+++ b/"):
+			current.Path = strings.TrimPrefix(line, "+++ b/")
+		}
+	}
+	return changes
+}
+
+// definedSymbol returns the name defined on a line of code, or ""
+func definedSymbol(line string) string {
+	if m := symbolDefinition.FindStringSubmatch(line); m != nil {
+		return m[1]
+	}
+	return ""
+}
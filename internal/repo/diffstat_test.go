@@ -0,0 +1,50 @@
+package repo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSummarizeDiff(t *testing.T) {
+	diff := `diff --git a/internal/engine/state.go b/internal/engine/state.go
+index 1111111..2222222 100644
+--- a/internal/engine/state.go
++++ b/internal/engine/state.go
+@@ -10,7 +10,8 @@ func (e *Engine) createCheckpoint(ctx context.Context) error {
+ 	a := 1
+-	b := 2
++	b := 3
++	c := 4
+diff --git a/app/models.py b/app/models.py
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/app/models.py
+@@ -0,0 +1,4 @@
++class User:
++    async def save(self):
++-- not a header
++        pass
+diff --git a/old.txt b/new.txt
+similarity index 100%
+rename from old.txt
+rename to new.txt
+diff --git a/logo.png b/logo.png
+deleted file mode 100644
+index 4444444..0000000
+Binary files a/logo.png and /dev/null differ
+`
+	var got []string
+	for _, c := range SummarizeDiff(diff) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"M internal/engine/state.go (+2 -1): createCheckpoint",
+		"A app/models.py (+4 -0): User, save",
+		"R old.txt -> new.txt",
+		"D logo.png (binary)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected changes:\n%s", strings.Join(got, "\n"))
+	}
+}
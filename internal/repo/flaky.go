@@ -0,0 +1,277 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// QuarantineWindow is how long after its last flake a test stays quarantined
+	QuarantineWindow = 14 * 24 * time.Hour
+	// maxQuarantinedFailures ends a quarantine once a test has failed every
+	// rerun this many times in a row: it is broken now, not flaky
+	maxQuarantinedFailures = 3
+)
+
+// FlakyTest is the flakiness history of one test
+type FlakyTest struct {
+	Name      string    `json:"name"`     // Test name, prefixed with the sub-project dir
+	Flakes    int       `json:"flakes"`   // Runs where the test both failed and passed
+	Failures  int       `json:"failures"` // Runs where the test failed every rerun
+	Streak    int       `json:"streak"`   // Consecutive runs failing every rerun since the last flake
+	FirstSeen time.Time `json:"first_seen"`
+	LastFlaky time.Time `json:"last_flaky,omitempty"`
+}
+
+// Quarantined reports whether the test is known to be flaky. Quarantined
+// tests no longer block checkpoints. A quarantine expires QuarantineWindow
+// after the last flake, or when the test keeps failing every rerun.
+func (t *FlakyTest) Quarantined() bool {
+	return t.Flakes > 0 && time.Since(t.LastFlaky) < QuarantineWindow && t.Streak < maxQuarantinedFailures
+}
+
+// FlakyTracker records which tests fail intermittently, persisted in
+// .brewol/flaky.json. Deleting an entry lifts its quarantine.
+type FlakyTracker struct {
+	path  string
+	tests map[string]*FlakyTest
+	mu    sync.RWMutex
+}
+
+// NewFlakyTracker opens (or creates) the flakiness history of a workspace
+func NewFlakyTracker(workspaceRoot string) (*FlakyTracker, error) {
+	dir := filepath.Join(workspaceRoot, ".brewol")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	ft := &FlakyTracker{
+		path:  filepath.Join(dir, "flaky.json"),
+		tests: make(map[string]*FlakyTest),
+	}
+	data, err := os.ReadFile(ft.path)
+	if os.IsNotExist(err) {
+		return ft, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read flaky tests: %w", err)
+	}
+	var tests []*FlakyTest
+	if err := json.Unmarshal(data, &tests); err != nil {
+		return nil, fmt.Errorf("failed to parse flaky tests: %w", err)
+	}
+	for _, t := range tests {
+		ft.tests[t.Name] = t
+	}
+	return ft, nil
+}
+
+// Record adds the outcome of a verification run: tests whose results
+// differed between reruns and tests that failed every time
+func (ft *FlakyTracker) Record(flaky, failed []string) error {
+	if len(flaky) == 0 && len(failed) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	ft.mu.Lock()
+	for _, name := range flaky {
+		t := ft.test(name, now)
+		t.Flakes++
+		t.Streak = 0
+		t.LastFlaky = now
+	}
+	for _, name := range failed {
+		if t, ok := ft.tests[name]; ok {
+			t.Failures++
+			t.Streak++
+		}
+	}
+	ft.mu.Unlock()
+
+	return ft.save()
+}
+
+// test returns the history of a test, creating it. Caller must hold ft.mu.
+func (ft *FlakyTracker) test(name string, now time.Time) *FlakyTest {
+	t, ok := ft.tests[name]
+	if !ok {
+		t = &FlakyTest{Name: name, FirstSeen: now}
+		ft.tests[name] = t
+	}
+	return t
+}
+
+// Quarantined reports whether a test is known to be flaky
+func (ft *FlakyTracker) Quarantined(name string) bool {
+	ft.mu.RLock()
+	defer ft.mu.RUnlock()
+	t, ok := ft.tests[name]
+	return ok && t.Quarantined()
+}
+
+// Release forgets a test's history, lifting its quarantine
+func (ft *FlakyTracker) Release(name string) error {
+	ft.mu.Lock()
+	_, ok := ft.tests[name]
+	delete(ft.tests, name)
+	ft.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return ft.save()
+}
+
+// Tests returns the recorded tests, most flaky first
+func (ft *FlakyTracker) Tests() []FlakyTest {
+	ft.mu.RLock()
+	tests := make([]FlakyTest, 0, len(ft.tests))
+	for _, t := range ft.tests {
+		tests = append(tests, *t)
+	}
+	ft.mu.RUnlock()
+
+	sort.Slice(tests, func(i, j int) bool {
+		if tests[i].Flakes != tests[j].Flakes {
+			return tests[i].Flakes > tests[j].Flakes
+		}
+		return tests[i].Name < tests[j].Name
+	})
+	return tests
+}
+
+// save writes the history to disk
+func (ft *FlakyTracker) save() error {
+	tests := ft.Tests()
+	data, err := json.MarshalIndent(tests, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(ft.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save flaky tests: %w", err)
+	}
+	return nil
+}
+
+// runTestStep runs a step and, when a test step fails, reruns it to tell
+// flaky tests from real failures. Tests that pass in some run are flaky;
+// tests failing every run are real failures unless quarantined. The result
+// succeeds when only flaky or quarantined tests failed.
+func (v *Verifier) runTestStep(ctx context.Context, project *Project, step Step) *VerificationResult {
+	pv := NewVerifier(project)
+	result := pv.RunStep(ctx, step)
+	if result.Success || step.Kind != StepTest || v.flaky == nil {
+		return result
+	}
+
+	first := GetFailingTests(result.Output, project.Type)
+	if len(first) == 0 {
+		return result // Build errors, panics and unparsed output are never flaky
+	}
+
+	failedRuns := make(map[string]int) // Test -> runs it failed in
+	for _, name := range dedupe(first) {
+		failedRuns[name]++
+	}
+	rerunStep := step
+	rerunStep.Command = rerunCommand(project, step.Command, first)
+	runs := 1
+	for i := 0; i < v.reruns; i++ {
+		rerun := pv.RunStep(ctx, rerunStep)
+		result.Duration += rerun.Duration
+		runs++
+		if rerun.Success {
+			break
+		}
+		again := GetFailingTests(rerun.Output, project.Type)
+		if len(again) == 0 {
+			return result // Failed differently; don't guess
+		}
+		for _, name := range dedupe(again) {
+			failedRuns[name]++
+		}
+	}
+
+	var flaky, failed, quarantined, blocking []string
+	for name, n := range failedRuns {
+		key := testKey(project, name)
+		switch {
+		case n < runs:
+			flaky = append(flaky, key)
+		case v.flaky.Quarantined(key):
+			failed = append(failed, key)
+			quarantined = append(quarantined, key)
+		default:
+			failed = append(failed, key)
+			blocking = append(blocking, key)
+		}
+	}
+	sort.Strings(flaky)
+	sort.Strings(quarantined)
+	sort.Strings(blocking)
+	if err := v.flaky.Record(flaky, failed); err != nil {
+		result.Output += "\n" + err.Error()
+	}
+
+	result.Flaky = append(flaky, quarantined...)
+	result.Failures = blocking
+	if len(flaky) > 0 {
+		result.Output += fmt.Sprintf("\n--- flaky ---\nPassed on rerun (%d runs): %s", runs, strings.Join(flaky, ", "))
+	}
+	if len(quarantined) > 0 {
+		result.Output += "\nQuarantined as known flaky: " + strings.Join(quarantined, ", ")
+	}
+	if len(blocking) == 0 {
+		result.Success = true
+	}
+	return result
+}
+
+// rerunCommand narrows a test command to the failing tests where the runner
+// supports selecting them, and otherwise reruns the whole command
+func rerunCommand(project *Project, command string, tests []string) string {
+	switch {
+	case project.Type == ProjectTypeGo && strings.HasPrefix(command, "go test") && !strings.Contains(command, " -run"):
+		var names []string
+		for _, name := range dedupe(tests) {
+			names = append(names, regexp.QuoteMeta(strings.SplitN(name, "/", 2)[0]))
+		}
+		return command + " -run '^(" + strings.Join(dedupe(names), "|") + ")$'"
+	case project.Type == ProjectTypePython && strings.HasSuffix(command, "pytest"):
+		var ids []string
+		for _, id := range dedupe(tests) {
+			ids = append(ids, "'"+strings.ReplaceAll(id, "'", `'\''`)+"'")
+		}
+		return command + " " + strings.Join(ids, " ")
+	}
+	return command
+}
+
+// testKey names a test uniquely across sub-projects
+func testKey(project *Project, name string) string {
+	if project.Dir == "" {
+		return name
+	}
+	return project.Dir + ": " + name
+}
+
+// dedupe removes repeated names, as parsers may report a test more than once
+func dedupe(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	var unique []string
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
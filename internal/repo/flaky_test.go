@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifier_FlakyTests(t *testing.T) {
+	root := t.TempDir()
+	// TestTiming fails on the first run only; TestBroken always fails
+	writeFiles(t, root, map[string]string{
+		"test.sh": `n=$(cat runs 2>/dev/null || echo 0); echo $((n+1)) > runs
+if [ "$n" = 0 ]; then echo "--- FAIL: TestTiming (0.01s)"; fi
+echo "--- FAIL: TestBroken (0.00s)"
+exit 1
+`,
+	})
+	p := &Project{Root: root, Type: ProjectTypeGo}
+	p.SetSteps([]Step{{Name: "test", Kind: StepTest, Command: "sh test.sh", Required: true}})
+
+	tracker, err := NewFlakyTracker(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v := NewVerifier(p)
+	v.SetFlakyTracker(tracker, 2)
+
+	results, ok := v.Verify(context.Background())
+	if ok {
+		t.Fatal("expected the consistent failure to fail verification")
+	}
+	if got := strings.Join(results[0].Flaky, " "); got != "TestTiming" {
+		t.Errorf("expected TestTiming to be flaky, got %q", got)
+	}
+	if !strings.Contains(results[0].Output, "Passed on rerun (3 runs): TestTiming") {
+		t.Errorf("expected a flaky note in the output, got %q", results[0].Output)
+	}
+
+	// The history survives a restart
+	tracker, err = NewFlakyTracker(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !tracker.Quarantined("TestTiming") || tracker.Quarantined("TestBroken") {
+		t.Errorf("unexpected quarantine: %+v", tracker.Tests())
+	}
+
+	// A quarantined test failing every run no longer gates
+	writeFiles(t, root, map[string]string{"test.sh": "echo '--- FAIL: TestTiming (0.01s)'; exit 1\n"})
+	v.SetFlakyTracker(tracker, 1)
+	results, ok = v.Verify(context.Background())
+	if !ok || !results[0].Success {
+		t.Fatalf("expected quarantined failures not to gate, got %+v", results[0])
+	}
+	if got := strings.Join(results[0].Flaky, " "); got != "TestTiming" {
+		t.Errorf("expected TestTiming to be reported, got %q", got)
+	}
+
+	// A test failing every rerun keeps its quarantine for a few runs only
+	for i := 0; i < 2; i++ {
+		if _, ok := v.Verify(context.Background()); !ok {
+			t.Fatalf("expected run %d of the quarantined test not to gate", i+2)
+		}
+	}
+	if _, ok := v.Verify(context.Background()); ok {
+		t.Error("expected a test failing every run to lose its quarantine")
+	}
+
+	// Failures that can't be attributed to tests are never flaky
+	writeFiles(t, root, map[string]string{"test.sh": "echo 'build failed'; exit 2\n"})
+	if _, ok := v.Verify(context.Background()); ok {
+		t.Error("expected an unparsed failure to fail verification")
+	}
+}
+
+func TestRerunCommand(t *testing.T) {
+	goProject := &Project{Type: ProjectTypeGo}
+	if got := rerunCommand(goProject, "go test -race ./...", []string{"TestA/sub", "TestA", "TestB"}); got != "go test -race ./... -run '^(TestA|TestB)$'" {
+		t.Errorf("unexpected go rerun: %s", got)
+	}
+	if got := rerunCommand(goProject, "make test", []string{"TestA"}); got != "make test" {
+		t.Errorf("expected the whole command to rerun, got %s", got)
+	}
+	pyProject := &Project{Type: ProjectTypePython}
+	if got := rerunCommand(pyProject, "uv run pytest", []string{"tests/test_a.py::test_x"}); got != "uv run pytest 'tests/test_a.py::test_x'" {
+		t.Errorf("unexpected pytest rerun: %s", got)
+	}
+}
+
+func TestFlakyTracker_Expiry(t *testing.T) {
+	tracker, err := NewFlakyTracker(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := tracker.Record([]string{"TestOld", "TestRecent", "TestFixed"}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tracker.tests["TestOld"].LastFlaky = time.Now().Add(-QuarantineWindow - time.Hour)
+
+	if tracker.Quarantined("TestOld") {
+		t.Error("expected the quarantine to expire after the window")
+	}
+	if !tracker.Quarantined("TestRecent") {
+		t.Error("expected a recent flake to stay quarantined")
+	}
+
+	if err := tracker.Release("TestFixed"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tracker.Quarantined("TestFixed") || len(tracker.Tests()) != 2 {
+		t.Errorf("expected a released test to be forgotten, got %+v", tracker.Tests())
+	}
+}
@@ -0,0 +1,342 @@
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Target is the narrowest test command covering a change within one project
+type Target struct {
+	Project *Project
+	Command string
+	Scope   []string // Packages, crates or test files selected (nil = whole project)
+}
+
+// Full reports whether the target runs the project's whole test suite
+func (t Target) Full() bool {
+	return len(t.Scope) == 0
+}
+
+// Targets computes the tests affected by changed workspace-relative files:
+// Go packages that import a changed package, Cargo crates that depend on a
+// changed crate, pytest files for changed modules, and otherwise the owning
+// project's test command. Files that may affect everything (go.mod,
+// Cargo.lock, conftest.py, ...) select the project's whole suite.
+func (v *Verifier) Targets(ctx context.Context, files []string) []Target {
+	var targets []Target
+	for _, project := range v.project.Affected(files) {
+		rel := projectFiles(project, files)
+
+		var target Target
+		var ok bool
+		switch {
+		case len(project.Steps) > 0:
+			target, ok = Target{Project: project, Command: project.TestCommand}, true
+		case project.Type == ProjectTypeGo:
+			target, ok = goTarget(ctx, project, rel)
+		case project.Type == ProjectTypeRust:
+			target, ok = cargoTarget(ctx, project, rel)
+		case project.Type == ProjectTypePython:
+			target, ok = pytestTarget(project, rel)
+		default:
+			target, ok = Target{Project: project, Command: project.TestCommand}, true
+		}
+		if ok && target.Command != "" {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// TargetedCheck runs only the tests affected by the changed files, for fast
+// feedback between full verifications
+func (v *Verifier) TargetedCheck(ctx context.Context, files []string) *VerificationResult {
+	var results []*VerificationResult
+	for _, target := range v.Targets(ctx, files) {
+		result := v.runTestStep(ctx, target.Project, Step{Name: "targeted tests", Kind: StepTest, Command: target.Command, Required: true})
+		if target.Project.Dir != "" {
+			result.Name = target.Project.Dir + ": " + result.Name
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return &VerificationResult{Success: true, Output: "No tests affected by the changed files"}
+	}
+	return CombineResults(results)
+}
+
+// projectFiles returns the changed files inside a project, relative to it
+func projectFiles(project *Project, files []string) []string {
+	var rel []string
+	for _, file := range files {
+		for _, path := range strings.Split(file, " -> ") {
+			path = filepath.ToSlash(strings.TrimSpace(path))
+			if project.Dir == "" {
+				rel = append(rel, path)
+			} else if strings.HasPrefix(path, project.Dir+"/") {
+				rel = append(rel, strings.TrimPrefix(path, project.Dir+"/"))
+			}
+		}
+	}
+	return rel
+}
+
+// goTarget selects the packages of a Go module affected by changed files,
+// following the import graph from each changed package to its importers
+func goTarget(ctx context.Context, project *Project, files []string) (Target, bool) {
+	full := Target{Project: project, Command: project.TestCommand}
+
+	changedDirs := make(map[string]bool)
+	for _, file := range files {
+		switch filepath.Base(file) {
+		case "go.mod", "go.sum", "go.work", "go.work.sum":
+			return full, true
+		}
+		changedDirs[filepath.ToSlash(filepath.Dir(file))] = true
+	}
+
+	cmd := exec.CommandContext(ctx, "go", "list", "-e", "-f",
+		`{{.Dir}}{{"\t"}}{{.ImportPath}}{{"\t"}}{{join .Imports " "}} {{join .TestImports " "}} {{join .XTestImports " "}}`, "./...")
+	cmd.Dir = project.Root
+	output, err := cmd.Output()
+	if err != nil {
+		return full, true
+	}
+
+	dirs := make(map[string]string)        // Import path -> relative dir
+	importers := make(map[string][]string) // Import path -> packages importing it
+	byDir := make(map[string]string)       // Relative dir -> import path
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		rel, err := filepath.Rel(project.Root, parts[0])
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		dirs[parts[1]] = rel
+		byDir[rel] = parts[1]
+		for _, imp := range strings.Fields(parts[2]) {
+			importers[imp] = append(importers[imp], parts[1])
+		}
+	}
+
+	// Seed with the package owning each changed file. Non-Go files only
+	// count inside a package directory or its testdata.
+	affected := make(map[string]bool)
+	var queue []string
+	for dir := range changedDirs {
+		if before, _, found := strings.Cut("/"+dir+"/", "/testdata/"); found {
+			dir = strings.TrimPrefix(before, "/")
+			if dir == "" {
+				dir = "."
+			}
+		}
+		if pkg, ok := byDir[dir]; ok && !affected[pkg] {
+			affected[pkg] = true
+			queue = append(queue, pkg)
+		}
+	}
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		for _, importer := range importers[pkg] {
+			if _, inModule := dirs[importer]; inModule && !affected[importer] {
+				affected[importer] = true
+				queue = append(queue, importer)
+			}
+		}
+	}
+
+	if len(affected) == 0 {
+		return Target{}, false
+	}
+	if len(affected) == len(dirs) {
+		return full, true
+	}
+
+	scope := make([]string, 0, len(affected))
+	for pkg := range affected {
+		if dir := dirs[pkg]; dir == "." {
+			scope = append(scope, ".")
+		} else {
+			scope = append(scope, "./"+dir)
+		}
+	}
+	sort.Strings(scope)
+
+	// Keep the project's test flags, e.g. go test -race ./...
+	command := "go test " + strings.Join(scope, " ")
+	if strings.HasPrefix(project.TestCommand, "go test") && strings.Contains(project.TestCommand, "./...") {
+		command = strings.Replace(project.TestCommand, "./...", strings.Join(scope, " "), 1)
+	}
+	return Target{Project: project, Command: command, Scope: scope}, true
+}
+
+// cargoPackage is a workspace member as reported by cargo metadata
+type cargoPackage struct {
+	Name         string `json:"name"`
+	ManifestPath string `json:"manifest_path"`
+	Dependencies []struct {
+		Name string `json:"name"`
+	} `json:"dependencies"`
+}
+
+// cargoTarget selects the crates of a Cargo workspace affected by changed
+// files, following dependencies from each changed crate to its dependents
+func cargoTarget(ctx context.Context, project *Project, files []string) (Target, bool) {
+	full := Target{Project: project, Command: project.TestCommand}
+
+	cmd := exec.CommandContext(ctx, "cargo", "metadata", "--no-deps", "--format-version", "1")
+	cmd.Dir = project.Root
+	output, err := cmd.Output()
+	if err != nil {
+		return full, true
+	}
+	var metadata struct {
+		Packages []cargoPackage `json:"packages"`
+	}
+	if err := json.Unmarshal(output, &metadata); err != nil || len(metadata.Packages) < 2 {
+		return full, true
+	}
+
+	dirs := make(map[string]string) // Crate name -> relative dir
+	dependents := make(map[string][]string)
+	for _, pkg := range metadata.Packages {
+		rel, err := filepath.Rel(project.Root, filepath.Dir(pkg.ManifestPath))
+		if err != nil {
+			return full, true
+		}
+		dirs[pkg.Name] = filepath.ToSlash(rel)
+		for _, dep := range pkg.Dependencies {
+			dependents[dep.Name] = append(dependents[dep.Name], pkg.Name)
+		}
+	}
+
+	affected := make(map[string]bool)
+	var queue []string
+	for _, file := range files {
+		// The deepest crate directory containing the file owns it
+		owner := ""
+		for name, dir := range dirs {
+			if (dir == "." || strings.HasPrefix(file, dir+"/")) && (owner == "" || pathDepth(dir) > pathDepth(dirs[owner])) {
+				owner = name
+			}
+		}
+
+		// The lockfile and the workspace manifest can affect every crate
+		rootManifest := file == "Cargo.toml" && (owner == "" || dirs[owner] == ".")
+		if file == "Cargo.lock" || rootManifest {
+			return full, true
+		}
+		if owner != "" && !affected[owner] {
+			affected[owner] = true
+			queue = append(queue, owner)
+		}
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[name] {
+			if _, member := dirs[dependent]; member && !affected[dependent] {
+				affected[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	if len(affected) == 0 {
+		return Target{}, false
+	}
+	if len(affected) == len(dirs) {
+		return full, true
+	}
+	scope := make([]string, 0, len(affected))
+	for name := range affected {
+		scope = append(scope, name)
+	}
+	sort.Strings(scope)
+	return Target{Project: project, Command: "cargo test -p " + strings.Join(scope, " -p "), Scope: scope}, true
+}
+
+// pytestTarget selects the test files covering changed Python files: changed
+// test files themselves and test_<module>.py / <module>_test.py for changed
+// modules. A changed module without matching tests selects the whole suite.
+func pytestTarget(project *Project, files []string) (Target, bool) {
+	full := Target{Project: project, Command: project.TestCommand}
+
+	// Projects testing through tox or nox can still run pytest directly in
+	// their virtualenv, if they have one
+	pytest := project.TestCommand
+	if !strings.HasSuffix(pytest, "pytest") {
+		if project.VirtualEnv == "" {
+			return full, true
+		}
+		pytest = "pytest"
+	}
+
+	testFiles := make(map[string][]string) // Base name -> paths
+	for _, file := range ListFiles(project.Root) {
+		file = filepath.ToSlash(file)
+		if base := filepath.Base(file); isPytestFile(base) {
+			testFiles[base] = append(testFiles[base], file)
+		}
+	}
+
+	selected := make(map[string]bool)
+	for _, file := range files {
+		base := filepath.Base(file)
+		switch {
+		case base == "conftest.py" || base == "pyproject.toml" || base == "setup.py" || base == "setup.cfg" || base == "pytest.ini" || base == "tox.ini":
+			return full, true
+		case !strings.HasSuffix(base, ".py"):
+			continue
+		case isPytestFile(base):
+			if fileExists(filepath.Join(project.Root, file)) {
+				selected[file] = true
+			}
+		default:
+			module := strings.TrimSuffix(base, ".py")
+			matches := append(testFiles["test_"+module+".py"], testFiles[module+"_test.py"]...)
+			if len(matches) == 0 {
+				return full, true
+			}
+			for _, match := range matches {
+				selected[match] = true
+			}
+		}
+	}
+
+	if len(selected) == 0 {
+		return Target{}, false
+	}
+	scope := make([]string, 0, len(selected))
+	for file := range selected {
+		scope = append(scope, file)
+	}
+	sort.Strings(scope)
+	return Target{Project: project, Command: pytest + " -x --tb=short " + strings.Join(scope, " "), Scope: scope}, true
+}
+
+// pathDepth returns the number of components of a relative path ("." is 0)
+func pathDepth(dir string) int {
+	if dir == "." {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+// isPytestFile reports whether a file name follows pytest's test file naming
+func isPytestFile(base string) bool {
+	return strings.HasSuffix(base, ".py") && (strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py"))
+}
@@ -0,0 +1,134 @@
+package repo
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestTargets_Go(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":            "module example.com/app\n\ngo 1.21\n",
+		"main.go":           "package main\n\nimport _ \"example.com/app/api\"\n\nfunc main() {}\n",
+		"api/api.go":        "package api\n\nimport _ \"example.com/app/store\"\n",
+		"store/store.go":    "package store\n",
+		"util/util.go":      "package util\n",
+		"util/util_test.go": "package util_test\n\nimport _ \"example.com/app/store\"\n",
+		"store/testdata/a":  "fixture",
+		"docs/README.md":    "docs",
+	})
+	p := DetectProject(root)
+	p.TestCommand = "go test -count=1 ./..."
+	v := NewVerifier(p)
+
+	for _, tc := range []struct {
+		files    []string
+		expected string
+	}{
+		{[]string{"api/api.go"}, "go test -count=1 . ./api"},
+		{[]string{"store/testdata/a"}, "go test -count=1 ./..."},
+		{[]string{"api/api.go", "docs/README.md"}, "go test -count=1 . ./api"},
+		{[]string{"util/util.go"}, "go test -count=1 ./util"},
+		{[]string{"go.sum"}, "go test -count=1 ./..."},
+		{[]string{"docs/README.md"}, ""},
+	} {
+		var commands []string
+		for _, target := range v.Targets(context.Background(), tc.files) {
+			commands = append(commands, target.Command)
+		}
+		if got := strings.Join(commands, "; "); got != tc.expected {
+			t.Errorf("Targets(%v) = %q, want %q", tc.files, got, tc.expected)
+		}
+	}
+
+	result := v.TargetedCheck(context.Background(), []string{"util/util.go"})
+	if !result.Success || result.Command != "go test -count=1 ./util" {
+		t.Errorf("unexpected targeted result: %+v", result)
+	}
+}
+
+func TestTargets_Pytest(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"pyproject.toml":            "[project]\nname = \"app\"\n",
+		"app/orders.py":             "",
+		"app/billing.py":            "",
+		"tests/test_orders.py":      "",
+		"tests/unit/orders_test.py": "",
+		"tests/test_misc.py":        "",
+	})
+	v := NewVerifier(DetectProject(root))
+
+	for _, tc := range []struct {
+		files    []string
+		expected string
+	}{
+		{[]string{"app/orders.py"}, "pytest -x --tb=short tests/test_orders.py tests/unit/orders_test.py"},
+		{[]string{"tests/test_misc.py", "README.md"}, "pytest -x --tb=short tests/test_misc.py"},
+		{[]string{"app/billing.py"}, "pytest"},
+		{[]string{"tests/conftest.py"}, "pytest"},
+		{[]string{"README.md"}, ""},
+	} {
+		var commands []string
+		for _, target := range v.Targets(context.Background(), tc.files) {
+			commands = append(commands, target.Command)
+		}
+		if got := strings.Join(commands, "; "); got != tc.expected {
+			t.Errorf("Targets(%v) = %q, want %q", tc.files, got, tc.expected)
+		}
+	}
+}
+
+func TestTargets_SubProjects(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"Makefile":         "test:\n\techo root\n",
+		"web/package.json": `{"name": "web", "scripts": {"test": "vitest"}}`,
+	})
+	targets := NewVerifier(DetectProject(root)).Targets(context.Background(), []string{"web/src/app.ts"})
+	if len(targets) != 1 || targets[0].Project.Dir != "web" || targets[0].Command != "npm test" || !targets[0].Full() {
+		t.Errorf("expected the owning package's tests, got %+v", targets)
+	}
+}
+
+func TestTargets_Cargo(t *testing.T) {
+	if _, err := exec.LookPath("cargo"); err != nil {
+		t.Skip("cargo not available")
+	}
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"Cargo.toml":           "[workspace]\nmembers = [\"core\", \"api\", \"cli\"]\n",
+		"core/Cargo.toml":      "[package]\nname = \"core\"\nversion = \"0.1.0\"\nedition = \"2021\"\n",
+		"core/src/lib.rs":      "",
+		"api/Cargo.toml":       "[package]\nname = \"api\"\nversion = \"0.1.0\"\nedition = \"2021\"\n\n[dependencies]\ncore = { path = \"../core\" }\n",
+		"api/src/lib.rs":       "",
+		"cli/Cargo.toml":       "[package]\nname = \"cli\"\nversion = \"0.1.0\"\nedition = \"2021\"\n",
+		"cli/src/main.rs":      "fn main() {}\n",
+		"docs/architecture.md": "",
+	})
+	p := detectDir(root) // The members are tested through the workspace root
+	v := NewVerifier(p)
+
+	for _, tc := range []struct {
+		files    []string
+		expected string
+	}{
+		{[]string{"core/src/lib.rs"}, "cargo test -p api -p core"},
+		{[]string{"cli/src/main.rs"}, "cargo test -p cli"},
+		{[]string{"Cargo.lock"}, "cargo test"},
+		{[]string{"docs/architecture.md"}, ""},
+	} {
+		var commands []string
+		for _, target := range v.Targets(context.Background(), tc.files) {
+			commands = append(commands, target.Command)
+		}
+		if got := strings.Join(commands, "; "); got != tc.expected {
+			t.Errorf("Targets(%v) = %q, want %q", tc.files, got, tc.expected)
+		}
+	}
+}
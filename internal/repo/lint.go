@@ -0,0 +1,286 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// diagnosticLine matches "file:line[:col][:] message" as printed by most
+	// linters (go vet, golangci-lint, ruff, rubocop, swiftlint, credo, ...)
+	diagnosticLine = regexp.MustCompile(`^(?:\./)?([^\s:][^:]*\.\w+):(\d+)(?::(\d+))?:?\s+(.+)$`)
+	// rustcLocation matches the " --> file:line:col" line under a rustc or clippy message
+	rustcLocation = regexp.MustCompile(`^\s*--> ([^:]+):(\d+):(\d+)`)
+	// eslintEntry matches "  line:col  severity  message" under a file in eslint's stylish format
+	eslintEntry = regexp.MustCompile(`^\s+(\d+):(\d+)\s+((?:error|warning)\s+.+)$`)
+	// cargoEdition matches the edition in Cargo.toml
+	cargoEdition = regexp.MustCompile(`(?m)^\s*edition\s*=\s*"(\d+)"`)
+	// shellSafe matches arguments that need no quoting
+	shellSafe = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+)
+
+// Diagnostic is a linter finding at a position in a file
+type Diagnostic struct {
+	File    string // Workspace-relative path
+	Line    int
+	Column  int // 0 when not reported
+	Message string
+}
+
+// String formats the diagnostic as file:line[:col]: message
+func (d Diagnostic) String() string {
+	if d.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+}
+
+// LintOptions selects the stages of the post-edit lint pipeline
+type LintOptions struct {
+	Format bool // Run the formatter on the changed files
+	Fix    bool // Apply the linter's safe fixes to the changed files
+}
+
+// LintReport is the outcome of the post-edit lint pipeline
+type LintReport struct {
+	Commands    []string            // Format and fix commands that were run
+	Result      *VerificationResult // Lint run after fixes (nil without a linter)
+	Diagnostics []Diagnostic        // Remaining findings in the changed files
+	Other       int                 // Remaining findings in other files
+}
+
+// LintChanges runs the post-edit pipeline on changed workspace-relative
+// files: the formatter on those files only, the linter's safe fixes, then
+// the linter, whose findings are parsed into diagnostics. Formatters and
+// fixers that can't be limited to the changed files are skipped.
+func (v *Verifier) LintChanges(ctx context.Context, files []string, opts LintOptions) *LintReport {
+	report := &LintReport{}
+	var results []*VerificationResult
+	for _, project := range v.project.Affected(files) {
+		var changed []string
+		for _, file := range projectFiles(project, files) {
+			if fileExists(filepath.Join(project.Root, file)) {
+				changed = append(changed, file)
+			}
+		}
+		if len(changed) == 0 {
+			continue
+		}
+
+		pv := NewVerifier(project)
+		for _, command := range []string{formatCommand(project, changed, opts.Format), fixCommand(project, changed, opts.Fix)} {
+			if command == "" {
+				continue
+			}
+			pv.runCommand(ctx, command)
+			report.Commands = append(report.Commands, command)
+		}
+
+		if len(project.stepsOfKind(StepLint)) == 0 {
+			continue
+		}
+		result := pv.RunLint(ctx)
+		if project.Dir != "" {
+			result.Name = project.Dir + ": lint"
+		}
+		results = append(results, result)
+
+		inChanged := make(map[string]bool, len(changed))
+		for _, file := range changed {
+			inChanged[file] = true
+		}
+		for _, d := range ParseDiagnostics(project.Root, result.Output) {
+			if !inChanged[d.File] {
+				report.Other++
+				continue
+			}
+			if project.Dir != "" {
+				d.File = project.Dir + "/" + d.File
+			}
+			report.Diagnostics = append(report.Diagnostics, d)
+		}
+	}
+	if len(results) > 0 {
+		report.Result = CombineResults(results)
+	}
+	return report
+}
+
+// ParseDiagnostics extracts file/line diagnostics from linter output, with
+// paths made relative to root
+func ParseDiagnostics(root, output string) []Diagnostic {
+	var diagnostics []Diagnostic
+	seen := make(map[Diagnostic]bool)
+	add := func(file, line, col, message string) {
+		d := Diagnostic{File: relativePath(root, file), Message: strings.TrimSpace(message)}
+		d.Line, _ = strconv.Atoi(line)
+		d.Column, _ = strconv.Atoi(col)
+		if !seen[d] {
+			seen[d] = true
+			diagnostics = append(diagnostics, d)
+		}
+	}
+
+	var message, eslintFile string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case rustcLocation.MatchString(line) && message != "":
+			m := rustcLocation.FindStringSubmatch(line)
+			add(m[1], m[2], m[3], message)
+			message = ""
+		case strings.HasPrefix(line, "warning:") || strings.HasPrefix(line, "error:") || strings.HasPrefix(line, "error["):
+			message = line
+		case eslintEntry.MatchString(line) && eslintFile != "":
+			m := eslintEntry.FindStringSubmatch(line)
+			add(eslintFile, m[1], m[2], strings.Join(strings.Fields(m[3]), " "))
+		case diagnosticLine.MatchString(strings.TrimSpace(line)):
+			m := diagnosticLine.FindStringSubmatch(strings.TrimSpace(line))
+			add(m[1], m[2], m[3], m[4])
+		case line != "" && !strings.HasPrefix(line, " ") && filepath.Ext(line) != "" && !strings.Contains(line, " "):
+			eslintFile = line // eslint's stylish format lists findings under the file
+		}
+	}
+	return diagnostics
+}
+
+// formatCommand returns the project's formatter limited to the given
+// project-relative files, or "" when it can't be limited to them
+func formatCommand(project *Project, files []string, enabled bool) string {
+	command := project.FormatCommand
+	if !enabled || command == "" || len(project.Steps) > 0 {
+		return ""
+	}
+
+	switch {
+	case command == "gofmt -w .":
+		return withFiles("gofmt -w", files, ".go")
+	case command == "cargo fmt":
+		edition := "2021"
+		if data, err := os.ReadFile(filepath.Join(project.Root, "Cargo.toml")); err == nil {
+			if m := cargoEdition.FindSubmatch(data); m != nil {
+				edition = string(m[1])
+			}
+		}
+		return withFiles("rustfmt --edition "+edition, files, ".rs")
+	case strings.HasSuffix(command, "ruff format ."):
+		return withFiles(strings.TrimSuffix(command, " ."), files, ".py", ".pyi")
+	case command == "bundle exec rubocop -a":
+		return withFiles(command, files, ".rb")
+	case command == "vendor/bin/php-cs-fixer fix":
+		return withFiles(command+" --path-mode=intersection", files, ".php")
+	case command == "mix format":
+		return withFiles(command, files, ".ex", ".exs")
+	case command == "swift format --in-place --recursive .":
+		return withFiles("swift format --in-place", files, ".swift")
+	case strings.HasSuffix(command, "xargs clang-format -i"):
+		return withFiles("clang-format -i", files, ".c", ".cc", ".cpp", ".cxx", ".h", ".hh", ".hpp")
+	case strings.HasPrefix(command, "dotnet format ") && !strings.Contains(command, "--include"):
+		return withFiles(command+" --include", files, ".cs", ".fs")
+	case project.Type == ProjectTypeNode && fileContains(filepath.Join(project.Root, "package.json"), "prettier"):
+		return withFiles(nodeRunner(command)+" prettier --write --ignore-unknown", files)
+	}
+	return ""
+}
+
+// fixCommand returns the linter's safe auto-fix limited to the given
+// project-relative files where possible, or "" when it has none
+func fixCommand(project *Project, files []string, enabled bool) string {
+	command := project.LintCommand
+	if !enabled || command == "" || len(project.Steps) > 0 {
+		return ""
+	}
+
+	switch {
+	case command == "golangci-lint run":
+		dirs := make(map[string]bool)
+		var packages []string
+		for _, file := range files {
+			if dir := filepath.ToSlash(filepath.Dir(file)); strings.HasSuffix(file, ".go") && !dirs[dir] {
+				dirs[dir] = true
+				packages = append(packages, "./"+strings.TrimPrefix(dir, "."))
+			}
+		}
+		if len(packages) == 0 {
+			return ""
+		}
+		return command + " --fix " + strings.Join(packages, " ")
+	case command == "cargo clippy":
+		// Only machine-applicable suggestions are applied
+		if !hasExt(files, ".rs") {
+			return ""
+		}
+		return "cargo clippy --fix --allow-dirty --allow-staged"
+	case strings.HasSuffix(command, "ruff check ."):
+		return withFiles(strings.TrimSuffix(command, " .")+" --fix", files, ".py", ".pyi")
+	case command == "bundle exec rubocop":
+		return withFiles(command+" -a", files, ".rb") // -a applies safe corrections only
+	case command == "swiftlint":
+		return withFiles("swiftlint --fix", files, ".swift")
+	case project.Type == ProjectTypeNode && fileContains(filepath.Join(project.Root, "package.json"), "eslint"):
+		return withFiles(nodeRunner(command)+" eslint --fix", files, ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs", ".vue")
+	}
+	return ""
+}
+
+// withFiles appends the files with one of the extensions (any file when
+// none are given) to a command, or returns "" when there are none
+func withFiles(command string, files []string, exts ...string) string {
+	var args []string
+	for _, file := range files {
+		if len(exts) == 0 || hasExt([]string{file}, exts...) {
+			args = append(args, shellQuote(file))
+		}
+	}
+	if len(args) == 0 {
+		return ""
+	}
+	return command + " " + strings.Join(args, " ")
+}
+
+// hasExt reports whether any file has one of the extensions
+func hasExt(files []string, exts ...string) bool {
+	for _, file := range files {
+		for _, ext := range exts {
+			if strings.HasSuffix(file, ext) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nodeRunner returns the command running a package binary with the package
+// manager of a script command such as "pnpm format"
+func nodeRunner(command string) string {
+	switch {
+	case strings.HasPrefix(command, "pnpm"):
+		return "pnpm exec"
+	case strings.HasPrefix(command, "yarn"):
+		return "yarn"
+	}
+	return "npx"
+}
+
+// shellQuote quotes an argument for sh
+func shellQuote(arg string) string {
+	if shellSafe.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// relativePath makes a reported path relative to root
+func relativePath(root, path string) string {
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(root, path); err == nil {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(strings.TrimPrefix(path, "./"))
+}
@@ -0,0 +1,118 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	root := "/work/app"
+	output := `./calc.go:12:2: ineffectual assignment to err (ineffassign)
+app/models.py:3:1: F401 [*] ` + "`os`" + ` imported but unused
+warning: unused variable: ` + "`x`" + `
+ --> src/main.rs:4:9
+  |
+/work/app/web/src/index.ts
+   7:5  error  'y' is assigned a value but never used  no-unused-vars
+
+✖ 1 problem (1 error, 0 warnings)
+Found 2 errors.`
+
+	var got []string
+	for _, d := range ParseDiagnostics(root, output) {
+		got = append(got, d.String())
+	}
+	want := []string{
+		"calc.go:12:2: ineffectual assignment to err (ineffassign)",
+		"app/models.py:3:1: F401 [*] `os` imported but unused",
+		"src/main.rs:4:9: warning: unused variable: `x`",
+		"web/src/index.ts:7:5: error 'y' is assigned a value but never used no-unused-vars",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected diagnostics:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestVerifier_LintChanges(t *testing.T) {
+	if _, err := exec.LookPath("gofmt"); err != nil {
+		t.Skip("gofmt not available")
+	}
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"a.go":     "package a\nfunc  A( ) {}\n",
+		"other.go": "package a\n",
+	})
+	p := &Project{
+		Root:          root,
+		Type:          ProjectTypeGo,
+		FormatCommand: "gofmt -w .",
+		LintCommand:   "echo './a.go:3:1: exported function A should have comment'; echo './other.go:1:1: package comment missing'; exit 1",
+	}
+
+	report := NewVerifier(p).LintChanges(context.Background(), []string{"a.go", "gone.go"}, LintOptions{Format: true, Fix: true})
+	if strings.Join(report.Commands, " | ") != "gofmt -w a.go" {
+		t.Errorf("expected only the changed file to be formatted, got %v", report.Commands)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "a.go")); string(data) != "package a\n\nfunc A() {}\n" {
+		t.Errorf("expected a.go to be formatted, got %q", data)
+	}
+	if len(report.Diagnostics) != 1 || report.Diagnostics[0].File != "a.go" || report.Diagnostics[0].Line != 3 || report.Other != 1 {
+		t.Errorf("unexpected findings: %+v (other %d)", report.Diagnostics, report.Other)
+	}
+	if report.Result == nil || report.Result.Success {
+		t.Errorf("expected the failing lint result, got %+v", report.Result)
+	}
+
+	report = NewVerifier(p).LintChanges(context.Background(), []string{"a.go"}, LintOptions{})
+	if len(report.Commands) != 0 {
+		t.Errorf("expected no formatting when disabled, got %v", report.Commands)
+	}
+}
+
+func TestFormatAndFixCommands(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"Cargo.toml":   "[package]\nname = \"x\"\nedition = \"2018\"\n",
+		"package.json": `{"devDependencies": {"prettier": "^3", "eslint": "^9"}}`,
+	})
+	files := []string{"main.go", "pkg/util.go", "src/lib.rs", "app/it's.py", "web/index.ts"}
+
+	tests := []struct {
+		project *Project
+		format  string
+		fix     string
+	}{
+		{&Project{Root: root, Type: ProjectTypeGo, FormatCommand: "gofmt -w .", LintCommand: "golangci-lint run"},
+			"gofmt -w main.go pkg/util.go", "golangci-lint run --fix ./ ./pkg"},
+		{&Project{Root: root, Type: ProjectTypeRust, FormatCommand: "cargo fmt", LintCommand: "cargo clippy"},
+			"rustfmt --edition 2018 src/lib.rs", "cargo clippy --fix --allow-dirty --allow-staged"},
+		{&Project{Root: root, Type: ProjectTypePython, FormatCommand: "uv run ruff format .", LintCommand: "uv run ruff check ."},
+			`uv run ruff format 'app/it'\''s.py'`, `uv run ruff check --fix 'app/it'\''s.py'`},
+		{&Project{Root: root, Type: ProjectTypeNode, FormatCommand: "pnpm format", LintCommand: "pnpm lint"},
+			"pnpm exec prettier --write --ignore-unknown main.go pkg/util.go src/lib.rs 'app/it'\\''s.py' web/index.ts", "pnpm exec eslint --fix web/index.ts"},
+		{&Project{Root: root, Type: ProjectTypeMake, FormatCommand: "make format", LintCommand: "make lint"}, "", ""},
+	}
+	for _, tt := range tests {
+		if got := formatCommand(tt.project, files, true); got != tt.format {
+			t.Errorf("%s format: got %q, want %q", tt.project.Type, got, tt.format)
+		}
+		if got := fixCommand(tt.project, files, true); got != tt.fix {
+			t.Errorf("%s fix: got %q, want %q", tt.project.Type, got, tt.fix)
+		}
+	}
+}
+
+func TestVerifier_LintRequired(t *testing.T) {
+	p := &Project{Root: t.TempDir(), Type: ProjectTypeMake, LintCommand: "exit 1", TestCommand: "true"}
+	if _, ok := NewVerifier(p).Verify(context.Background()); !ok {
+		t.Error("expected advisory lint failures not to fail verification")
+	}
+	p.LintRequired = true
+	if results, ok := NewVerifier(p).Verify(context.Background()); ok || results[len(results)-1].Name != "lint" {
+		t.Errorf("expected required lint to fail verification, got %+v", results)
+	}
+}
@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Step kinds known to the verifier
+const (
+	StepBuild  = "build"
+	StepTest   = "test"
+	StepLint   = "lint"
+	StepFormat = "format"
+)
+
+// Step is a single verification command
+type Step struct {
+	Name     string            // Display name (defaults to Kind)
+	Kind     string            // build, test, lint, format or empty for custom steps
+	Command  string            // Shell command
+	Dir      string            // Working directory relative to the project root
+	Timeout  time.Duration     // Timeout (0 = none beyond the caller's context)
+	Required bool              // Whether failure blocks commits
+	Env      map[string]string // Extra environment variables
+}
+
+// Describe returns a one-line description of the step
+func (s Step) Describe() string {
+	var details []string
+	if s.Dir != "" && s.Dir != "." {
+		details = append(details, "in "+s.Dir)
+	}
+	if s.Timeout > 0 {
+		details = append(details, "timeout "+s.Timeout.String())
+	}
+	if len(s.Env) > 0 {
+		keys := make([]string, 0, len(s.Env))
+		for k := range s.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		details = append(details, "env "+strings.Join(keys, " "))
+	}
+	if !s.Required {
+		details = append(details, "advisory")
+	}
+
+	desc := fmt.Sprintf("%s: `%s`", s.Name, s.Command)
+	if len(details) > 0 {
+		desc += " (" + strings.Join(details, ", ") + ")"
+	}
+	return desc
+}
+
+// VerifySteps returns the project's verification steps in run order. Steps
+// declared by the repo are used as given; otherwise they are derived from
+// the detected commands, with build and test required, lint required when
+// LintRequired is set and format advisory.
+func (p *Project) VerifySteps() []Step {
+	if len(p.Steps) > 0 {
+		return p.Steps
+	}
+
+	var steps []Step
+	for _, s := range []Step{
+		{Kind: StepFormat, Command: p.FormatCommand},
+		{Kind: StepLint, Command: p.LintCommand, Required: p.LintRequired},
+		{Kind: StepBuild, Command: p.BuildCommand, Required: true},
+		{Kind: StepTest, Command: p.TestCommand, Required: true},
+	} {
+		if s.Command != "" {
+			s.Name = s.Kind
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+// SetSteps replaces the detected commands with explicit verification steps.
+// The per-kind commands are updated to match so that everything reporting
+// them agrees with the steps that are run.
+func (p *Project) SetSteps(steps []Step) {
+	p.Steps = steps
+	p.BuildCommand = stepCommand(steps, StepBuild)
+	p.TestCommand = stepCommand(steps, StepTest)
+	p.LintCommand = stepCommand(steps, StepLint)
+	p.FormatCommand = stepCommand(steps, StepFormat)
+}
+
+// stepsOfKind returns the verification steps of a kind
+func (p *Project) stepsOfKind(kind string) []Step {
+	var steps []Step
+	for _, s := range p.VerifySteps() {
+		if s.Kind == kind {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+// stepCommand returns the commands of all steps of a kind joined with &&
+func stepCommand(steps []Step, kind string) string {
+	var commands []string
+	for _, s := range steps {
+		if s.Kind == kind {
+			commands = append(commands, s.Command)
+		}
+	}
+	return strings.Join(commands, " && ")
+}
@@ -0,0 +1,208 @@
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// todoComment matches a marker at the start of a comment, with an
+	// optional owner or issue in parentheses: "// TODO(alice): message"
+	todoComment = regexp.MustCompile(`(?://+|#+|/\*+|\*|--|;+|<!--|%+)\s*(TODO|FIXME|HACK|XXX)\b(?:\(([^)]*)\))?:?\s*(.*)$`)
+	// issueRef matches an issue reference: #123, GH-12, PROJ-42 or an issue URL
+	issueRef = regexp.MustCompile(`(?:^|[\s(\[])(#\d+|[A-Z][A-Z0-9]+-\d+|https?://\S+/(?:issues|pull)/\d+)`)
+)
+
+// todoPriorities ranks the markers the scanner looks for
+var todoPriorities = map[string]int{
+	"FIXME": 2,
+	"HACK":  2,
+	"TODO":  3,
+	"XXX":   3,
+}
+
+// defaultTODOIgnore lists paths never scanned: brewol's own state and
+// vendored or generated code
+var defaultTODOIgnore = []string{".brewol/", "vendor/", "third_party/", "node_modules/", "*.min.js", "*.pb.go", "*_generated.go", "*.lock"}
+
+// maxScanFileSize skips files too large to be hand-written source
+const maxScanFileSize = 1 << 20
+
+// Issue is a TODO-style comment found in the codebase
+type Issue struct {
+	ID       string // Stable across line moves: derived from file, type and message
+	Type     string // TODO, FIXME, HACK or XXX
+	File     string // Workspace-relative path
+	Line     int
+	Message  string
+	Owner    string    // Name in TODO(name): ("" = none)
+	Ref      string    // Issue reference such as #123, PROJ-42 or an issue URL
+	Author   string    // Who last changed the line, from git blame ("" = uncommitted)
+	Date     time.Time // When the line was last changed (zero = uncommitted)
+	Priority int       // 1 = critical, 2 = high, 3 = medium, 4 = low
+}
+
+// Age returns how long ago the comment was last changed, or 0 when uncommitted
+func (i Issue) Age() time.Duration {
+	if i.Date.IsZero() {
+		return 0
+	}
+	return time.Since(i.Date)
+}
+
+// ScanForTODOs finds TODO, FIXME, HACK and XXX comments in the files of the
+// workspace, skipping files ignored by git, brewol's state, vendored code and
+// paths matching the ignore patterns. Authors and dates come from git blame.
+func ScanForTODOs(root string, ignore []string) ([]Issue, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("failed to scan for TODOs: %w", err)
+	}
+
+	patterns := append(append([]string{}, defaultTODOIgnore...), ignore...)
+	var issues []Issue
+	for _, file := range ListFiles(root) {
+		file = filepath.ToSlash(file)
+		if ignored(file, patterns) {
+			continue
+		}
+		found := scanFile(root, file)
+		if len(found) > 0 {
+			blame(root, found)
+			issues = append(issues, found...)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues, nil
+}
+
+// scanFile finds the TODO comments in one text file
+func scanFile(root, file string) []Issue {
+	fullPath := filepath.Join(root, filepath.FromSlash(file))
+	info, err := os.Stat(fullPath)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxScanFileSize {
+		return nil
+	}
+	data, err := os.ReadFile(fullPath)
+	if err != nil || bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return nil // Binary
+	}
+
+	var issues []Issue
+	seen := make(map[string]int) // Repeated identical comments get distinct IDs
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxScanFileSize)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if !strings.Contains(line, "TODO") && !strings.Contains(line, "FIXME") && !strings.Contains(line, "HACK") && !strings.Contains(line, "XXX") {
+			continue
+		}
+		m := todoComment.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		issue := Issue{Type: m[1], File: file, Line: n, Priority: todoPriorities[m[1]]}
+		issue.Message = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(m[3]), "*/"), "-->"))
+		if tag := strings.TrimSpace(m[2]); tag != "" {
+			if ref := issueRef.FindStringSubmatch(" " + tag); ref != nil {
+				issue.Ref = ref[1]
+			} else {
+				issue.Owner = strings.TrimPrefix(tag, "@")
+			}
+		}
+		if ref := issueRef.FindStringSubmatch(issue.Message); ref != nil && issue.Ref == "" {
+			issue.Ref = ref[1]
+		}
+
+		key := issue.Type + "\x00" + issue.Owner + "\x00" + issue.Ref + "\x00" + issue.Message
+		seen[key]++
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d", file, key, seen[key])))
+		issue.ID = hex.EncodeToString(sum[:6])
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// blame fills in the author and date of the lines of issues in one file
+func blame(root string, issues []Issue) {
+	args := []string{"blame", "--line-porcelain"}
+	for _, issue := range issues {
+		args = append(args, "-L", fmt.Sprintf("%d,%d", issue.Line, issue.Line))
+	}
+	cmd := exec.Command("git", append(args, "--", issues[0].File)...)
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return // Not a git repo, or the file is untracked
+	}
+
+	byLine := make(map[int]*Issue, len(issues))
+	for i := range issues {
+		byLine[issues[i].Line] = &issues[i]
+	}
+	var current *Issue
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) >= 3 && len(fields[0]) == 40:
+			// "<sha> <original line> <final line> [<group size>]"
+			n, _ := strconv.Atoi(fields[2])
+			current = byLine[n]
+			if current != nil && strings.Trim(fields[0], "0") == "" {
+				current = nil // Not committed yet
+			}
+		case current == nil:
+		case strings.HasPrefix(line, "author "):
+			current.Author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			if sec, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64); err == nil {
+				current.Date = time.Unix(sec, 0)
+			}
+		}
+	}
+}
+
+// ignored reports whether a workspace-relative path matches an ignore
+// pattern: "dir/" matches everything below a directory, other patterns are
+// globs matched against the whole path or, without a slash, the file name
+func ignored(file string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "/")
+		switch {
+		case strings.HasSuffix(pattern, "/**"):
+			pattern = strings.TrimSuffix(pattern, "**")
+			fallthrough
+		case strings.HasSuffix(pattern, "/"):
+			if strings.HasPrefix(file, pattern) || strings.Contains(file, "/"+pattern) {
+				return true
+			}
+		case strings.Contains(pattern, "/"):
+			if ok, _ := path.Match(pattern, file); ok {
+				return true
+			}
+		default:
+			if ok, _ := path.Match(pattern, path.Base(file)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestScanForTODOs(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":         "build/\n",
+		"main.go":            "package main\n\n// TODO(alice): handle overflow\nfunc main() {\n\tx := 1 // FIXME: see #42\n\ts := \"TODO list\"\n\t_, _ = x, s\n}\n",
+		"docs/notes.md":      "# Notes\n<!-- TODO(PROJ-12): write the guide -->\n",
+		"vendor/lib/lib.go":  "package lib // TODO: vendored\n",
+		"gen/out.go":         "package gen // TODO: generated\n",
+		"build/tmp.go":       "package tmp // TODO: ignored by git\n",
+		".brewol/logs/x.log": "# TODO: from a log\n",
+	})
+	for _, args := range [][]string{{"init", "-q"}, {"add", "-A"}, {"-c", "user.email=a@example.com", "-c", "user.name=alice", "commit", "-qm", "init"}} {
+		if out, err := exec.Command("git", append([]string{"-C", root}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	writeFiles(t, root, map[string]string{"new.py": "# HACK: uncommitted\n"})
+
+	issues, err := ScanForTODOs(root, []string{"gen/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, i := range issues {
+		got = append(got, strings.Join([]string{i.File, i.Type, i.Message, i.Owner, i.Ref, i.Author}, "|"))
+	}
+	want := []string{
+		"docs/notes.md|TODO|write the guide||PROJ-12|alice",
+		"main.go|TODO|handle overflow|alice||alice",
+		"main.go|FIXME|see #42||#42|alice",
+		"new.py|HACK|uncommitted|||",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected issues:\n%s", strings.Join(got, "\n"))
+	}
+	if issues[1].Line != 3 || issues[1].Date.IsZero() || issues[1].Priority != 3 || issues[2].Priority != 2 {
+		t.Errorf("unexpected details: %+v", issues[1])
+	}
+
+	// IDs survive the comment moving down
+	writeFiles(t, root, map[string]string{"main.go": "package main\n\nimport \"fmt\"\n\n// TODO(alice): handle overflow\nfunc main() { fmt.Println() }\n"})
+	moved, err := ScanForTODOs(root, []string{"gen/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if moved[1].Line != 5 || moved[1].ID != issues[1].ID {
+		t.Errorf("expected a stable ID for the moved comment, got %+v (was %s)", moved[1], issues[1].ID)
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	patterns := []string{"gen/", "docs/**", "*.min.js", "api/*.pb.go"}
+	for file, want := range map[string]bool{
+		"gen/a.go":          true,
+		"pkg/gen/a.go":      true,
+		"docs/x/y.md":       true,
+		"web/app.min.js":    true,
+		"api/service.pb.go": true,
+		"api/v1/x.pb.go":    false,
+		"general.go":        false,
+	} {
+		if got := ignored(file, patterns); got != want {
+			t.Errorf("ignored(%q) = %v, want %v", file, got, want)
+		}
+	}
+}
@@ -6,22 +6,35 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
 
 // VerificationResult represents the result of a verification run
 type VerificationResult struct {
+	Name     string // Step name (empty for ad-hoc commands)
+	Kind     string // Step kind (empty for ad-hoc commands)
 	Command  string
 	Success  bool
 	Output   string
 	Duration time.Duration
 	ExitCode int
+	Required bool     // Whether the step blocks commits
+	Flaky    []string // Failed tests ignored as flaky or quarantined
+	Failures []string // Failing tests of a test step
+	Findings []string // Findings of a lint step, without positions
 }
 
 // Verifier runs verification commands for a project
 type Verifier struct {
-	project *Project
+	project  *Project
+	flaky    *FlakyTracker // Flakiness history (nil = no reruns)
+	reruns   int           // Reruns of a failing test step
+	baseline *Baseline     // Failures that don't fail verification (nil = none)
 }
 
 // NewVerifier creates a new Verifier for the given project
@@ -29,92 +42,171 @@ func NewVerifier(project *Project) *Verifier {
 	return &Verifier{project: project}
 }
 
+// SetFlakyTracker enables rerunning failing test steps up to reruns times,
+// recording flaky tests in tracker and not gating on quarantined ones
+func (v *Verifier) SetFlakyTracker(tracker *FlakyTracker, reruns int) {
+	v.flaky = tracker
+	v.reruns = reruns
+}
+
+// SetBaseline stops failures already present at baseline from failing
+// VerifyFiles, so only new breakage blocks
+func (v *Verifier) SetBaseline(baseline *Baseline) {
+	v.baseline = baseline
+}
+
+// FlakyTracker returns the flakiness history, or nil when not tracked
+func (v *Verifier) FlakyTracker() *FlakyTracker {
+	return v.flaky
+}
+
+// Project returns the project being verified
+func (v *Verifier) Project() *Project {
+	return v.project
+}
+
 // RunTests runs the project's test suite
 func (v *Verifier) RunTests(ctx context.Context) *VerificationResult {
-	if v.project.TestCommand == "" {
-		return &VerificationResult{
-			Command: "",
-			Success: true,
-			Output:  "No test command configured for this project type",
-		}
-	}
-
-	return v.runCommand(ctx, v.project.TestCommand)
+	return v.runKind(ctx, StepTest)
 }
 
 // RunBuild runs the project's build command
 func (v *Verifier) RunBuild(ctx context.Context) *VerificationResult {
-	if v.project.BuildCommand == "" {
-		return &VerificationResult{
-			Command: "",
-			Success: true,
-			Output:  "No build command configured for this project type",
-		}
-	}
-
-	return v.runCommand(ctx, v.project.BuildCommand)
+	return v.runKind(ctx, StepBuild)
 }
 
 // RunLint runs the project's linter
 func (v *Verifier) RunLint(ctx context.Context) *VerificationResult {
-	if v.project.LintCommand == "" {
-		return &VerificationResult{
-			Command: "",
-			Success: true,
-			Output:  "No lint command configured for this project type",
-		}
-	}
-
-	return v.runCommand(ctx, v.project.LintCommand)
+	return v.runKind(ctx, StepLint)
 }
 
 // RunFormat runs the project's formatter
 func (v *Verifier) RunFormat(ctx context.Context) *VerificationResult {
-	if v.project.FormatCommand == "" {
-		return &VerificationResult{
-			Command: "",
-			Success: true,
-			Output:  "No format command configured for this project type",
-		}
+	return v.runKind(ctx, StepFormat)
+}
+
+// RunAll runs all verification steps in order
+func (v *Verifier) RunAll(ctx context.Context) []*VerificationResult {
+	var results []*VerificationResult
+	for _, step := range v.project.VerifySteps() {
+		results = append(results, v.RunStep(ctx, step))
 	}
+	return results
+}
 
-	return v.runCommand(ctx, v.project.FormatCommand)
+// Verify verifies every project in the workspace
+func (v *Verifier) Verify(ctx context.Context) ([]*VerificationResult, bool) {
+	return v.VerifyFiles(ctx, nil)
 }
 
-// RunAll runs all verification commands
-func (v *Verifier) RunAll(ctx context.Context) []*VerificationResult {
+// VerifyFiles verifies the projects affected by the given workspace-relative
+// files, or every project when there are none. It runs every step except
+// formatters and stops at the first required step that fails with more than
+// its baseline failures. It reports whether all required steps passed or only
+// repeated their baseline failures; advisory failures are included in the
+// results but do not fail verification.
+func (v *Verifier) VerifyFiles(ctx context.Context, files []string) ([]*VerificationResult, bool) {
 	var results []*VerificationResult
+	for _, project := range v.project.Affected(files) {
+		for _, step := range project.VerifySteps() {
+			if step.Kind == StepFormat {
+				continue
+			}
+			result := v.runTestStep(ctx, project, step)
+			if project.Dir != "" {
+				result.Name = project.Dir + ": " + result.Name
+			}
+			results = append(results, result)
+			if step.Required && !result.Success && !v.baseline.Preexisting(result) {
+				return results, false
+			}
+		}
+	}
+	return results, true
+}
 
-	// Order: format -> lint -> build -> test
-	if v.project.FormatCommand != "" {
-		results = append(results, v.RunFormat(ctx))
+// runKind runs all steps of a kind, combining their results
+func (v *Verifier) runKind(ctx context.Context, kind string) *VerificationResult {
+	steps := v.project.stepsOfKind(kind)
+	if len(steps) == 0 {
+		return &VerificationResult{
+			Command: "",
+			Success: true,
+			Output:  fmt.Sprintf("No %s command configured for this project type", kind),
+		}
 	}
 
-	if v.project.LintCommand != "" {
-		results = append(results, v.RunLint(ctx))
+	var results []*VerificationResult
+	for _, step := range steps {
+		result := v.RunStep(ctx, step)
+		results = append(results, result)
+		if !result.Success {
+			break
+		}
 	}
+	return CombineResults(results)
+}
 
-	if v.project.BuildCommand != "" {
-		results = append(results, v.RunBuild(ctx))
+// RunStep runs a single verification step
+func (v *Verifier) RunStep(ctx context.Context, step Step) *VerificationResult {
+	if step.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, step.Timeout)
+		defer cancel()
 	}
 
-	if v.project.TestCommand != "" {
-		results = append(results, v.RunTests(ctx))
+	dir := v.project.Root
+	if step.Dir != "" {
+		dir = filepath.Join(v.project.Root, step.Dir)
 	}
 
-	return results
+	var env []string
+	for k, val := range step.Env {
+		env = append(env, k+"="+val)
+	}
+	sort.Strings(env)
+
+	result := v.run(ctx, step.Command, dir, env)
+	result.Name = step.Name
+	result.Kind = step.Kind
+	result.Required = step.Required
+	switch {
+	case step.Kind == StepTest && !result.Success:
+		for _, name := range dedupe(GetFailingTests(result.Output, v.project.Type)) {
+			result.Failures = append(result.Failures, testKey(v.project, name))
+		}
+	case step.Kind == StepLint:
+		// Findings leave out line numbers so they survive unrelated edits
+		var findings []string
+		for _, d := range ParseDiagnostics(v.project.Root, result.Output) {
+			findings = append(findings, path.Join(v.project.Dir, d.File)+": "+d.Message)
+		}
+		result.Findings = dedupe(findings)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		result.Output += fmt.Sprintf("\n... (timed out after %s)", step.Timeout)
+	}
+	return result
 }
 
-// runCommand executes a shell command and returns the result
+// runCommand executes a shell command in the project root and returns the result
 func (v *Verifier) runCommand(ctx context.Context, command string) *VerificationResult {
+	return v.run(ctx, command, v.project.Root, nil)
+}
+
+// run executes a shell command in dir with extra environment variables
+func (v *Verifier) run(ctx context.Context, command, dir string, env []string) *VerificationResult {
 	start := time.Now()
 
 	cmd := exec.CommandContext(ctx, "sh", "-c", command)
-	cmd.Dir = v.project.Root
+	cmd.Dir = dir
 	cmd.Env = append(os.Environ(),
 		"GIT_TERMINAL_PROMPT=0",
 		"CI=true", // Many tools behave better in CI mode
 	)
+	cmd.Env = append(cmd.Env, v.project.Env()...)
+	cmd.Env = append(cmd.Env, env...)
+	cmd.WaitDelay = time.Second // Don't wait on children holding the output open after a timeout
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -157,6 +249,52 @@ func (v *Verifier) runCommand(ctx context.Context, command string) *Verification
 	}
 }
 
+// CombineResults merges the results of several steps into one, which fails
+// if any of them failed and takes its exit code from the first failure
+func CombineResults(results []*VerificationResult) *VerificationResult {
+	switch len(results) {
+	case 0:
+		return &VerificationResult{Success: true, Output: "No verification steps to run"}
+	case 1:
+		return results[0]
+	}
+
+	combined := &VerificationResult{Success: true}
+	var commands, outputs []string
+	for _, r := range results {
+		commands = append(commands, r.Command)
+		outputs = append(outputs, fmt.Sprintf("=== %s: %s (%s) ===\n%s", orCommand(r.Name, r.Command), r.Command, passFail(r.Success), r.Output))
+		combined.Duration += r.Duration
+		combined.Required = combined.Required || r.Required
+		combined.Flaky = append(combined.Flaky, r.Flaky...)
+		combined.Failures = append(combined.Failures, r.Failures...)
+		combined.Findings = append(combined.Findings, r.Findings...)
+		if !r.Success && combined.Success {
+			combined.Success = false
+			combined.ExitCode = r.ExitCode
+		}
+	}
+	combined.Command = strings.Join(commands, " && ")
+	combined.Output = strings.Join(outputs, "\n")
+	return combined
+}
+
+// orCommand returns name, or the command if the step is unnamed
+func orCommand(name, command string) string {
+	if name != "" {
+		return name
+	}
+	return command
+}
+
+// passFail labels a step outcome
+func passFail(success bool) string {
+	if success {
+		return "passed"
+	}
+	return "FAILED"
+}
+
 // QuickCheck performs a quick verification suitable for each iteration
 func (v *Verifier) QuickCheck(ctx context.Context) *VerificationResult {
 	// For Go: just run tests
@@ -164,6 +302,16 @@ func (v *Verifier) QuickCheck(ctx context.Context) *VerificationResult {
 	// For Python: run pytest
 	// For Rust: run cargo check
 
+	// Steps declared by the repo replace the built-in checks
+	if len(v.project.Steps) > 0 {
+		results, ok := v.Verify(ctx)
+		result := CombineResults(results)
+		if ok {
+			result.Success, result.ExitCode = true, 0 // Only advisory steps failed
+		}
+		return result
+	}
+
 	switch v.project.Type {
 	case ProjectTypeGo:
 		return v.runCommand(ctx, "go build ./... && go test ./...")
@@ -173,10 +321,13 @@ func (v *Verifier) QuickCheck(ctx context.Context) *VerificationResult {
 		}
 		return v.runCommand(ctx, "npm run build && npm test")
 	case ProjectTypePython:
-		return v.runCommand(ctx, "pytest -x --tb=short")
+		if !strings.HasSuffix(v.project.TestCommand, "pytest") {
+			return v.runCommand(ctx, v.project.TestCommand) // tox or nox
+		}
+		return v.runCommand(ctx, v.project.TestCommand+" -x --tb=short")
 	case ProjectTypeRust:
 		return v.runCommand(ctx, "cargo check && cargo test")
-	case ProjectTypeMake:
+	case ProjectTypeMake, ProjectTypeDotNet, ProjectTypeRuby, ProjectTypePHP, ProjectTypeElixir, ProjectTypeSwift, ProjectTypeCpp:
 		if v.project.TestCommand != "" {
 			return v.runCommand(ctx, v.project.TestCommand)
 		}
@@ -186,56 +337,6 @@ func (v *Verifier) QuickCheck(ctx context.Context) *VerificationResult {
 	}
 }
 
-// ScanForIssues scans the codebase for common issues
-type Issue struct {
-	Type     string
-	File     string
-	Line     int
-	Message  string
-	Priority int // 1 = critical, 2 = high, 3 = medium, 4 = low
-}
-
-// ScanForTODOs finds TODO/FIXME/HACK comments in the codebase
-func ScanForTODOs(root string) ([]Issue, error) {
-	patterns := []struct {
-		pattern  string
-		priority int
-	}{
-		{"FIXME", 2},
-		{"HACK", 2},
-		{"TODO", 3},
-		{"XXX", 3},
-	}
-
-	var issues []Issue
-
-	for _, p := range patterns {
-		cmd := exec.Command("rg", "--line-number", "--no-heading", p.pattern, root)
-		output, err := cmd.Output()
-		if err != nil {
-			// rg returns 1 for no matches
-			continue
-		}
-
-		for _, line := range strings.Split(string(output), "\n") {
-			if line == "" {
-				continue
-			}
-			parts := strings.SplitN(line, ":", 3)
-			if len(parts) >= 3 {
-				issues = append(issues, Issue{
-					Type:     p.pattern,
-					File:     parts[0],
-					Message:  strings.TrimSpace(parts[2]),
-					Priority: p.priority,
-				})
-			}
-		}
-	}
-
-	return issues, nil
-}
-
 // GetFailingTests extracts failing test names from test output
 func GetFailingTests(output string, projectType ProjectType) []string {
 	var failing []string
@@ -280,7 +381,83 @@ func GetFailingTests(output string, projectType ProjectType) []string {
 				}
 			}
 		}
+	case ProjectTypeDotNet:
+		// dotnet test: "  Failed Namespace.Class.Method [12 ms]"
+		for _, line := range strings.Split(output, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 && fields[0] == "Failed" {
+				failing = append(failing, fields[1])
+			}
+		}
+	case ProjectTypeRuby:
+		// RSpec: "rspec ./spec/foo_spec.rb:12 # Foo does x"; Minitest:
+		// "Failure:" or "Error:" followed by "FooTest#test_bar [file:line]:"
+		lines := strings.Split(output, "\n")
+		for i, line := range lines {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "rspec ./") {
+				failing = append(failing, strings.TrimSpace(strings.SplitN(line, " # ", 2)[0][len("rspec "):]))
+			}
+			if minitestHeader.MatchString(line) && i+1 < len(lines) {
+				name := strings.TrimSpace(lines[i+1])
+				name = strings.TrimSuffix(strings.SplitN(name, " [", 2)[0], ":")
+				if strings.Contains(name, "#") {
+					failing = append(failing, name)
+				}
+			}
+		}
+	case ProjectTypePHP:
+		// PHPUnit: "1) Tests\FooTest::testBar"
+		for _, line := range strings.Split(output, "\n") {
+			if m := phpunitFailure.FindStringSubmatch(line); m != nil {
+				failing = append(failing, m[1])
+			}
+		}
+	case ProjectTypeElixir:
+		// ExUnit: "  1) test adds numbers (MathTest)"
+		for _, line := range strings.Split(output, "\n") {
+			if m := exunitFailure.FindStringSubmatch(line); m != nil {
+				failing = append(failing, m[1])
+			}
+		}
+	case ProjectTypeSwift:
+		// XCTest: "Test Case 'FooTests.testBar' failed" (Linux) or
+		// "Test Case '-[Module.FooTests testBar]' failed" (macOS); Swift
+		// Testing: "✘ Test bar() failed after 0.001 seconds"
+		for _, line := range strings.Split(output, "\n") {
+			if m := xctestFailure.FindStringSubmatch(line); m != nil {
+				failing = append(failing, m[1])
+			} else if m := swiftTestingFailure.FindStringSubmatch(line); m != nil {
+				failing = append(failing, m[1])
+			}
+		}
+	case ProjectTypeCpp:
+		// CTest: "  2 - parser_test (Failed)" after "The following tests
+		// FAILED:"; Meson: " 2/3 parser_test   FAIL   0.01s   exit status 1"
+		inSummary := false
+		for _, line := range strings.Split(output, "\n") {
+			if strings.HasPrefix(line, "The following tests FAILED") {
+				inSummary = true
+				continue
+			}
+			if m := ctestFailure.FindStringSubmatch(line); inSummary && m != nil {
+				failing = append(failing, m[1])
+			} else if m := mesonFailure.FindStringSubmatch(line); m != nil {
+				failing = append(failing, m[1])
+			}
+		}
 	}
 
 	return failing
 }
+
+// Failure lines of test runners that need more than a prefix match
+var (
+	minitestHeader      = regexp.MustCompile(`^(\d+\) )?(Failure|Error):$`)
+	phpunitFailure      = regexp.MustCompile(`^\d+\) (\S+::\S+)`)
+	exunitFailure       = regexp.MustCompile(`^\s*\d+\) ((?:test|doctest) .+ \(\S+\))$`)
+	xctestFailure       = regexp.MustCompile(`Test Case '(.+)' failed`)
+	swiftTestingFailure = regexp.MustCompile(`✘ Test (.+?) failed after`)
+	ctestFailure        = regexp.MustCompile(`^\s*\d+ - (\S+) \(`)
+	mesonFailure        = regexp.MustCompile(`^\s*\d+/\d+\s+(.+?)\s+(?:FAIL|TIMEOUT|ERROR)\s`)
+)
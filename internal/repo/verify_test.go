@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -251,6 +252,25 @@ func TestVerifier_QuickCheck_Make_NoTest(t *testing.T) {
 	}
 }
 
+func TestVerifier_VirtualEnv(t *testing.T) {
+	tempDir := t.TempDir()
+	venv := filepath.Join(tempDir, ".venv")
+	os.MkdirAll(filepath.Join(venv, "bin"), 0755)
+	os.WriteFile(filepath.Join(venv, "pyvenv.cfg"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(venv, "bin", "pytest"), []byte("#!/bin/sh\necho \"venv pytest $VIRTUAL_ENV\"\n"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "requirements.txt"), []byte(""), 0644)
+
+	v := NewVerifier(DetectProject(tempDir))
+	result := v.RunTests(context.Background())
+
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Output)
+	}
+	if !strings.Contains(result.Output, "venv pytest "+venv) {
+		t.Errorf("expected pytest to run from the virtualenv, got: %s", result.Output)
+	}
+}
+
 func TestVerifier_StderrCapture(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -366,6 +386,101 @@ failures:
 	}
 }
 
+func TestGetFailingTests_Ecosystems(t *testing.T) {
+	tests := []struct {
+		name        string
+		projectType ProjectType
+		output      string
+		want        []string
+	}{
+		{
+			name:        "dotnet",
+			projectType: ProjectTypeDotNet,
+			output: `  Passed Shop.Tests.CartTests.AddsItem [3 ms]
+  Failed Shop.Tests.CartTests.RemovesItem [12 ms]
+  Error Message:
+   Assert.Equal() Failure
+Failed!  - Failed:     1, Passed:     1, Skipped:     0, Total:     2`,
+			want: []string{"Shop.Tests.CartTests.RemovesItem"},
+		},
+		{
+			name:        "rspec",
+			projectType: ProjectTypeRuby,
+			output: `Failed examples:
+
+rspec ./spec/cart_spec.rb:12 # Cart adds items
+rspec ./spec/cart_spec.rb:20 # Cart removes items`,
+			want: []string{"./spec/cart_spec.rb:12", "./spec/cart_spec.rb:20"},
+		},
+		{
+			name:        "minitest",
+			projectType: ProjectTypeRuby,
+			output: `  1) Failure:
+CartTest#test_adds_items [test/cart_test.rb:8]:
+Expected: 2
+  Actual: 1
+
+  2) Error:
+CartTest#test_removes_items:
+NoMethodError: undefined method`,
+			want: []string{"CartTest#test_adds_items", "CartTest#test_removes_items"},
+		},
+		{
+			name:        "phpunit",
+			projectType: ProjectTypePHP,
+			output: `There was 1 failure:
+
+1) Tests\CartTest::testAddsItem
+Failed asserting that 1 matches expected 2.`,
+			want: []string{"Tests\\CartTest::testAddsItem"},
+		},
+		{
+			name:        "exunit",
+			projectType: ProjectTypeElixir,
+			output: `  1) test adds items (CartTest)
+     test/cart_test.exs:8
+     Assertion with == failed`,
+			want: []string{"test adds items (CartTest)"},
+		},
+		{
+			name:        "xctest",
+			projectType: ProjectTypeSwift,
+			output: `Test Case 'CartTests.testAddsItem' started at 2024-01-01 10:00:00.000
+Test Case 'CartTests.testAddsItem' failed (0.002 seconds)
+Test Case '-[CartTests.CartTests testRemovesItem]' failed (0.001 seconds).
+✘ Test totals() failed after 0.001 seconds with 1 issue.`,
+			want: []string{"CartTests.testAddsItem", "-[CartTests.CartTests testRemovesItem]", "totals()"},
+		},
+		{
+			name:        "ctest",
+			projectType: ProjectTypeCpp,
+			output: `2/3 Test #2: parser_test ......................***Failed    0.01 sec
+
+67% tests passed, 1 tests failed out of 3
+
+The following tests FAILED:
+	  2 - parser_test (Failed)`,
+			want: []string{"parser_test"},
+		},
+		{
+			name:        "meson",
+			projectType: ProjectTypeCpp,
+			output: `1/2 lexer_test        OK              0.01s
+2/2 parser_test       FAIL            0.01s   exit status 1`,
+			want: []string{"parser_test"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failing := GetFailingTests(tt.output, tt.projectType)
+			if strings.Join(failing, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("expected %v, got %v", tt.want, failing)
+			}
+		})
+	}
+}
+
 func TestGetFailingTests_Unknown(t *testing.T) {
 	output := `Some random output`
 
@@ -375,3 +490,69 @@ func TestGetFailingTests_Unknown(t *testing.T) {
 		t.Errorf("expected 0 failing tests for unknown type, got %d", len(failing))
 	}
 }
+
+func TestProject_VerifySteps(t *testing.T) {
+	p := &Project{BuildCommand: "go build ./...", TestCommand: "go test ./...", LintCommand: "golangci-lint run"}
+
+	steps := p.VerifySteps()
+	if len(steps) != 3 || steps[0].Kind != StepLint || steps[0].Required || !steps[2].Required {
+		t.Fatalf("unexpected derived steps: %+v", steps)
+	}
+
+	p.SetSteps([]Step{
+		{Name: "unit", Kind: StepTest, Command: "task test", Required: true},
+		{Name: "e2e", Kind: StepTest, Command: "just e2e", Required: true},
+		{Name: "licenses", Command: "./scripts/licenses.sh"},
+	})
+	if p.TestCommand != "task test && just e2e" || p.BuildCommand != "" || p.LintCommand != "" {
+		t.Errorf("expected commands to follow the declared steps, got %+v", p)
+	}
+	if len(p.VerifySteps()) != 3 {
+		t.Errorf("expected declared steps to be used as given")
+	}
+	if desc := p.Steps[2].Describe(); desc != "licenses: `./scripts/licenses.sh` (advisory)" {
+		t.Errorf("unexpected description: %s", desc)
+	}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	tempDir := t.TempDir()
+	os.Mkdir(filepath.Join(tempDir, "web"), 0755)
+
+	p := &Project{Root: tempDir}
+	p.SetSteps([]Step{
+		{Name: "fmt", Kind: StepFormat, Command: "exit 1", Required: true},
+		{Name: "lint", Kind: StepLint, Command: "exit 3"},
+		{Name: "web", Kind: StepTest, Command: `test "$(basename "$PWD")" = web && test "$MODE" = ci`, Dir: "web", Env: map[string]string{"MODE": "ci"}, Required: true},
+		{Name: "slow", Command: "sleep 5", Timeout: 100 * time.Millisecond, Required: true},
+		{Name: "never", Command: "echo unreachable", Required: true},
+	})
+	v := NewVerifier(p)
+
+	results, ok := v.Verify(context.Background())
+	if ok {
+		t.Fatal("expected verification to fail on the timed out step")
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected format to be skipped and to stop after the failure, got %d results", len(results))
+	}
+	if results[0].Success || results[0].Required {
+		t.Errorf("expected advisory lint failure, got %+v", results[0])
+	}
+	if !results[1].Success {
+		t.Errorf("expected step to run in its directory with its env: %s", results[1].Output)
+	}
+	if results[2].Success || !strings.Contains(results[2].Output, "timed out") {
+		t.Errorf("expected timeout, got %+v", results[2])
+	}
+
+	combined := CombineResults(results)
+	if combined.Success || combined.ExitCode != 3 || !strings.Contains(combined.Output, "=== web:") {
+		t.Errorf("unexpected combined result: %+v", combined)
+	}
+
+	p.SetSteps(p.Steps[1:3])
+	if quick := v.QuickCheck(context.Background()); !quick.Success {
+		t.Errorf("expected quick check to ignore advisory failures: %s", quick.Output)
+	}
+}
@@ -0,0 +1,465 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// subProjectMarkers are the files that make a nested directory a sub-project.
+// Makefiles, requirements.txt and .NET project files are left out: nested
+// ones are usually parts of an enclosing build. Solution files and outermost
+// CMake and Meson files are matched by isSubProjectMarker.
+var subProjectMarkers = map[string]bool{
+	"go.mod":           true,
+	"package.json":     true,
+	"Cargo.toml":       true,
+	"pyproject.toml":   true,
+	"setup.py":         true,
+	"pom.xml":          true,
+	"build.gradle":     true,
+	"build.gradle.kts": true,
+	"Gemfile":          true,
+	"composer.json":    true,
+	"mix.exs":          true,
+	"Package.swift":    true,
+}
+
+// skipDirs are never searched for sub-projects
+var skipDirs = map[string]bool{
+	".git":         true,
+	".brewol":      true,
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"dist":         true,
+	"build":        true,
+	"builddir":     true,
+	"_build":       true,
+	"deps":         true,
+	".build":       true,
+	"bin":          true,
+	"obj":          true,
+	"__pycache__":  true,
+	".venv":        true,
+	"testdata":     true,
+	"fixtures":     true,
+}
+
+// detectSubProjects finds the projects nested below root. Node and Cargo
+// packages inside a declared workspace must be one of its members; go.work
+// modules are included even when untracked.
+func detectSubProjects(root string) []*Project {
+	dirs := make(map[string]bool)
+	for _, file := range ListFiles(root) {
+		dir := filepath.Dir(file)
+		if dir != "." && isSubProjectMarker(root, file) && !skippedPath(dir) {
+			dirs[dir] = true
+		}
+	}
+	for _, dir := range goWorkModules(root) {
+		if dir != "." && fileExists(filepath.Join(root, dir, "go.mod")) {
+			dirs[dir] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+	sort.Strings(sorted)
+
+	var projects []*Project
+	for _, dir := range sorted {
+		p := detectDir(filepath.Join(root, dir))
+		p.Dir = filepath.ToSlash(dir)
+
+		switch p.Type {
+		case ProjectTypeUnknown:
+			continue
+		case ProjectTypeNode:
+			if !workspaceMember(root, dir, nodeWorkspaceGlobs) {
+				continue
+			}
+			setNodeCommands(p, nearestPackageManager(root, dir))
+			dropMissingScripts(p)
+		case ProjectTypePython:
+			if p.PackageManager == "pip" && p.VirtualEnv == "" {
+				manager, venv := nearestPythonEnv(root, dir)
+				setPythonCommands(p, manager, venv)
+			}
+		case ProjectTypeRust:
+			if !cargoHasPackage(p.Root) {
+				continue // Virtual manifest; its members are found on their own
+			}
+			if !workspaceMember(root, dir, cargoWorkspaceGlobs) {
+				continue
+			}
+		}
+		projects = append(projects, p)
+	}
+	return projects
+}
+
+// Projects returns the root project, if it has anything to verify, followed
+// by its sub-projects. Steps declared by the repo cover the whole workspace.
+func (p *Project) Projects() []*Project {
+	if len(p.Steps) > 0 {
+		return []*Project{p}
+	}
+
+	var projects []*Project
+	if len(p.VerifySteps()) > 0 || len(p.SubProjects) == 0 {
+		projects = append(projects, p)
+	}
+	return append(projects, p.SubProjects...)
+}
+
+// Affected returns the projects containing the given workspace-relative
+// files: each file belongs to the deepest project whose directory contains
+// it. With no files, or when the repo declares its own verification steps,
+// every project is affected.
+func (p *Project) Affected(files []string) []*Project {
+	if len(files) == 0 || len(p.Steps) > 0 {
+		return p.Projects()
+	}
+
+	hit := make(map[*Project]bool)
+	for _, file := range files {
+		// Renames are reported as "old -> new"; both sides are affected
+		for _, path := range strings.Split(file, " -> ") {
+			hit[p.owner(filepath.ToSlash(strings.TrimSpace(path)))] = true
+		}
+	}
+
+	var affected []*Project
+	for _, project := range p.Projects() {
+		if hit[project] {
+			affected = append(affected, project)
+		}
+	}
+	return affected
+}
+
+// owner returns the deepest project containing a workspace-relative path
+func (p *Project) owner(path string) *Project {
+	owner := p
+	for _, sub := range p.SubProjects {
+		if (path == sub.Dir || strings.HasPrefix(path, sub.Dir+"/")) && len(sub.Dir) > len(owner.Dir) {
+			owner = sub
+		}
+	}
+	return owner
+}
+
+// TypeSummary describes the project type, listing sub-projects if any
+func (p *Project) TypeSummary() string {
+	if len(p.SubProjects) == 0 {
+		return string(p.Type)
+	}
+
+	parts := []string{string(p.Type)}
+	for _, sub := range p.SubProjects {
+		parts = append(parts, fmt.Sprintf("%s (%s)", sub.Dir, sub.Type))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// StepDescriptions describes the verification steps of every project
+func (p *Project) StepDescriptions() []string {
+	var descs []string
+	for _, project := range p.Projects() {
+		for _, step := range project.VerifySteps() {
+			desc := step.Describe()
+			if project.Dir != "" {
+				desc = project.Dir + "/ " + desc
+			}
+			descs = append(descs, desc)
+		}
+	}
+	return descs
+}
+
+// ListFiles returns the workspace-relative paths of the files in root,
+// sorted: in a git repository the tracked and untracked-but-not-ignored
+// files, otherwise every file outside hidden and vendored directories.
+// brewol's own state is never listed.
+func ListFiles(root string) []string {
+	var files []string
+	cmd := exec.Command("git", "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = root
+	if output, err := cmd.Output(); err == nil {
+		for _, file := range strings.Split(string(output), "\x00") {
+			if file != "" && !strings.HasPrefix(file, ".brewol/") {
+				files = append(files, filepath.FromSlash(file))
+			}
+		}
+		sort.Strings(files)
+		return files
+	}
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rel, err := filepath.Rel(root, path); err == nil {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+// isSubProjectMarker reports whether a workspace-relative file marks a
+// sub-project. CMakeLists.txt and meson.build only do so when no ancestor
+// has one, as nested ones are added by the enclosing build.
+func isSubProjectMarker(root, file string) bool {
+	name := filepath.Base(file)
+	switch {
+	case subProjectMarkers[name] || strings.HasSuffix(name, ".sln"):
+		return true
+	case name == "CMakeLists.txt" || name == "meson.build":
+		for dir := filepath.Dir(filepath.Dir(file)); ; dir = filepath.Dir(dir) {
+			if fileExists(filepath.Join(root, dir, name)) {
+				return false
+			}
+			if dir == "." {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// skippedPath reports whether a relative directory is inside a skipped one
+func skippedPath(dir string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(dir), "/") {
+		if skipDirs[part] {
+			return true
+		}
+	}
+	return false
+}
+
+// workspaceMember reports whether a sub-project directory is a member of
+// the closest enclosing workspace declared by one of its ancestors, as read
+// by globs. Directories outside any workspace are members.
+func workspaceMember(root, dir string, globs func(dir string) []string) bool {
+	for ancestor := filepath.Dir(dir); ; ancestor = filepath.Dir(ancestor) {
+		if members := globs(filepath.Join(root, ancestor)); len(members) > 0 {
+			rel, err := filepath.Rel(ancestor, dir)
+			return err == nil && matchesAny(members, filepath.ToSlash(rel))
+		}
+		if ancestor == "." {
+			return true
+		}
+	}
+}
+
+// goWorkModules returns the module directories listed in go.work
+func goWorkModules(root string) []string {
+	content, err := os.ReadFile(filepath.Join(root, "go.work"))
+	if err != nil {
+		return nil
+	}
+
+	var dirs []string
+	inUse := false
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(strings.SplitN(line, "//", 2)[0])
+		switch {
+		case line == "use (":
+			inUse = true
+		case inUse && line == ")":
+			inUse = false
+		case inUse && line != "":
+			dirs = append(dirs, filepath.Clean(strings.Trim(line, `"`)))
+		case strings.HasPrefix(line, "use "):
+			dirs = append(dirs, filepath.Clean(strings.Trim(strings.TrimSpace(line[4:]), `"`)))
+		}
+	}
+	return dirs
+}
+
+// nodeWorkspaceGlobs returns the package globs of an npm, yarn or pnpm
+// workspace rooted at dir
+func nodeWorkspaceGlobs(dir string) []string {
+	var globs []string
+
+	if content, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		var pkg struct {
+			Workspaces json.RawMessage `json:"workspaces"`
+		}
+		if json.Unmarshal(content, &pkg) == nil && len(pkg.Workspaces) > 0 {
+			// Either a list of globs or {"packages": [...]}
+			var list []string
+			var obj struct {
+				Packages []string `json:"packages"`
+			}
+			if json.Unmarshal(pkg.Workspaces, &list) == nil {
+				globs = append(globs, list...)
+			} else if json.Unmarshal(pkg.Workspaces, &obj) == nil {
+				globs = append(globs, obj.Packages...)
+			}
+		}
+	}
+
+	if content, err := os.ReadFile(filepath.Join(dir, "pnpm-workspace.yaml")); err == nil {
+		inPackages := false
+		for _, line := range strings.Split(string(content), "\n") {
+			trimmed := strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "packages:"):
+				inPackages = true
+			case inPackages && strings.HasPrefix(trimmed, "- "):
+				globs = append(globs, strings.Trim(strings.TrimSpace(trimmed[2:]), `"'`))
+			case trimmed != "" && !strings.HasPrefix(trimmed, "#"):
+				inPackages = false
+			}
+		}
+	}
+	return globs
+}
+
+// cargoWorkspaceGlobs returns the members of a Cargo workspace rooted at dir
+func cargoWorkspaceGlobs(dir string) []string {
+	content, err := os.ReadFile(filepath.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return nil
+	}
+
+	var globs []string
+	section, inMembers := "", false
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(strings.SplitN(line, "#", 2)[0])
+		if strings.HasPrefix(line, "[") && !inMembers {
+			section = line
+			continue
+		}
+		if section != "[workspace]" {
+			continue
+		}
+		if strings.HasPrefix(line, "members") {
+			inMembers = true
+			line = line[strings.Index(line, "=")+1:]
+		}
+		if !inMembers {
+			continue
+		}
+		for _, item := range strings.Split(strings.Trim(line, " []"), ",") {
+			if item = strings.Trim(strings.TrimSpace(item), `"'`); item != "" {
+				globs = append(globs, item)
+			}
+		}
+		if strings.Contains(line, "]") {
+			inMembers = false
+		}
+	}
+	return globs
+}
+
+// cargoHasPackage reports whether a Cargo.toml declares a package rather
+// than only a workspace
+func cargoHasPackage(dir string) bool {
+	content, err := os.ReadFile(filepath.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "[package]" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether a slash-separated directory matches one of the
+// workspace globs. A trailing /** matches any depth below its prefix.
+func matchesAny(globs []string, dir string) bool {
+	for _, glob := range globs {
+		if strings.HasPrefix(glob, "!") {
+			continue
+		}
+		glob = strings.TrimSuffix(strings.TrimPrefix(glob, "./"), "/")
+		if prefix, ok := strings.CutSuffix(glob, "/**"); ok {
+			if strings.HasPrefix(dir, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := filepath.Match(glob, dir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// nearestPackageManager returns the package manager of the closest
+// lockfile between a sub-project and the workspace root
+func nearestPackageManager(root, dir string) string {
+	for {
+		path := filepath.Join(root, dir)
+		for _, lock := range []string{"pnpm-lock.yaml", "yarn.lock", "package-lock.json"} {
+			if fileExists(filepath.Join(path, lock)) {
+				return nodePackageManager(path)
+			}
+		}
+		if dir == "." || dir == "" {
+			return "npm"
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// nearestPythonEnv returns the package manager and virtualenv of the
+// closest ancestor of a Python sub-project that has either, such as the
+// root of a uv workspace
+func nearestPythonEnv(root, dir string) (string, string) {
+	for dir != "." && dir != "" {
+		dir = filepath.Dir(dir)
+		path := filepath.Join(root, dir)
+		manager := pythonPackageManager(path)
+		if venv := findVirtualEnv(path, manager); manager != "pip" || venv != "" {
+			return manager, venv
+		}
+	}
+	return "pip", ""
+}
+
+// dropMissingScripts clears Node.js commands whose package.json script is
+// not defined, so a package without a lint script isn't failed for it
+func dropMissingScripts(p *Project) {
+	content, err := os.ReadFile(filepath.Join(p.Root, "package.json"))
+	if err != nil {
+		return
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if json.Unmarshal(content, &pkg) != nil {
+		return
+	}
+
+	for script, command := range map[string]*string{
+		"test":   &p.TestCommand,
+		"build":  &p.BuildCommand,
+		"lint":   &p.LintCommand,
+		"format": &p.FormatCommand,
+	} {
+		if _, ok := pkg.Scripts[script]; !ok {
+			*command = ""
+		}
+	}
+}
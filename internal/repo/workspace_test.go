@@ -0,0 +1,168 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files relative to root
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestDetectProject_Monorepo(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                           "module example.com/app\n",
+		"go.work":                          "go 1.22\n\nuse (\n\t.\n\t./tools/gen\n)\n",
+		"tools/gen/go.mod":                 "module example.com/gen\n",
+		"web/package.json":                 `{"name": "web", "workspaces": ["packages/*"], "scripts": {"test": "vitest", "build": "vite build"}}`,
+		"web/pnpm-lock.yaml":               "",
+		"web/packages/ui/package.json":     `{"name": "ui", "scripts": {"test": "vitest"}}`,
+		"crates/Cargo.toml":                "[workspace]\nmembers = [\n  \"core\",\n]\n",
+		"crates/core/Cargo.toml":           "[package]\nname = \"core\"\n",
+		"scripts/py/pyproject.toml":        "[project]\nname = \"tool\"\n",
+		"web/node_modules/x/package.json":  `{"name": "x"}`,
+		"internal/testdata/mod/go.mod":     "module fixture\n",
+		"docs/Makefile":                    "test:\n\techo\n",
+		"crates/core/benches/x/Cargo.toml": "[package]\nname = \"bench\"\n",
+		"services/billing/Billing.sln":     "",
+		"services/billing/src/Api.csproj":  "",
+		"native/CMakeLists.txt":            "project(codec)\n",
+		"native/src/CMakeLists.txt":        "add_library(codec codec.c)\n",
+	})
+
+	p := DetectProject(root)
+	if p.Type != ProjectTypeGo {
+		t.Fatalf("expected root Go project, got %s", p.Type)
+	}
+
+	var dirs []string
+	for _, sub := range p.SubProjects {
+		dirs = append(dirs, sub.Dir+":"+string(sub.Type))
+	}
+	expected := "crates/core:rust native:cpp scripts/py:python services/billing:dotnet tools/gen:go web:node web/packages/ui:node"
+	if strings.Join(dirs, " ") != expected {
+		t.Fatalf("expected sub-projects %q, got %q", expected, strings.Join(dirs, " "))
+	}
+
+	ui := p.SubProjects[6]
+	if ui.PackageManager != "pnpm" || ui.TestCommand != "pnpm test" || ui.BuildCommand != "" || ui.LintCommand != "" {
+		t.Errorf("expected ui to inherit pnpm and only run defined scripts: %+v", ui)
+	}
+	if ui.Root != filepath.Join(root, "web", "packages", "ui") {
+		t.Errorf("unexpected sub-project root: %s", ui.Root)
+	}
+	if summary := p.TypeSummary(); !strings.HasPrefix(summary, "go, crates/core (rust),") {
+		t.Errorf("unexpected type summary: %s", summary)
+	}
+}
+
+func TestProject_Affected(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":            "module example.com/app\n",
+		"web/package.json":  `{"name": "web", "scripts": {"test": "exit 0"}}`,
+		"web/e2e/setup.py":  "",
+		"docs/guide/inx.md": "",
+	})
+	p := DetectProject(root)
+
+	names := func(projects []*Project) string {
+		var dirs []string
+		for _, project := range projects {
+			dirs = append(dirs, "/"+project.Dir)
+		}
+		return strings.Join(dirs, " ")
+	}
+
+	for _, tc := range []struct {
+		files    []string
+		expected string
+	}{
+		{nil, "/ /web /web/e2e"},
+		{[]string{"web/src/app.ts"}, "/web"},
+		{[]string{"web/e2e/test_login.py", "main.go"}, "/ /web/e2e"},
+		{[]string{"webapp/x.go"}, "/"},
+		{[]string{"cmd/a.go -> web/a.ts"}, "/ /web"},
+	} {
+		if got := names(p.Affected(tc.files)); got != tc.expected {
+			t.Errorf("Affected(%v) = %q, want %q", tc.files, got, tc.expected)
+		}
+	}
+
+	p.SetSteps([]Step{{Name: "all", Command: "task check", Required: true}})
+	if got := names(p.Affected([]string{"web/x.ts"})); got != "/" {
+		t.Errorf("expected declared steps to cover the whole repo, got %q", got)
+	}
+}
+
+func TestVerifier_VerifyFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"Makefile":         "test:\n\techo root\n",
+		"web/package.json": `{"name": "web", "scripts": {"test": "x"}}`,
+	})
+	p := DetectProject(root)
+	p.SubProjects[0].TestCommand = "pwd"
+
+	results, ok := NewVerifier(p).VerifyFiles(context.Background(), []string{"web/index.js"})
+	if !ok || len(results) != 1 {
+		t.Fatalf("expected only the web project to be verified, got %d results", len(results))
+	}
+	if results[0].Name != "web: test" || strings.TrimSpace(results[0].Output) != filepath.Join(root, "web") {
+		t.Errorf("expected web test to run in its own root, got %q in %q", results[0].Name, results[0].Output)
+	}
+}
+
+func TestMatchesAny(t *testing.T) {
+	globs := []string{"./packages/*", "apps/**", "!apps/legacy", "tools/cli/"}
+	for dir, want := range map[string]bool{
+		"packages/ui":     true,
+		"packages/ui/sub": false,
+		"apps/web/admin":  true,
+		"tools/cli":       true,
+		"other":           false,
+	} {
+		if got := matchesAny(globs, dir); got != want {
+			t.Errorf("matchesAny(%q) = %v, want %v", dir, got, want)
+		}
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"café/menu.go":       "package menu\n",
+		"with space.go":      "package main\n",
+		".brewol/state.json": "{}",
+		"ignored.log":        "x",
+		".gitignore":         "*.log\n",
+	})
+
+	// Without git, hidden directories are skipped
+	if got := strings.Join(ListFiles(root), "|"); got != strings.Join([]string{".gitignore", filepath.Join("café", "menu.go"), "ignored.log", "with space.go"}, "|") {
+		t.Errorf("unexpected files without git: %s", got)
+	}
+
+	// With git, paths git would quote come back as they are
+	if out, err := exec.Command("git", "-C", root, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+	if got := strings.Join(ListFiles(root), "|"); got != strings.Join([]string{".gitignore", filepath.Join("café", "menu.go"), "with space.go"}, "|") {
+		t.Errorf("unexpected files with git: %s", got)
+	}
+}
@@ -0,0 +1,164 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/ai/brewol/internal/display"
+)
+
+// htmlStyle is inlined so the report is a single self-contained file
+const htmlStyle = `
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 1100px; padding: 0 1em; color: #1f2328; }
+h1 { font-size: 1.6em; border-bottom: 1px solid #d0d7de; padding-bottom: .3em; }
+h2 { font-size: 1.3em; margin-top: 2em; border-bottom: 1px solid #d0d7de; padding-bottom: .3em; }
+h3 { font-size: 1.05em; margin-top: 1.5em; }
+table { border-collapse: collapse; width: 100%; margin: .5em 0; font-size: .9em; }
+th, td { border: 1px solid #d0d7de; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+table.overview th { width: 10em; }
+code, pre { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: .85em; }
+pre { background: #f6f8fa; padding: .75em; overflow-x: auto; border-radius: 6px; }
+details { margin: .25em 0; }
+summary { cursor: pointer; }
+.ok { color: #1a7f37; font-weight: 600; }
+.fail { color: #cf222e; font-weight: 600; }
+.muted { color: #656d76; }
+.add { color: #1a7f37; background: #dafbe1; display: block; }
+.del { color: #cf222e; background: #ffebe9; display: block; }
+.hunk { color: #8250df; display: block; }
+.file { font-weight: 600; display: block; }
+`
+
+// HTML renders the report as a standalone HTML page
+func (r *Report) HTML() string {
+	var b strings.Builder
+
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n", esc(r.Title()), htmlStyle)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", esc(r.Title()))
+
+	b.WriteString("<table class=\"overview\">\n")
+	for _, row := range r.overview() {
+		fmt.Fprintf(&b, "<tr><th>%s</th><td>%s</td></tr>\n", esc(row[0]), esc(row[1]))
+	}
+	b.WriteString("</table>\n")
+
+	b.WriteString("<h2>Timeline</h2>\n")
+	if len(r.Cycles) == 0 {
+		b.WriteString("<p class=\"muted\">No cycles recorded.</p>\n")
+	} else {
+		b.WriteString("<table>\n<tr><th>Cycle</th><th>Offset</th><th>Duration</th><th>Tool calls</th><th>Failed</th><th>Requests</th><th>Prompt tokens</th><th>Output tokens</th></tr>\n")
+		for _, c := range r.Cycles {
+			failed := fmt.Sprintf("%d", c.Failures())
+			if c.Failures() > 0 {
+				failed = `<span class="fail">` + failed + `</span>`
+			}
+			fmt.Fprintf(&b, "<tr><td><a href=\"#cycle-%d\">%s</a></td><td>%s</td><td>%s</td><td>%d</td><td>%s</td><td>%d</td><td>%d</td><td>%d</td></tr>\n",
+				c.Number, cycleLabel(c.Number), r.offset(c.Start), display.Duration(c.End.Sub(c.Start)),
+				len(c.ToolCalls), failed, c.Requests, c.PromptTokens, c.EvalTokens)
+		}
+		b.WriteString("</table>\n")
+	}
+
+	b.WriteString("<h2>Tool Calls</h2>\n")
+	calls := 0
+	for _, c := range r.Cycles {
+		if len(c.ToolCalls) == 0 {
+			continue
+		}
+		calls += len(c.ToolCalls)
+		fmt.Fprintf(&b, "<h3 id=\"cycle-%d\">Cycle %s</h3>\n", c.Number, cycleLabel(c.Number))
+		if c.Goal != "" {
+			fmt.Fprintf(&b, "<p class=\"muted\">%s</p>\n", esc(c.Goal))
+		}
+		for _, call := range c.ToolCalls {
+			status := fmt.Sprintf(`<span class="ok">exit %d</span>`, call.ExitCode)
+			if call.Failed() {
+				status = fmt.Sprintf(`<span class="fail">exit %d</span>`, call.ExitCode)
+			}
+			if call.Error != "" {
+				status += " " + esc(call.Error)
+			}
+			fmt.Fprintf(&b, "<details><summary><code>%s</code> %s <code>%s</code> %s <span class=\"muted\">%.2fs</span></summary>\n",
+				r.offset(call.Time), esc(call.Name), esc(display.OneLine(call.Args)), status, call.Duration)
+			fmt.Fprintf(&b, "<pre>%s</pre>\n</details>\n", esc(display.OrDash(call.Output)))
+		}
+	}
+	if calls == 0 {
+		b.WriteString("<p class=\"muted\">No tool calls recorded.</p>\n")
+	}
+
+	b.WriteString("<h2>Verification</h2>\n")
+	if len(r.Verifications) == 0 {
+		b.WriteString("<p class=\"muted\">No verification runs recorded.</p>\n")
+	}
+	for _, v := range r.Verifications {
+		result := `<span class="ok">PASSED</span>`
+		if !v.Success {
+			result = `<span class="fail">FAILED</span>`
+		}
+		fmt.Fprintf(&b, "<details><summary><code>%s</code> cycle %s %s <code>%s</code> %s <span class=\"muted\">exit %d, %.2fs</span></summary>\n",
+			r.offset(v.Time), cycleLabel(v.Cycle), esc(v.Kind), esc(v.Command), result, v.ExitCode, v.Duration)
+		fmt.Fprintf(&b, "<pre>%s</pre>\n</details>\n", esc(display.OrDash(v.Output)))
+	}
+
+	b.WriteString("<h2>Checkpoints</h2>\n")
+	if len(r.Checkpoints) == 0 {
+		b.WriteString("<p class=\"muted\">No checkpoints recorded.</p>\n")
+	}
+	for _, cp := range r.Checkpoints {
+		fmt.Fprintf(&b, "<h3><code>%s</code> %s</h3>\n<p class=\"muted\">Cycle %s, %s</p>\n",
+			esc(display.OrDash(display.ShortCommit(cp.Commit))), esc(display.OneLine(cp.Message)), cycleLabel(cp.Cycle), r.offset(cp.Time))
+		if cp.Diff == "" {
+			b.WriteString("<p class=\"muted\">No diff saved.</p>\n")
+		} else {
+			fmt.Fprintf(&b, "<pre>%s</pre>\n", htmlDiff(cp.Diff))
+		}
+	}
+
+	b.WriteString("<h2>Compactions</h2>\n")
+	if len(r.Compactions) == 0 {
+		b.WriteString("<p class=\"muted\">No compaction events recorded.</p>\n")
+	} else {
+		b.WriteString("<table>\n<tr><th>Offset</th><th>Cycle</th><th>Reason</th><th>Tokens</th><th>Compacted</th></tr>\n")
+		for _, c := range r.Compactions {
+			fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%d → %d</td><td>%s</td></tr>\n",
+				r.offset(c.Time), cycleLabel(c.Cycle), esc(c.Reason), c.TokensBefore, c.TokensAfter, esc(c.Items))
+		}
+		b.WriteString("</table>\n")
+	}
+
+	fmt.Fprintf(&b, "<p class=\"muted\">Generated by brewol on %s</p>\n</body>\n</html>\n", formatTime(r.GeneratedAt))
+	return b.String()
+}
+
+// htmlDiff escapes a unified diff and marks up its lines for colouring
+func htmlDiff(diff string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		class := ""
+		switch {
+		case strings.HasPrefix(line, "diff --git"), strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			class = "file"
+		case strings.HasPrefix(line, "@@"):
+			class = "hunk"
+		case strings.HasPrefix(line, "+"):
+			class = "add"
+		case strings.HasPrefix(line, "-"):
+			class = "del"
+		}
+		if class == "" {
+			b.WriteString(esc(line) + "\n")
+		} else {
+			fmt.Fprintf(&b, "<span class=\"%s\">%s</span>", class, esc(line))
+		}
+	}
+	return b.String()
+}
+
+// esc escapes text for HTML
+func esc(s string) string {
+	return html.EscapeString(s)
+}
@@ -0,0 +1,182 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ai/brewol/internal/display"
+)
+
+// Markdown renders the report as GitHub-flavoured Markdown
+func (r *Report) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", r.Title())
+	b.WriteString("| | |\n|---|---|\n")
+	for _, row := range r.overview() {
+		fmt.Fprintf(&b, "| %s | %s |\n", row[0], mdCell(row[1]))
+	}
+
+	b.WriteString("\n## Timeline\n\n")
+	if len(r.Cycles) == 0 {
+		b.WriteString("No cycles recorded.\n")
+	} else {
+		b.WriteString("| Cycle | Offset | Duration | Tool calls | Failed | Requests | Prompt tokens | Output tokens |\n")
+		b.WriteString("|---|---|---|---|---|---|---|---|\n")
+		for _, c := range r.Cycles {
+			fmt.Fprintf(&b, "| %s | %s | %s | %d | %d | %d | %d | %d |\n",
+				cycleLabel(c.Number), r.offset(c.Start), display.Duration(c.End.Sub(c.Start)),
+				len(c.ToolCalls), c.Failures(), c.Requests, c.PromptTokens, c.EvalTokens)
+		}
+	}
+
+	b.WriteString("\n## Tool Calls\n")
+	calls := 0
+	for _, c := range r.Cycles {
+		if len(c.ToolCalls) == 0 {
+			continue
+		}
+		calls += len(c.ToolCalls)
+		fmt.Fprintf(&b, "\n### Cycle %s\n\n", cycleLabel(c.Number))
+		b.WriteString("| Offset | Tool | Arguments | Exit | Duration |\n|---|---|---|---|---|\n")
+		for _, call := range c.ToolCalls {
+			exit := fmt.Sprintf("%d", call.ExitCode)
+			if call.Error != "" {
+				exit += " (" + call.Error + ")"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %.2fs |\n",
+				r.offset(call.Time), call.Name, mdCode(call.Args), mdCell(exit), call.Duration)
+		}
+		for _, call := range c.ToolCalls {
+			if call.Failed() && strings.TrimSpace(call.Output) != "" {
+				fmt.Fprintf(&b, "\n<details><summary>%s %s (exit %d)</summary>\n\n%s\n</details>\n",
+					esc(call.Name), esc(display.OneLine(call.Args)), call.ExitCode, mdFence("", call.Output))
+			}
+		}
+	}
+	if calls == 0 {
+		b.WriteString("\nNo tool calls recorded.\n")
+	}
+
+	b.WriteString("\n## Verification\n\n")
+	if len(r.Verifications) == 0 {
+		b.WriteString("No verification runs recorded.\n")
+	} else {
+		b.WriteString("| Offset | Cycle | Kind | Command | Result | Exit | Duration |\n|---|---|---|---|---|---|---|\n")
+		for _, v := range r.Verifications {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %d | %.2fs |\n",
+				r.offset(v.Time), cycleLabel(v.Cycle), v.Kind, mdCode(v.Command), passFail(v.Success), v.ExitCode, v.Duration)
+		}
+		for _, v := range r.Verifications {
+			if !v.Success && strings.TrimSpace(v.Output) != "" {
+				fmt.Fprintf(&b, "\n<details><summary>%s: %s</summary>\n\n%s\n</details>\n",
+					esc(v.Kind), esc(display.OneLine(v.Command)), mdFence("", v.Output))
+			}
+		}
+	}
+
+	b.WriteString("\n## Checkpoints\n")
+	if len(r.Checkpoints) == 0 {
+		b.WriteString("\nNo checkpoints recorded.\n")
+	}
+	for _, cp := range r.Checkpoints {
+		fmt.Fprintf(&b, "\n### %s %s\n\nCycle %s, %s\n\n", display.OrDash(display.ShortCommit(cp.Commit)), display.OneLine(cp.Message), cycleLabel(cp.Cycle), r.offset(cp.Time))
+		if cp.Diff == "" {
+			b.WriteString("No diff saved.\n")
+		} else {
+			b.WriteString(mdFence("diff", cp.Diff) + "\n")
+		}
+	}
+
+	b.WriteString("\n## Compactions\n\n")
+	if len(r.Compactions) == 0 {
+		b.WriteString("No compaction events recorded.\n")
+	} else {
+		b.WriteString("| Offset | Cycle | Reason | Tokens | Compacted |\n|---|---|---|---|---|\n")
+		for _, c := range r.Compactions {
+			fmt.Fprintf(&b, "| %s | %s | %s | %d → %d | %s |\n",
+				r.offset(c.Time), cycleLabel(c.Cycle), c.Reason, c.TokensBefore, c.TokensAfter, mdCell(c.Items))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n---\nGenerated by brewol on %s\n", formatTime(r.GeneratedAt))
+	return b.String()
+}
+
+// overview returns the label/value rows of the report header
+func (r *Report) overview() [][2]string {
+	rows := [][2]string{
+		{"Goal", display.OrDash(r.Goal)},
+		{"Model", display.OrDash(r.Model)},
+		{"Branch", display.OrDash(r.Branch)},
+		{"Started", formatTime(r.StartTime)},
+		{"Duration", display.Duration(r.Duration)},
+		{"Outcome", r.Outcome},
+		{"Cycles", fmt.Sprintf("%d", r.SessionSummary.Cycles)},
+		{"Checkpoints", fmt.Sprintf("%d", len(r.Checkpoints))},
+		{"Tool calls", fmt.Sprintf("%d (%d failed)", r.ToolCalls, r.Failures)},
+		{"Tokens", fmt.Sprintf("%d prompt, %d output", r.PromptTokens, r.EvalTokens)},
+	}
+	if r.Usage != nil {
+		rows = append(rows,
+			[2]string{"Throughput", fmt.Sprintf("%.1f tok/s", r.Usage.TokensPerSec())},
+			[2]string{"Wall time", fmt.Sprintf("model %s, tools %s, verification %s",
+				formatSeconds(r.Usage.ModelSeconds), formatSeconds(r.Usage.ToolSeconds), formatSeconds(r.Usage.VerifySeconds))},
+		)
+	}
+	if r.ResumedFrom != "" {
+		rows = append(rows, [2]string{"Resumed from", r.ResumedFrom})
+	}
+	return rows
+}
+
+// offset renders a timestamp relative to the session start
+func (r *Report) offset(t time.Time) string {
+	if r.StartTime.IsZero() {
+		return t.Format("15:04:05")
+	}
+	return "+" + display.Duration(t.Sub(r.StartTime))
+}
+
+// passFail renders a verification outcome
+func passFail(ok bool) string {
+	if ok {
+		return "PASSED"
+	}
+	return "FAILED"
+}
+
+// mdCellReplacer escapes table separators and stops tags in log text from
+// being rendered as HTML
+var mdCellReplacer = strings.NewReplacer("|", `\|`, "<", "&lt;")
+
+// mdCell makes text safe for a single Markdown table cell
+func mdCell(s string) string {
+	return mdCellReplacer.Replace(display.OneLine(s))
+}
+
+// mdCode renders text as inline code inside a table cell
+func mdCode(s string) string {
+	s = strings.ReplaceAll(display.OneLine(s), "|", `\|`)
+	if s == "" {
+		return "-"
+	}
+	if !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	fence := "``"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	return fence + " " + s + " " + fence
+}
+
+// mdFence wraps text in a code fence longer than any backtick run inside it
+func mdFence(lang, s string) string {
+	fence := "```"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimRight(s, "\n") + "\n" + fence
+}
@@ -0,0 +1,257 @@
+// Package report renders self-contained HTML and Markdown reports of past
+// sessions from their logs.
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ai/brewol/internal/display"
+	"github.com/ai/brewol/internal/logs"
+)
+
+// maxOutputChars caps tool and verification output included in a report
+const maxOutputChars = 4000
+
+// Report is everything known about a session, grouped for rendering
+type Report struct {
+	logs.SessionSummary
+	Cycles        []Cycle
+	Checkpoints   []Checkpoint
+	Verifications []Verification
+	Compactions   []Compaction
+	PromptTokens  int
+	EvalTokens    int
+	Usage         *logs.Usage // Session accounting, if recorded
+	GeneratedAt   time.Time
+}
+
+// Cycle is one autonomy cycle. Cycle 0 holds activity logged before the
+// first cycle started (or all activity for sessions without cycle markers).
+type Cycle struct {
+	Number       int
+	Goal         string
+	Start        time.Time
+	End          time.Time
+	ToolCalls    []ToolCall
+	Requests     int
+	PromptTokens int
+	EvalTokens   int
+}
+
+// Failures returns the number of failed tool calls in the cycle
+func (c Cycle) Failures() int {
+	n := 0
+	for _, call := range c.ToolCalls {
+		if call.Failed() {
+			n++
+		}
+	}
+	return n
+}
+
+// ToolCall is a single tool execution
+type ToolCall struct {
+	Time     time.Time
+	Cycle    int
+	Name     string
+	Args     string
+	Output   string
+	ExitCode int
+	Duration float64 // Seconds
+	Error    string
+}
+
+// Failed reports whether the call errored or exited non-zero
+func (t ToolCall) Failed() bool {
+	return t.Error != "" || t.ExitCode != 0
+}
+
+// Checkpoint is a checkpoint commit and its diff
+type Checkpoint struct {
+	Time    time.Time
+	Cycle   int
+	Commit  string
+	Message string
+	Diff    string // Empty when no patch was saved
+}
+
+// Verification is the result of a build, test or other verification command
+type Verification struct {
+	Time     time.Time
+	Cycle    int
+	Kind     string
+	Command  string
+	Output   string
+	Success  bool
+	ExitCode int
+	Duration float64 // Seconds
+}
+
+// Compaction is a context compaction event
+type Compaction struct {
+	Time         time.Time
+	Cycle        int
+	Reason       string
+	Items        string
+	TokensBefore int
+	TokensAfter  int
+}
+
+// Build reads a session directory and assembles its report
+func Build(sessionDir string) (*Report, error) {
+	summary, err := logs.SummarizeSession(sessionDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	timeline, err := logs.ReadTimeline(sessionDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	patches, err := logs.ReadPatches(sessionDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patches: %w", err)
+	}
+
+	r := &Report{SessionSummary: *summary, GeneratedAt: time.Now()}
+	if metrics, err := logs.ReadMetrics(sessionDir); err == nil {
+		r.Usage = &metrics.Session
+	}
+	cycle := &Cycle{Start: summary.StartTime, End: summary.StartTime}
+
+	for _, t := range timeline {
+		if t.Type == "cycle" && t.Source == logs.SourceTranscript {
+			r.addCycle(cycle)
+			cycle = &Cycle{Number: metaInt(t.Metadata, "cycle_id") + 1, Goal: t.Content, Start: t.Timestamp, End: t.Timestamp}
+			continue
+		}
+
+		switch {
+		case t.Source == logs.SourceTools:
+			args, _ := t.Metadata["args"].(string)
+			errText, _ := t.Metadata["error"].(string)
+			cycle.ToolCalls = append(cycle.ToolCalls, ToolCall{
+				Time:     t.Timestamp,
+				Cycle:    cycle.Number,
+				Name:     strings.TrimPrefix(t.Type, "tool:"),
+				Args:     args,
+				Output:   clip(t.Content),
+				ExitCode: t.ExitCode(),
+				Duration: t.Duration(),
+				Error:    errText,
+			})
+		case t.Type == "usage":
+			prompt, eval := metaInt(t.Metadata, "prompt_tokens"), metaInt(t.Metadata, "eval_tokens")
+			cycle.Requests++
+			cycle.PromptTokens += prompt
+			cycle.EvalTokens += eval
+			r.PromptTokens += prompt
+			r.EvalTokens += eval
+		case t.Type == "checkpoint":
+			commit, _ := t.Metadata["commit"].(string)
+			r.Checkpoints = append(r.Checkpoints, Checkpoint{
+				Time:    t.Timestamp,
+				Cycle:   cycle.Number,
+				Commit:  commit,
+				Message: t.Content,
+				Diff:    checkpointDiff(patches, commit),
+			})
+		case t.Type == "verification":
+			kind, _ := t.Metadata["kind"].(string)
+			command, _ := t.Metadata["command"].(string)
+			success, _ := t.Metadata["success"].(bool)
+			r.Verifications = append(r.Verifications, Verification{
+				Time:     t.Timestamp,
+				Cycle:    cycle.Number,
+				Kind:     kind,
+				Command:  command,
+				Output:   clip(t.Content),
+				Success:  success,
+				ExitCode: t.ExitCode(),
+				Duration: t.Duration(),
+			})
+		case t.Type == "compaction":
+			reason, _ := t.Metadata["reason"].(string)
+			r.Compactions = append(r.Compactions, Compaction{
+				Time:         t.Timestamp,
+				Cycle:        cycle.Number,
+				Reason:       reason,
+				Items:        t.Content,
+				TokensBefore: metaInt(t.Metadata, "tokens_before"),
+				TokensAfter:  metaInt(t.Metadata, "tokens_after"),
+			})
+		}
+		if t.Timestamp.After(cycle.End) {
+			cycle.End = t.Timestamp
+		}
+	}
+	r.addCycle(cycle)
+
+	return r, nil
+}
+
+// addCycle appends a finished cycle, skipping an empty preamble
+func (r *Report) addCycle(c *Cycle) {
+	if c.Number == 0 && len(c.ToolCalls) == 0 && c.Requests == 0 {
+		return
+	}
+	if n := len(r.Cycles); n > 0 && r.Cycles[n-1].End.Before(c.Start) {
+		r.Cycles[n-1].End = c.Start
+	}
+	r.Cycles = append(r.Cycles, *c)
+}
+
+// Title returns the report heading
+func (r *Report) Title() string {
+	return "Session report: " + r.ID
+}
+
+// checkpointDiff returns the saved patch of a checkpoint commit
+func checkpointDiff(patches []logs.Patch, commit string) string {
+	if commit == "" {
+		return ""
+	}
+	for _, p := range patches {
+		if p.Name == "checkpoint-"+commit {
+			return p.Content
+		}
+	}
+	return ""
+}
+
+// metaInt reads a numeric metadata field decoded from JSON
+func metaInt(metadata map[string]interface{}, key string) int {
+	v, _ := metadata[key].(float64)
+	return int(v)
+}
+
+// clip truncates long output, keeping the end where errors usually are
+func clip(s string) string {
+	if len(s) <= maxOutputChars {
+		return s
+	}
+	tail := strings.ToValidUTF8(s[len(s)-maxOutputChars:], "")
+	return fmt.Sprintf("[... %d bytes omitted ...]\n", len(s)-len(tail)) + tail
+}
+
+// cycleLabel names a cycle for display
+func cycleLabel(n int) string {
+	if n == 0 {
+		return "start"
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// formatSeconds renders a number of seconds as h:mm:ss
+func formatSeconds(s float64) string {
+	return display.Duration(time.Duration(s * float64(time.Second)))
+}
+
+// formatTime renders a timestamp, or a dash when unknown
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
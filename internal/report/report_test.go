@@ -0,0 +1,108 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ai/brewol/internal/logs"
+)
+
+// writeLog writes entries as JSONL into a session directory
+func writeLog(t *testing.T, dir, name string, entries ...logs.Entry) {
+	t.Helper()
+	var b strings.Builder
+	for _, e := range entries {
+		data, _ := json.Marshal(e)
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(b.String()), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func newTestSession(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "20240501-100000")
+	os.MkdirAll(filepath.Join(dir, "patches"), 0755)
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+
+	meta, _ := json.Marshal(logs.Meta{ID: "20240501-100000", Goal: "Fix <parser>", Model: "qwen", StartTime: base, EndTime: base.Add(3 * time.Minute), Cycles: 2, Outcome: logs.OutcomeStopped})
+	os.WriteFile(filepath.Join(dir, "meta.json"), meta, 0644)
+
+	writeLog(t, dir, "transcript.jsonl",
+		logs.Entry{Timestamp: base, Type: "objective", Content: "Fix <parser>"},
+		logs.Entry{Timestamp: base.Add(time.Second), Type: "cycle", Content: "Fix <parser>", Metadata: map[string]interface{}{"cycle_id": 0}},
+		logs.Entry{Timestamp: base.Add(5 * time.Second), Type: "usage", Metadata: map[string]interface{}{"cycle_id": 0, "prompt_tokens": 1200, "eval_tokens": 300}},
+		logs.Entry{Timestamp: base.Add(20 * time.Second), Type: "verification", Content: "FAIL: TestParse", Metadata: map[string]interface{}{"kind": "test", "command": "go test ./...", "success": false, "exit_code": 1, "duration": 1.5}},
+		logs.Entry{Timestamp: base.Add(time.Minute), Type: "cycle", Content: "Fix <parser>", Metadata: map[string]interface{}{"cycle_id": 1}},
+		logs.Entry{Timestamp: base.Add(65 * time.Second), Type: "compaction", Content: "transcript(4 msgs)", Metadata: map[string]interface{}{"reason": "pre_send", "tokens_before": 9000, "tokens_after": 4000}},
+		logs.Entry{Timestamp: base.Add(70 * time.Second), Type: "usage", Metadata: map[string]interface{}{"cycle_id": 1, "prompt_tokens": 800, "eval_tokens": 100}},
+		logs.Entry{Timestamp: base.Add(2 * time.Minute), Type: "checkpoint", Content: "Parser fixed", Metadata: map[string]interface{}{"commit": "abc123def4567"}},
+	)
+	writeLog(t, dir, "tools.jsonl",
+		logs.Entry{Timestamp: base.Add(10 * time.Second), Type: "tool:shell", Content: "FAIL", Metadata: map[string]interface{}{"args": "go test ./...", "exit_code": 1, "duration": 2.0}},
+		logs.Entry{Timestamp: base.Add(80 * time.Second), Type: "tool:shell", Content: "ok", Metadata: map[string]interface{}{"args": "go test ./...", "exit_code": 0, "duration": 1.0}},
+	)
+	os.WriteFile(filepath.Join(dir, "patches", "checkpoint-abc123def4567-1714557720000000000.patch"),
+		[]byte("diff --git a/p.go b/p.go\n@@ -1 +1 @@\n-old\n+new\n"), 0644)
+	return dir
+}
+
+func TestBuild(t *testing.T) {
+	r, err := Build(newTestSession(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(r.Cycles) != 2 || r.Cycles[0].Number != 1 || r.Cycles[1].Number != 2 {
+		t.Fatalf("expected cycles 1 and 2, got %+v", r.Cycles)
+	}
+	if c := r.Cycles[0]; len(c.ToolCalls) != 1 || c.Failures() != 1 || c.PromptTokens != 1200 || c.End.Sub(c.Start) != 59*time.Second {
+		t.Errorf("unexpected first cycle: %+v", c)
+	}
+	if r.PromptTokens != 2000 || r.EvalTokens != 400 {
+		t.Errorf("expected token totals 2000/400, got %d/%d", r.PromptTokens, r.EvalTokens)
+	}
+	if len(r.Verifications) != 1 || r.Verifications[0].Success || r.Verifications[0].Cycle != 1 {
+		t.Errorf("unexpected verifications: %+v", r.Verifications)
+	}
+	if len(r.Compactions) != 1 || r.Compactions[0].TokensAfter != 4000 || r.Compactions[0].Cycle != 2 {
+		t.Errorf("unexpected compactions: %+v", r.Compactions)
+	}
+	if len(r.Checkpoints) != 1 || !strings.Contains(r.Checkpoints[0].Diff, "+new") {
+		t.Errorf("expected checkpoint with its saved diff, got %+v", r.Checkpoints)
+	}
+}
+
+func TestRender(t *testing.T) {
+	r, err := Build(newTestSession(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	md := r.Markdown()
+	for _, want := range []string{"# Session report: 20240501-100000", "| Goal | Fix &lt;parser> |", "| 1 | +0:00:01 | 0:00:59 | 1 | 1 | 1 | 1200 | 300 |", "```diff\n", "| test | `go test ./...` | FAILED |", "9000 → 4000"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected markdown to contain %q:\n%s", want, md)
+		}
+	}
+
+	page := r.HTML()
+	if strings.Contains(page, "<parser>") || !strings.Contains(page, "Fix &lt;parser&gt;") {
+		t.Error("expected HTML to escape log content")
+	}
+	if !strings.Contains(page, `<span class="add">+new</span>`) || !strings.Contains(page, "<style>") {
+		t.Error("expected a self-contained page with a coloured diff")
+	}
+}
+
+func TestMdFence(t *testing.T) {
+	if got := mdFence("", "a ``` b"); !strings.HasPrefix(got, "````\n") || !strings.HasSuffix(got, "\n````") {
+		t.Errorf("expected a longer fence, got %q", got)
+	}
+}
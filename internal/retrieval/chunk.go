@@ -0,0 +1,70 @@
+// Package retrieval provides embedding-based code retrieval over the workspace.
+package retrieval
+
+import (
+	"strings"
+)
+
+// Chunking defaults
+const (
+	DefaultChunkLines   = 60   // Lines per chunk
+	DefaultChunkOverlap = 10   // Lines shared between consecutive chunks
+	maxEmbedChars       = 2000 // Characters of a chunk sent to the embedding model
+)
+
+// Chunk is a contiguous range of lines from a workspace file
+type Chunk struct {
+	Path      string    `json:"path"`
+	StartLine int       `json:"start_line"` // 1-based, inclusive
+	EndLine   int       `json:"end_line"`   // 1-based, inclusive
+	Text      string    `json:"text"`
+	Vector    []float32 `json:"vector,omitempty"`
+}
+
+// ChunkText splits content into overlapping line-based chunks.
+// Blank chunks are dropped.
+func ChunkText(path, content string, lines, overlap int) []Chunk {
+	if lines <= 0 {
+		lines = DefaultChunkLines
+	}
+	if overlap < 0 || overlap >= lines {
+		overlap = 0
+	}
+
+	all := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	var chunks []Chunk
+	step := lines - overlap
+
+	for start := 0; start < len(all); start += step {
+		end := start + lines
+		if end > len(all) {
+			end = len(all)
+		}
+
+		text := strings.Join(all[start:end], "\n")
+		if strings.TrimSpace(text) != "" {
+			chunks = append(chunks, Chunk{
+				Path:      path,
+				StartLine: start + 1,
+				EndLine:   end,
+				Text:      text,
+			})
+		}
+
+		if end == len(all) {
+			break
+		}
+	}
+
+	return chunks
+}
+
+// embedText returns the text sent to the embedding model for a chunk.
+// The path is included so file names contribute to relevance.
+func (c Chunk) embedText() string {
+	text := c.Path + "\n" + c.Text
+	if len(text) > maxEmbedChars {
+		text = text[:maxEmbedChars]
+	}
+	return text
+}
@@ -0,0 +1,49 @@
+package retrieval
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestChunkText(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 25; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	content := strings.Join(lines, "\n") + "\n"
+
+	chunks := ChunkText("a.go", content, 10, 2)
+
+	expected := [][2]int{{1, 10}, {9, 18}, {17, 25}}
+	if len(chunks) != len(expected) {
+		t.Fatalf("expected %d chunks, got %d", len(expected), len(chunks))
+	}
+	for i, want := range expected {
+		if chunks[i].StartLine != want[0] || chunks[i].EndLine != want[1] {
+			t.Errorf("chunk %d: expected lines %d-%d, got %d-%d", i, want[0], want[1], chunks[i].StartLine, chunks[i].EndLine)
+		}
+		if chunks[i].Path != "a.go" {
+			t.Errorf("chunk %d: expected path a.go, got %s", i, chunks[i].Path)
+		}
+	}
+	if !strings.HasPrefix(chunks[1].Text, "line 9\n") {
+		t.Errorf("expected overlap to start at line 9, got %q", chunks[1].Text)
+	}
+}
+
+func TestChunkText_SmallAndBlank(t *testing.T) {
+	if chunks := ChunkText("a.go", "package a\n", 0, 0); len(chunks) != 1 || chunks[0].EndLine != 1 {
+		t.Errorf("expected a single one-line chunk, got %+v", chunks)
+	}
+	if chunks := ChunkText("a.go", "\n\n\n", 10, 2); len(chunks) != 0 {
+		t.Errorf("expected blank content to produce no chunks, got %d", len(chunks))
+	}
+}
+
+func TestChunk_EmbedTextTruncated(t *testing.T) {
+	c := Chunk{Path: "big.go", Text: strings.Repeat("x", 5000)}
+	if got := len(c.embedText()); got != maxEmbedChars {
+		t.Errorf("expected %d chars, got %d", maxEmbedChars, got)
+	}
+}
@@ -0,0 +1,344 @@
+package retrieval
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ai/brewol/internal/repo"
+)
+
+// Index defaults
+const (
+	DefaultTopK     = 5
+	maxFileSize     = 256 * 1024 // Larger files are skipped
+	embedBatchSize  = 32
+	indexVersion    = 1
+	binarySniffSize = 8000
+)
+
+// EmbedFunc returns one embedding vector per input text
+type EmbedFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+// fileEntry is the indexed state of a single file
+type fileEntry struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Hash    string    `json:"hash"`
+	Chunks  []Chunk   `json:"chunks"`
+}
+
+// indexFile is the on-disk index format
+type indexFile struct {
+	Version int                   `json:"version"`
+	Model   string                `json:"model"`
+	Files   map[string]*fileEntry `json:"files"`
+}
+
+// Result is a chunk matched by a search
+type Result struct {
+	Chunk
+	Score float64 `json:"score"`
+}
+
+// UpdateStats describes the outcome of an incremental update
+type UpdateStats struct {
+	Files    int // Files in the index after the update
+	Chunks   int // Chunks in the index after the update
+	Embedded int // Files (re-)embedded by this update
+	Removed  int // Files dropped because they no longer exist
+}
+
+// Changed reports whether the update modified the index
+func (s UpdateStats) Changed() bool {
+	return s.Embedded > 0 || s.Removed > 0
+}
+
+// Index is an on-disk embedding index of workspace files under .brewol/index/
+type Index struct {
+	root  string
+	path  string
+	model string
+	embed EmbedFunc
+	data  indexFile
+	mu    sync.Mutex // Guards data
+	busy  sync.Mutex // Serializes updates, held while embedding
+}
+
+// NewIndex opens (or creates) the index for a workspace. The model name is
+// recorded so that switching embedding models triggers a full re-index.
+func NewIndex(workspaceRoot, model string, embed EmbedFunc) (*Index, error) {
+	dir := filepath.Join(workspaceRoot, ".brewol", "index")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	idx := &Index{
+		root:  workspaceRoot,
+		path:  filepath.Join(dir, "embeddings.json"),
+		model: model,
+		embed: embed,
+	}
+	idx.load()
+	return idx, nil
+}
+
+// load reads the index from disk, starting fresh if it is missing or stale
+func (idx *Index) load() {
+	idx.data = indexFile{Version: indexVersion, Model: idx.model, Files: make(map[string]*fileEntry)}
+
+	raw, err := os.ReadFile(idx.path)
+	if err != nil {
+		return
+	}
+
+	var data indexFile
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return
+	}
+	if data.Version != indexVersion || data.Model != idx.model || data.Files == nil {
+		return
+	}
+	idx.data = data
+}
+
+// save writes the index to disk atomically
+func (idx *Index) save() error {
+	raw, err := json.Marshal(idx.data)
+	if err != nil {
+		return err
+	}
+
+	tmp := idx.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return os.Rename(tmp, idx.path)
+}
+
+// Update brings the index in line with the workspace. Unchanged files (same
+// size and modification time, or same content hash) are not re-embedded.
+// The index stays searchable while changed chunks are being embedded.
+func (idx *Index) Update(ctx context.Context) (UpdateStats, error) {
+	idx.busy.Lock()
+	defer idx.busy.Unlock()
+
+	var stats UpdateStats
+
+	files := repo.ListFiles(idx.root)
+
+	idx.mu.Lock()
+
+	seen := make(map[string]bool, len(files))
+	var pending []Chunk
+	pendingEntries := make(map[string]*fileEntry)
+
+	for _, rel := range files {
+		info, err := os.Stat(filepath.Join(idx.root, rel))
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxFileSize {
+			continue
+		}
+		seen[rel] = true
+
+		existing := idx.data.Files[rel]
+		if existing != nil && existing.Size == info.Size() && existing.ModTime.Equal(info.ModTime()) {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(idx.root, rel))
+		if err != nil || isBinary(content) {
+			delete(seen, rel)
+			continue
+		}
+
+		hash := hashContent(content)
+		if existing != nil && existing.Hash == hash {
+			existing.ModTime = info.ModTime()
+			existing.Size = info.Size()
+			continue
+		}
+
+		entry := &fileEntry{ModTime: info.ModTime(), Size: info.Size(), Hash: hash}
+		entry.Chunks = ChunkText(rel, string(content), DefaultChunkLines, DefaultChunkOverlap)
+		pendingEntries[rel] = entry
+		pending = append(pending, entry.Chunks...)
+	}
+	idx.mu.Unlock()
+
+	// Embed new and changed chunks in batches
+	vectors := make([][]float32, 0, len(pending))
+	for start := 0; start < len(pending); start += embedBatchSize {
+		end := start + embedBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+
+		texts := make([]string, 0, end-start)
+		for _, c := range pending[start:end] {
+			texts = append(texts, c.embedText())
+		}
+
+		batch, err := idx.embed(ctx, texts)
+		if err != nil {
+			return stats, fmt.Errorf("failed to embed chunks: %w", err)
+		}
+		if len(batch) != len(texts) {
+			return stats, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(batch))
+		}
+		vectors = append(vectors, batch...)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	// Attach vectors in the same order the chunks were queued
+	i := 0
+	for _, rel := range files {
+		entry, ok := pendingEntries[rel]
+		if !ok {
+			continue
+		}
+		for j := range entry.Chunks {
+			entry.Chunks[j].Vector = vectors[i]
+			i++
+		}
+		idx.data.Files[rel] = entry
+		stats.Embedded++
+	}
+
+	for rel := range idx.data.Files {
+		if !seen[rel] {
+			delete(idx.data.Files, rel)
+			stats.Removed++
+		}
+	}
+
+	stats.Files = len(idx.data.Files)
+	for _, entry := range idx.data.Files {
+		stats.Chunks += len(entry.Chunks)
+	}
+
+	if stats.Changed() {
+		if err := idx.save(); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// Search returns the k chunks most similar to the query
+func (idx *Index) Search(ctx context.Context, query string, k int) ([]Result, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	if k <= 0 {
+		k = DefaultTopK
+	}
+
+	vectors, err := idx.embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("expected 1 embedding, got %d", len(vectors))
+	}
+	queryVec := vectors[0]
+
+	idx.mu.Lock()
+	var results []Result
+	for _, entry := range idx.data.Files {
+		for _, c := range entry.Chunks {
+			results = append(results, Result{Chunk: c, Score: cosine(queryVec, c.Vector)})
+		}
+	}
+	idx.mu.Unlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Path != results[j].Path {
+			return results[i].Path < results[j].Path
+		}
+		return results[i].StartLine < results[j].StartLine
+	})
+
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// Stats returns the current size of the index
+func (idx *Index) Stats() UpdateStats {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	stats := UpdateStats{Files: len(idx.data.Files)}
+	for _, entry := range idx.data.Files {
+		stats.Chunks += len(entry.Chunks)
+	}
+	return stats
+}
+
+// Model returns the embedding model the index was built with
+func (idx *Index) Model() string {
+	return idx.model
+}
+
+// FormatResults renders search results for inclusion in an observation
+func FormatResults(results []Result) string {
+	if len(results) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## RELEVANT CODE\n")
+	for _, r := range results {
+		b.WriteString(fmt.Sprintf("\n### %s:%d-%d (score %.2f)\n```\n%s\n```\n", r.Path, r.StartLine, r.EndLine, r.Score, r.Text))
+	}
+	return b.String()
+}
+
+// isBinary reports whether content looks like a binary file
+func isBinary(content []byte) bool {
+	sniff := content
+	if len(sniff) > binarySniffSize {
+		sniff = sniff[:binarySniffSize]
+	}
+	return bytes.IndexByte(sniff, 0) != -1
+}
+
+// hashContent returns the hex SHA-256 of content
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// cosine returns the cosine similarity of two vectors (0 if incompatible)
+func cosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
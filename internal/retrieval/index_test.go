@@ -0,0 +1,226 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// vocabEmbedder embeds text as keyword counts over a fixed vocabulary and
+// records how many texts it was asked to embed
+type vocabEmbedder struct {
+	vocab []string
+	calls int
+}
+
+func (v *vocabEmbedder) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	v.calls += len(texts)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, len(v.vocab))
+		for j, word := range v.vocab {
+			vec[j] = float32(strings.Count(strings.ToLower(text), word))
+		}
+		vectors[i] = vec
+	}
+	return vectors, nil
+}
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestIndex_UpdateAndSearch(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "auth/login.go", "package auth\n\nfunc Login(password string) {}\n")
+	writeFile(t, root, "billing/invoice.go", "package billing\n\nfunc Invoice(amount int) {}\n")
+	writeFile(t, root, "node_modules/dep/index.js", "password password password\n")
+	writeFile(t, root, "bin/tool", "ELF\x00\x00password")
+
+	emb := &vocabEmbedder{vocab: []string{"password", "login", "invoice", "amount"}}
+	idx, err := NewIndex(root, "test-embed", emb.embed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stats, err := idx.Update(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Files != 2 || stats.Embedded != 2 {
+		t.Errorf("expected 2 files embedded (skipping vendored and binary), got %+v", stats)
+	}
+
+	results, err := idx.Search(context.Background(), "where is the login password checked", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Path != filepath.Join("auth", "login.go") {
+		t.Fatalf("expected auth/login.go as top result, got %+v", results)
+	}
+
+	text := FormatResults(results)
+	if !strings.Contains(text, "RELEVANT CODE") || !strings.Contains(text, "func Login") {
+		t.Errorf("unexpected formatted results: %q", text)
+	}
+}
+
+func TestIndex_Incremental(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.go", "package a // login\n")
+	writeFile(t, root, "b.go", "package b // invoice\n")
+
+	emb := &vocabEmbedder{vocab: []string{"login", "invoice"}}
+	idx, err := NewIndex(root, "test-embed", emb.embed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := idx.Update(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Nothing changed: no embedding calls
+	emb.calls = 0
+	stats, err := idx.Update(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if emb.calls != 0 || stats.Changed() {
+		t.Errorf("expected no work for unchanged workspace, got %d calls, %+v", emb.calls, stats)
+	}
+
+	// Touch without content change: hash matches, no re-embed
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(root, "a.go"), later, later)
+	if _, err := idx.Update(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if emb.calls != 0 {
+		t.Errorf("expected touched file not to be re-embedded, got %d calls", emb.calls)
+	}
+
+	// Modify one file and delete the other
+	writeFile(t, root, "a.go", "package a // login login\n")
+	os.Remove(filepath.Join(root, "b.go"))
+
+	stats, err = idx.Update(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Embedded != 1 || stats.Removed != 1 || stats.Files != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if emb.calls != 1 {
+		t.Errorf("expected only the changed chunk to be embedded, got %d", emb.calls)
+	}
+
+	// Reopen from disk: index persists
+	emb.calls = 0
+	reopened, err := NewIndex(root, "test-embed", emb.embed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reopened.Stats().Files != 1 {
+		t.Errorf("expected persisted index with 1 file, got %+v", reopened.Stats())
+	}
+
+	// A different model invalidates the index
+	other, err := NewIndex(root, "other-embed", emb.embed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if other.Stats().Files != 0 {
+		t.Errorf("expected empty index for a different model, got %+v", other.Stats())
+	}
+}
+
+func TestIndex_EmbedError(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.go", "package a\n")
+
+	idx, err := NewIndex(root, "test-embed", func(ctx context.Context, texts []string) ([][]float32, error) {
+		return nil, fmt.Errorf("model not found")
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := idx.Update(context.Background()); err == nil {
+		t.Error("expected embedding error to be returned")
+	}
+}
+
+func TestIndex_SearchDuringUpdate(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.go", "package a\n\nfunc Login() {}\n")
+
+	emb := &vocabEmbedder{vocab: []string{"login", "invoice"}}
+	embedding, release := make(chan struct{}), make(chan struct{})
+	blocked := false
+	idx, err := NewIndex(root, "test-embed", func(ctx context.Context, texts []string) ([][]float32, error) {
+		if blocked {
+			close(embedding)
+			<-release
+		}
+		return emb.embed(ctx, texts)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := idx.Update(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	writeFile(t, root, "b.go", "package b\n\nfunc Invoice() {}\n")
+	blocked = true
+	done := make(chan error)
+	go func() {
+		_, err := idx.Update(context.Background())
+		done <- err
+	}()
+	<-embedding
+
+	// The index must stay readable while the update is waiting on the model
+	if stats := idx.Stats(); stats.Files != 1 {
+		t.Errorf("expected the previous index while embedding, got %+v", stats)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats := idx.Stats(); stats.Files != 2 {
+		t.Errorf("expected both files after the update, got %+v", stats)
+	}
+}
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"identical", []float32{1, 2}, []float32{1, 2}, 1},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 0},
+		{"length mismatch", []float32{1}, []float32{1, 2}, 0},
+		{"zero vector", []float32{0, 0}, []float32{1, 1}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cosine(tt.a, tt.b)
+			if got < tt.want-1e-9 || got > tt.want+1e-9 {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/ai/brewol/internal/repo"
 )
 
 // EditQAResult contains the results of post-edit verification
@@ -102,49 +104,34 @@ func (r *Registry) VerifyBeforeCommit(ctx context.Context, forceAnyway bool) (*T
 	return verifyResult, nil
 }
 
-// runVerification runs the appropriate verification commands
+// runVerification runs the verification steps of the projects affected by
+// the uncommitted changes. Advisory steps are reported but only a failing
+// required step fails verification.
 func (r *Registry) runVerification(ctx context.Context) (*ToolResult, error) {
-	// Try to detect project type and run appropriate verification
-	// For now, try common patterns
-
-	// Check for Go project
-	if fileExists(filepath.Join(r.workspaceRoot, "go.mod")) {
-		// Run go build and go test
-		buildResult, _ := r.Execute(ctx, "shell", json.RawMessage(`{"command": "go build ./..."}`))
-		if buildResult != nil && buildResult.ExitCode != 0 {
-			return buildResult, fmt.Errorf("go build failed")
-		}
+	r.mu.RLock()
+	verifier := r.verifier
+	r.mu.RUnlock()
 
-		testResult, _ := r.Execute(ctx, "shell", json.RawMessage(`{"command": "go test ./..."}`))
-		return testResult, nil
-	}
-
-	// Check for Node project
-	if fileExists(filepath.Join(r.workspaceRoot, "package.json")) {
-		testResult, _ := r.Execute(ctx, "shell", json.RawMessage(`{"command": "npm test"}`))
-		return testResult, nil
+	results, ok := verifier.VerifyFiles(ctx, GetDirtyFiles(r.workspaceRoot))
+	if len(results) == 0 {
+		return &ToolResult{
+			Name:   "verify",
+			Output: "No verification command found for this project type",
+		}, nil
 	}
 
-	// Check for Python project
-	if fileExists(filepath.Join(r.workspaceRoot, "pyproject.toml")) || fileExists(filepath.Join(r.workspaceRoot, "pytest.ini")) {
-		testResult, _ := r.Execute(ctx, "shell", json.RawMessage(`{"command": "pytest"}`))
-		return testResult, nil
+	combined := repo.CombineResults(results)
+	result := &ToolResult{
+		Name:     "verify",
+		Output:   combined.Output,
+		Duration: combined.Duration.Seconds(),
 	}
-
-	// Check for Makefile with test target
-	if fileExists(filepath.Join(r.workspaceRoot, "Makefile")) {
-		content, _ := os.ReadFile(filepath.Join(r.workspaceRoot, "Makefile"))
-		if strings.Contains(string(content), "test:") {
-			testResult, _ := r.Execute(ctx, "shell", json.RawMessage(`{"command": "make test"}`))
-			return testResult, nil
-		}
+	if !ok {
+		failed := results[len(results)-1]
+		result.ExitCode = failed.ExitCode
+		return result, fmt.Errorf("%s failed", failed.Name)
 	}
-
-	// No verification available
-	return &ToolResult{
-		Name:   "verify",
-		Output: "No verification command found for this project type",
-	}, nil
+	return result, nil
 }
 
 // extractModifiedFiles extracts the list of modified files from tool args/result
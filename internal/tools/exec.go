@@ -15,9 +15,14 @@ import (
 
 // ExecTool executes shell commands
 type ExecTool struct {
-	root string
+	root       string
+	timeoutSec int      // Default timeout (0 = defaultExecTimeoutSec)
+	env        []string // Project environment, e.g. an activated virtualenv
 }
 
+// defaultExecTimeoutSec is the exec timeout when none is configured
+const defaultExecTimeoutSec = 120
+
 type execArgs struct {
 	Cmd        string            `json:"cmd"`
 	Cwd        string            `json:"cwd"`
@@ -27,6 +32,14 @@ type execArgs struct {
 
 func (t *ExecTool) Name() string { return "exec" }
 
+// defaultTimeout returns the timeout used when a call does not set one
+func (t *ExecTool) defaultTimeout() int {
+	if t.timeoutSec > 0 {
+		return t.timeoutSec
+	}
+	return defaultExecTimeoutSec
+}
+
 func (t *ExecTool) Description() string {
 	return "Execute a shell command. Commands run within the workspace root by default. Returns stdout, stderr, and exit code."
 }
@@ -50,8 +63,8 @@ func (t *ExecTool) Parameters() map[string]interface{} {
 			},
 			"timeout_sec": map[string]interface{}{
 				"type":        "integer",
-				"description": "Timeout in seconds (default 120)",
-				"default":     120,
+				"description": fmt.Sprintf("Timeout in seconds (default %d)", t.defaultTimeout()),
+				"default":     t.defaultTimeout(),
 			},
 		},
 		"required": []string{"cmd"},
@@ -67,7 +80,7 @@ func (t *ExecTool) Execute(ctx context.Context, args json.RawMessage) (*ToolResu
 	}
 
 	if a.TimeoutSec == 0 {
-		a.TimeoutSec = 120
+		a.TimeoutSec = t.defaultTimeout()
 	}
 
 	// Determine working directory
@@ -89,7 +102,7 @@ func (t *ExecTool) Execute(ctx context.Context, args json.RawMessage) (*ToolResu
 	cmd.Dir = workDir
 
 	// Set environment
-	cmd.Env = os.Environ()
+	cmd.Env = append(os.Environ(), t.env...)
 	for k, v := range a.Env {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
 	}
@@ -180,7 +193,9 @@ func KillProcess(cmd *exec.Cmd) error {
 // ShellTool is an alias for ExecTool with simplified args (just "command" field)
 // Used by the engine when executing commands from code blocks
 type ShellTool struct {
-	root string
+	root       string
+	timeoutSec int      // Timeout (0 = defaultExecTimeoutSec)
+	env        []string // Project environment, e.g. an activated virtualenv
 }
 
 type shellArgs struct {
@@ -213,8 +228,8 @@ func (t *ShellTool) Execute(ctx context.Context, args json.RawMessage) (*ToolRes
 	}
 
 	// Delegate to ExecTool with the command
-	execTool := &ExecTool{root: t.root}
-	execArgs, _ := json.Marshal(execArgs{Cmd: a.Command, TimeoutSec: 120})
+	execTool := &ExecTool{root: t.root, timeoutSec: t.timeoutSec, env: t.env}
+	execArgs, _ := json.Marshal(execArgs{Cmd: a.Command})
 	result, err := execTool.Execute(ctx, execArgs)
 	if result != nil {
 		result.Name = t.Name() // Override name to "shell"
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -444,6 +446,60 @@ func GetCurrentBranch(root string) string {
 	return strings.TrimSpace(string(output))
 }
 
+// GetHeadCommit returns the SHA of the current HEAD commit
+func GetHeadCommit(root string) string {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// GetHeadTime returns when the HEAD commit was made (zero without commits)
+func GetHeadTime(root string) time.Time {
+	cmd := exec.Command("git", "log", "-1", "--format=%ct")
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return time.Time{}
+	}
+	sec, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// GetRecentSubjects returns the subject lines of the last n commits
+func GetRecentSubjects(root string, n int) []string {
+	cmd := exec.Command("git", "log", fmt.Sprintf("-%d", n), "--format=%s")
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	var subjects []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			subjects = append(subjects, line)
+		}
+	}
+	return subjects
+}
+
+// StageAll stages every change in the workspace, as git_commit does
+func StageAll(root string) error {
+	cmd := exec.Command("git", "add", "-A")
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stage changes: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // IsGitRepo checks if the directory is a git repository
 func IsGitRepo(root string) bool {
 	cmd := exec.Command("git", "rev-parse", "--git-dir")
@@ -468,3 +524,50 @@ func GetDirtyFiles(root string) []string {
 	}
 	return files
 }
+
+// GetDiff returns the uncoloured output of git diff with the given arguments
+func GetDiff(root string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"diff", "--no-color", "--no-ext-diff"}, args...)...)
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git diff failed: %s", msg)
+		}
+		return "", fmt.Errorf("git diff failed: %w", err)
+	}
+	return string(output), nil
+}
+
+// GetWorkingDiff is GetDiff against the working tree, followed by the files
+// git doesn't track yet (and doesn't ignore), shown as added. The index is
+// left untouched.
+func GetWorkingDiff(root string, args ...string) (string, error) {
+	diff, err := GetDiff(root, args...)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("git", "ls-files", "-z", "--others", "--exclude-standard")
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return diff, nil
+	}
+	var sb strings.Builder
+	sb.WriteString(diff)
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file == "" {
+			continue
+		}
+		cmd := exec.Command("git", "diff", "--no-color", "--no-ext-diff", "--no-index", "--", os.DevNull, file)
+		cmd.Dir = root
+		added, _ := cmd.Output() // Exits with 1 because the files differ
+		sb.Write(added)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,326 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/ai/brewol/internal/repo"
+)
+
+// maxSearchFileSize skips files too large to be source, like rg --max-filesize 1M
+const maxSearchFileSize = 1 << 20
+
+// searchEscape matches regex escapes, which don't count as uppercase for smart case
+var searchEscape = regexp.MustCompile(`\\(?:[pP]\{[^}]*\}|.)`)
+
+// searchLine is a matching or context line of a search result
+type searchLine struct {
+	number int
+	text   string
+	match  bool
+}
+
+// searchFile is what a search found in one file
+type searchFile struct {
+	path  string // Workspace-relative, slash-separated
+	lines []searchLine
+}
+
+// searchQuery is a compiled rg_search request, shared by rg and the native
+// search so both select the same lines
+type searchQuery struct {
+	expr     string // Final regex, with literal, word and case options applied
+	pattern  *regexp.Regexp
+	glob     *searchGlob // nil = all files
+	maxCount int         // Matching lines per file
+	before   int
+	after    int
+}
+
+// newSearchQuery compiles the search arguments
+func newSearchQuery(a rgSearchArgs) (*searchQuery, error) {
+	expr := a.Query
+	if a.Literal {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if a.Word {
+		expr = `(?:^|[^\pL\pN_])(?:` + expr + `)(?:$|[^\pL\pN_])`
+	}
+	switch a.Case {
+	case "", "sensitive":
+	case "insensitive":
+		expr = "(?i)" + expr
+	case "smart":
+		if !hasUpper(a.Query, a.Literal) {
+			expr = "(?i)" + expr
+		}
+	default:
+		return nil, fmt.Errorf("invalid case %q: must be sensitive, insensitive or smart", a.Case)
+	}
+
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	q := &searchQuery{expr: expr, pattern: pattern, maxCount: a.MaxResults, before: a.Context, after: a.Context}
+	if a.Before > 0 {
+		q.before = a.Before
+	}
+	if a.After > 0 {
+		q.after = a.After
+	}
+	if a.Glob != "" {
+		if q.glob, err = newSearchGlob(a.Glob); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// hasUpper reports whether a pattern has an uppercase letter outside escapes
+func hasUpper(query string, literal bool) bool {
+	if !literal {
+		query = searchEscape.ReplaceAllString(query, "")
+	}
+	return strings.IndexFunc(query, unicode.IsUpper) >= 0
+}
+
+// searchGlob filters files with rg --glob semantics: globs without a slash
+// match file names at any depth, globs with one match the path from the root,
+// and "!glob" excludes matching files and directories instead
+type searchGlob struct {
+	source   string
+	pattern  *regexp.Regexp
+	exclude  bool
+	anywhere bool // No slash: matched against each name in the path
+}
+
+// newSearchGlob compiles a glob supporting *, ?, **, [...] and {a,b}
+func newSearchGlob(glob string) (*searchGlob, error) {
+	g := &searchGlob{source: glob}
+	if strings.HasPrefix(glob, "!") {
+		g.exclude = true
+		glob = glob[1:]
+	}
+	glob = strings.TrimSuffix(glob, "/")
+	g.anywhere = !strings.Contains(glob, "/")
+	glob = strings.TrimPrefix(glob, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid glob %q: unclosed [", glob)
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '{':
+			sb.WriteString("(?:")
+			braces++
+		case c == '}' && braces > 0:
+			sb.WriteString(")")
+			braces--
+		case c == ',' && braces > 0:
+			sb.WriteString("|")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if braces > 0 {
+		return nil, fmt.Errorf("invalid glob %q: unclosed {", glob)
+	}
+	sb.WriteString("$")
+
+	pattern, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", glob, err)
+	}
+	g.pattern = pattern
+	return g, nil
+}
+
+// includes reports whether a workspace-relative file passes the glob
+func (g *searchGlob) includes(file string) bool {
+	if !g.exclude {
+		if g.anywhere {
+			return g.pattern.MatchString(filepath.Base(file))
+		}
+		return g.pattern.MatchString(file)
+	}
+
+	// Excluding a directory excludes everything below it
+	parts := strings.Split(file, "/")
+	for i := range parts {
+		name := parts[i]
+		if !g.anywhere {
+			name = strings.Join(parts[:i+1], "/")
+		}
+		if g.pattern.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// goSearch searches the workspace without rg: files git doesn't ignore,
+// skipping hidden, binary and large files as rg does, in parallel
+func goSearch(ctx context.Context, root string, q *searchQuery) ([]searchFile, error) {
+	var files []string
+	for _, file := range listSearchFiles(root) {
+		if q.glob == nil || q.glob.includes(file) {
+			files = append(files, file)
+		}
+	}
+
+	results := make([]searchFile, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = q.searchFile(root, files[i])
+			}
+		}()
+	}
+feed:
+	for i := range files {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var found []searchFile
+	for _, r := range results {
+		if len(r.lines) > 0 {
+			found = append(found, r)
+		}
+	}
+	return found, nil
+}
+
+// searchFile finds the first maxCount matching lines of a file with their
+// context. Lines in the trailing context that match are reported as matches,
+// as rg does.
+func (q *searchQuery) searchFile(root, file string) searchFile {
+	result := searchFile{path: file}
+	fullPath := filepath.Join(root, filepath.FromSlash(file))
+	info, err := os.Lstat(fullPath)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxSearchFileSize {
+		return result
+	}
+	data, err := os.ReadFile(fullPath)
+	if err != nil || bytes.IndexByte(data, 0) >= 0 {
+		return result // Binary
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	var shown []bool
+	count := 0
+	for i, line := range lines {
+		if count == q.maxCount {
+			break
+		}
+		if !q.pattern.MatchString(line) {
+			continue
+		}
+		count++
+		if shown == nil {
+			shown = make([]bool, len(lines))
+		}
+		for j := max(0, i-q.before); j <= min(len(lines)-1, i+q.after); j++ {
+			shown[j] = true
+		}
+	}
+	for i, ok := range shown {
+		if ok {
+			result.lines = append(result.lines, searchLine{number: i + 1, text: lines[i], match: q.pattern.MatchString(lines[i])})
+		}
+	}
+	return result
+}
+
+// listSearchFiles returns the slash-separated files rg would search: those
+// git doesn't ignore when root is in a repository, skipping hidden files
+func listSearchFiles(root string) []string {
+	var files []string
+	for _, file := range repo.ListFiles(root) {
+		file = filepath.ToSlash(file)
+		if !strings.HasPrefix(file, ".") && !strings.Contains(file, "/.") {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// renderSearch formats results as rg does: "path:line:text" for matches,
+// "path-line-text" for context and "--" between groups of lines. Output
+// stops after maxResults matching lines and the trailing context of the last.
+func renderSearch(files []searchFile, maxResults int, q *searchQuery) string {
+	var sb strings.Builder
+	count, last := 0, 0
+	for _, f := range files {
+		if count >= maxResults {
+			break
+		}
+		prev := 0
+		for _, l := range f.lines {
+			if count >= maxResults && l.number > last+q.after {
+				break
+			}
+			if sb.Len() > 0 && (q.before > 0 || q.after > 0) && (prev == 0 || l.number != prev+1) {
+				sb.WriteString("--\n")
+			}
+			sep := "-"
+			if l.match {
+				sep = ":"
+				count++
+				last = l.number
+			}
+			fmt.Fprintf(&sb, "%s%s%d%s%s\n", f.path, sep, l.number, sep, truncateLine(l.text, 200))
+			prev = l.number
+		}
+	}
+	return sb.String()
+}
@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/ai/brewol/internal/ollama"
+	"github.com/ai/brewol/internal/repo"
 )
 
 // ToolResult represents the result of a tool execution
@@ -30,14 +32,20 @@ type Tool interface {
 // Registry manages available tools
 type Registry struct {
 	tools         map[string]Tool
+	disabled      map[string]bool
+	verifier      *repo.Verifier // Runs verification before commits
+	execTimeout   int            // Exec and shell timeout in seconds (0 = default)
 	workspaceRoot string
 	mu            sync.RWMutex
 }
 
-// NewRegistry creates a new tool registry
-func NewRegistry(workspaceRoot string) *Registry {
+// NewRegistry creates a new tool registry for the project detected at
+// workspaceRoot
+func NewRegistry(workspaceRoot string, project *repo.Project) *Registry {
 	r := &Registry{
 		tools:         make(map[string]Tool),
+		disabled:      make(map[string]bool),
+		verifier:      repo.NewVerifier(project),
 		workspaceRoot: workspaceRoot,
 	}
 
@@ -47,8 +55,7 @@ func NewRegistry(workspaceRoot string) *Registry {
 	r.Register(&FSWriteTool{root: workspaceRoot})
 	r.Register(&FSPatchTool{root: workspaceRoot})
 	r.Register(&RgSearchTool{root: workspaceRoot})
-	r.Register(&ExecTool{root: workspaceRoot})
-	r.Register(&ShellTool{root: workspaceRoot}) // Used by code block execution
+	r.registerExec(project.Env()) // exec and shell, used by code block execution
 	r.Register(&GitStatusTool{root: workspaceRoot})
 	r.Register(&GitDiffTool{root: workspaceRoot})
 	r.Register(&GitCheckoutTool{root: workspaceRoot})
@@ -66,21 +73,65 @@ func (r *Registry) Register(tool Tool) {
 	r.tools[tool.Name()] = tool
 }
 
-// Get returns a tool by name
+// Disable stops tools from being listed or executed
+func (r *Registry) Disable(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range names {
+		r.disabled[name] = true
+	}
+}
+
+// SetExecTimeout sets the default timeout of the exec and shell tools
+func (r *Registry) SetExecTimeout(timeout time.Duration) {
+	sec := int(timeout.Seconds())
+	if sec < 1 {
+		sec = 1
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.execTimeout = sec
+	r.registerExec(r.verifier.Project().Env())
+}
+
+// SetVerifier sets the verifier used before commits, so that it shares the
+// engine's configured verification steps. Commands run by the exec and
+// shell tools use its project's environment.
+func (r *Registry) SetVerifier(v *repo.Verifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.verifier = v
+	r.registerExec(v.Project().Env())
+}
+
+// registerExec (re)registers the exec and shell tools with the configured
+// timeout and a project environment. Callers other than NewRegistry must
+// hold the lock.
+func (r *Registry) registerExec(env []string) {
+	r.tools["exec"] = &ExecTool{root: r.workspaceRoot, timeoutSec: r.execTimeout, env: env}
+	r.tools["shell"] = &ShellTool{root: r.workspaceRoot, timeoutSec: r.execTimeout, env: env}
+}
+
+// Get returns an enabled tool by name
 func (r *Registry) Get(name string) (Tool, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	if r.disabled[name] {
+		return nil, false
+	}
 	tool, ok := r.tools[name]
 	return tool, ok
 }
 
-// List returns all registered tool names
+// List returns all enabled tool names
 func (r *Registry) List() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	names := make([]string, 0, len(r.tools))
 	for name := range r.tools {
-		names = append(names, name)
+		if !r.disabled[name] {
+			names = append(names, name)
+		}
 	}
 	return names
 }
@@ -89,7 +140,7 @@ func (r *Registry) List() []string {
 func (r *Registry) Execute(ctx context.Context, name string, args json.RawMessage) (*ToolResult, error) {
 	tool, ok := r.Get(name)
 	if !ok {
-		return nil, fmt.Errorf("unknown tool: %s", name)
+		return nil, fmt.Errorf("unknown or disabled tool: %s", name)
 	}
 	return tool.Execute(ctx, args)
 }
@@ -100,7 +151,10 @@ func (r *Registry) ToOllamaTools() []ollama.Tool {
 	defer r.mu.RUnlock()
 
 	tools := make([]ollama.Tool, 0, len(r.tools))
-	for _, tool := range r.tools {
+	for name, tool := range r.tools {
+		if r.disabled[name] {
+			continue
+		}
 		tools = append(tools, ollama.Tool{
 			Type: "function",
 			Function: ollama.ToolDef{
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ai/brewol/internal/repo"
+)
+
+func TestRegistry_Disable(t *testing.T) {
+	root := t.TempDir()
+	r := NewRegistry(root, repo.DetectProject(root))
+	r.Disable("git_reset_hard")
+
+	if _, ok := r.Get("git_reset_hard"); ok {
+		t.Error("expected disabled tool to be hidden")
+	}
+	for _, name := range r.List() {
+		if name == "git_reset_hard" {
+			t.Error("expected disabled tool to be unlisted")
+		}
+	}
+	for _, tool := range r.ToOllamaTools() {
+		if tool.Function.Name == "git_reset_hard" {
+			t.Error("expected disabled tool to be hidden from the model")
+		}
+	}
+	if _, err := r.Execute(context.Background(), "git_reset_hard", json.RawMessage(`{}`)); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("expected disabled error, got %v", err)
+	}
+}
+
+func TestRegistry_SetExecTimeout(t *testing.T) {
+	root := t.TempDir()
+	r := NewRegistry(root, repo.DetectProject(root))
+	r.SetExecTimeout(time.Second)
+
+	shell, _ := r.Get("shell")
+	if shell.(*ShellTool).timeoutSec != 1 {
+		t.Errorf("expected shell to use the configured timeout, got %d", shell.(*ShellTool).timeoutSec)
+	}
+
+	tool, _ := r.Get("exec")
+	params := tool.Parameters()["properties"].(map[string]interface{})["timeout_sec"].(map[string]interface{})
+	if params["default"] != 1 {
+		t.Errorf("expected exec to advertise the configured default, got %v", params["default"])
+	}
+}
+
+func TestRegistry_ExecVirtualEnv(t *testing.T) {
+	root := t.TempDir()
+	venv := filepath.Join(root, ".venv")
+	os.MkdirAll(filepath.Join(venv, "bin"), 0755)
+	os.WriteFile(filepath.Join(venv, "pyvenv.cfg"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(root, "pyproject.toml"), []byte("[project]\nname = \"app\"\n"), 0644)
+
+	r := NewRegistry(root, repo.DetectProject(root))
+	r.SetExecTimeout(10 * time.Second)
+
+	for _, name := range []string{"exec", "shell"} {
+		args := json.RawMessage(`{"cmd": "echo $VIRTUAL_ENV", "command": "echo $VIRTUAL_ENV"}`)
+		result, err := r.Execute(context.Background(), name, args)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.TrimSpace(result.Output) != venv {
+			t.Errorf("expected %s to run in the virtualenv, got %q", name, result.Output)
+		}
+	}
+}
+
+func TestRegistry_RunVerification(t *testing.T) {
+	root := t.TempDir()
+	r := NewRegistry(root, repo.DetectProject(root))
+
+	project := &repo.Project{Root: root}
+	project.SetSteps([]repo.Step{
+		{Name: "check", Command: "echo checked", Required: true},
+		{Name: "style", Kind: repo.StepLint, Command: "exit 2"},
+	})
+	r.SetVerifier(repo.NewVerifier(project))
+
+	result, err := r.runVerification(context.Background())
+	if err != nil {
+		t.Fatalf("expected advisory failure not to fail verification: %v", err)
+	}
+	if !strings.Contains(result.Output, "checked") || !strings.Contains(result.Output, "=== style: exit 2 (FAILED) ===") {
+		t.Errorf("expected all step output, got:\n%s", result.Output)
+	}
+
+	project.SetSteps([]repo.Step{{Name: "task test", Kind: repo.StepTest, Command: "exit 4", Required: true}})
+	result, err = r.runVerification(context.Background())
+	if err == nil || result.ExitCode != 4 || !strings.Contains(err.Error(), "task test") {
+		t.Errorf("expected required step failure, got %v / %+v", err, result)
+	}
+}
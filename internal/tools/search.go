@@ -1,14 +1,12 @@
 package tools
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
@@ -22,12 +20,18 @@ type rgSearchArgs struct {
 	Query      string `json:"query"`
 	Glob       string `json:"glob"`
 	MaxResults int    `json:"max_results"`
+	Literal    bool   `json:"literal"`
+	Word       bool   `json:"word"`
+	Case       string `json:"case"`
+	Context    int    `json:"context"`
+	Before     int    `json:"before"`
+	After      int    `json:"after"`
 }
 
 func (t *RgSearchTool) Name() string { return "rg_search" }
 
 func (t *RgSearchTool) Description() string {
-	return "Search for patterns in files using ripgrep (rg). Falls back to Go implementation if rg is not available."
+	return "Search for patterns in files using ripgrep (rg), skipping files ignored by git, hidden and binary files. Falls back to an equivalent Go implementation if rg is not available."
 }
 
 func (t *RgSearchTool) Parameters() map[string]interface{} {
@@ -40,7 +44,7 @@ func (t *RgSearchTool) Parameters() map[string]interface{} {
 			},
 			"glob": map[string]interface{}{
 				"type":        "string",
-				"description": "Glob pattern to filter files (e.g., '*.go', '**/*.ts')",
+				"description": "Glob pattern to filter files (e.g., '*.go', 'src/**/*.ts', '*.{js,ts}', '!vendor')",
 				"default":     "",
 			},
 			"max_results": map[string]interface{}{
@@ -48,6 +52,37 @@ func (t *RgSearchTool) Parameters() map[string]interface{} {
 				"description": "Maximum number of results to return",
 				"default":     100,
 			},
+			"literal": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Treat the query as a literal string instead of a regex",
+				"default":     false,
+			},
+			"word": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only match whole words",
+				"default":     false,
+			},
+			"case": map[string]interface{}{
+				"type":        "string",
+				"description": "Case matching: sensitive, insensitive, or smart (insensitive unless the query has uppercase)",
+				"enum":        []string{"sensitive", "insensitive", "smart"},
+				"default":     "sensitive",
+			},
+			"context": map[string]interface{}{
+				"type":        "integer",
+				"description": "Lines of context to show before and after each match",
+				"default":     0,
+			},
+			"before": map[string]interface{}{
+				"type":        "integer",
+				"description": "Lines of context before each match (overrides context)",
+				"default":     0,
+			},
+			"after": map[string]interface{}{
+				"type":        "integer",
+				"description": "Lines of context after each match (overrides context)",
+				"default":     0,
+			},
 		},
 		"required": []string{"query"},
 	}
@@ -65,161 +100,127 @@ func (t *RgSearchTool) Execute(ctx context.Context, args json.RawMessage) (*Tool
 		a.MaxResults = 100
 	}
 
-	// Try ripgrep first
-	if rgPath, err := exec.LookPath("rg"); err == nil {
-		return t.executeRg(ctx, rgPath, a, start)
-	}
-
-	// Fallback to Go implementation
-	return t.executeGoSearch(ctx, a, start)
-}
-
-func (t *RgSearchTool) executeRg(ctx context.Context, rgPath string, a rgSearchArgs, start time.Time) (*ToolResult, error) {
-	cmdArgs := []string{
-		"--line-number",
-		"--color=never",
-		"--no-heading",
-		fmt.Sprintf("--max-count=%d", a.MaxResults),
-	}
-
-	if a.Glob != "" {
-		cmdArgs = append(cmdArgs, "--glob", a.Glob)
-	}
-
-	cmdArgs = append(cmdArgs, a.Query, t.root)
-
-	cmd := exec.CommandContext(ctx, rgPath, cmdArgs...)
-	cmd.Dir = t.root
-
-	output, err := cmd.Output()
-	exitCode := 0
+	// Both implementations run the same compiled query so results don't
+	// depend on whether rg is installed
+	q, err := newSearchQuery(a)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			exitCode = exitErr.ExitCode()
-			// rg returns 1 for no matches, which is not an error
-			if exitCode == 1 {
-				return &ToolResult{
-					Name:     t.Name(),
-					Output:   "No matches found",
-					Duration: time.Since(start).Seconds(),
-					ExitCode: exitCode,
-				}, nil
-			}
-		} else {
-			return &ToolResult{Name: t.Name(), Error: err, Duration: time.Since(start).Seconds()}, err
-		}
+		return &ToolResult{Name: t.Name(), Error: err}, err
 	}
 
-	return &ToolResult{
-		Name:     t.Name(),
-		Output:   string(output),
-		Duration: time.Since(start).Seconds(),
-		ExitCode: exitCode,
-	}, nil
-}
-
-func (t *RgSearchTool) executeGoSearch(ctx context.Context, a rgSearchArgs, start time.Time) (*ToolResult, error) {
-	pattern, err := regexp.Compile(a.Query)
-	if err != nil {
-		return &ToolResult{Name: t.Name(), Error: err, Duration: time.Since(start).Seconds()}, err
+	var files []searchFile
+	if rgPath, lookErr := exec.LookPath("rg"); lookErr == nil {
+		files, err = t.executeRg(ctx, rgPath, q)
+	} else {
+		// Fallback to Go implementation
+		files, err = goSearch(ctx, t.root, q)
 	}
-
-	var output strings.Builder
-	resultCount := 0
-
-	// Walk directory and search files
-	err = filepath.Walk(t.root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if info.IsDir() {
-			// Skip common non-source directories
-			name := info.Name()
-			if name == ".git" || name == "node_modules" || name == "vendor" || name == "__pycache__" {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		// Check glob match
-		relPath, _ := filepath.Rel(t.root, path)
-		if a.Glob != "" {
-			matched, _ := filepath.Match(a.Glob, filepath.Base(path))
-			if !matched {
-				return nil
-			}
-		}
-
-		// Search file
-		matches, err := searchFileForPattern(path, pattern, a.MaxResults-resultCount)
-		if err != nil {
-			return nil
-		}
-
-		for _, match := range matches {
-			fmt.Fprintf(&output, "%s:%d:%s\n", relPath, match.line, match.text)
-			resultCount++
-			if resultCount >= a.MaxResults {
-				return filepath.SkipAll
-			}
-		}
-
-		return nil
-	})
-
-	if err != nil && err != context.Canceled && err != filepath.SkipAll {
+	if err != nil {
 		return &ToolResult{Name: t.Name(), Error: err, Duration: time.Since(start).Seconds()}, err
 	}
 
-	if resultCount == 0 {
+	if len(files) == 0 {
 		return &ToolResult{
 			Name:     t.Name(),
 			Output:   "No matches found",
 			Duration: time.Since(start).Seconds(),
+			ExitCode: 1,
 		}, nil
 	}
 
 	return &ToolResult{
 		Name:     t.Name(),
-		Output:   output.String(),
+		Output:   renderSearch(files, a.MaxResults, q),
 		Duration: time.Since(start).Seconds(),
 	}, nil
 }
 
-type searchMatch struct {
-	line int
-	text string
+// rgMessage is a line of rg --json output
+type rgMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		Path       rgText `json:"path"`
+		Lines      rgText `json:"lines"`
+		LineNumber int    `json:"line_number"`
+	} `json:"data"`
 }
 
-func searchFileForPattern(path string, pattern *regexp.Regexp, maxMatches int) ([]searchMatch, error) {
-	file, err := os.Open(path)
+// rgText is text in rg --json output, base64-encoded when not valid UTF-8
+type rgText struct {
+	Text  string `json:"text"`
+	Bytes []byte `json:"bytes"`
+}
+
+func (r rgText) String() string {
+	if r.Bytes != nil {
+		return string(r.Bytes)
+	}
+	return r.Text
+}
+
+// executeRg runs the query with rg, reading its JSON output so results are
+// rendered exactly like the Go implementation's
+func (t *RgSearchTool) executeRg(ctx context.Context, rgPath string, q *searchQuery) ([]searchFile, error) {
+	cmdArgs := []string{
+		"--json",
+		"--max-filesize=1M",
+		fmt.Sprintf("--max-count=%d", q.maxCount),
+		fmt.Sprintf("--before-context=%d", q.before),
+		fmt.Sprintf("--after-context=%d", q.after),
+	}
+
+	if q.glob != nil {
+		cmdArgs = append(cmdArgs, "--glob", q.glob.source)
+	}
+
+	cmdArgs = append(cmdArgs, "--regexp", q.expr, ".")
+
+	cmd := exec.CommandContext(ctx, rgPath, cmdArgs...)
+	cmd.Dir = t.root
+
+	output, err := cmd.Output()
 	if err != nil {
-		return nil, err
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return nil, err
+		}
+		// rg returns 1 for no matches, and 2 for errors such as unreadable
+		// files alongside any matches
+		if exitErr.ExitCode() == 2 && len(output) == 0 {
+			return nil, fmt.Errorf("rg failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
 	}
-	defer file.Close()
-
-	var matches []searchMatch
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
-
-	for scanner.Scan() && len(matches) < maxMatches {
-		lineNum++
-		line := scanner.Text()
-		if pattern.MatchString(line) {
-			matches = append(matches, searchMatch{
-				line: lineNum,
-				text: truncateLine(line, 200),
-			})
+
+	byPath := make(map[string]*searchFile)
+	var paths []string
+	for _, line := range strings.Split(string(output), "\n") {
+		var msg rgMessage
+		if line == "" || json.Unmarshal([]byte(line), &msg) != nil {
+			continue
+		}
+		if msg.Type != "match" && msg.Type != "context" {
+			continue
 		}
+		path := strings.TrimPrefix(filepath.ToSlash(msg.Data.Path.String()), "./")
+		f, ok := byPath[path]
+		if !ok {
+			f = &searchFile{path: path}
+			byPath[path] = f
+			paths = append(paths, path)
+		}
+		f.lines = append(f.lines, searchLine{
+			number: msg.Data.LineNumber,
+			text:   strings.TrimSuffix(msg.Data.Lines.String(), "\n"),
+			match:  msg.Type == "match",
+		})
 	}
 
-	return matches, scanner.Err()
+	// rg searches in parallel, so order files as the Go implementation does
+	sort.Strings(paths)
+	files := make([]searchFile, 0, len(paths))
+	for _, path := range paths {
+		files = append(files, *byPath[path])
+	}
+	return files, nil
 }
 
 func truncateLine(s string, maxLen int) string {
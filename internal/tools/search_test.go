@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRgSearch_GoFallback(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":       "build/\n",
+		"main.go":          "package main\n\n// Handler serves requests\nfunc handler() {}\n\nvar handlers = 1\n",
+		"lib/util.go":      "package lib\n\nfunc Handler() {}\n",
+		"lib/util_test.go": "package lib\n\n// handler test\n",
+		"web/app.ts":       "export const handler = 1;\n",
+		"build/out.go":     "// handler (ignored)\n",
+		".hidden/x.go":     "// handler (hidden)\n",
+		"bin.dat":          "handler\x00\n",
+		"big.txt":          strings.Repeat("handler\n", 200000),
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+	if out, err := exec.Command("git", "-C", root, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+
+	tool := &RgSearchTool{root: root}
+	tests := []struct {
+		args string
+		want string
+	}{
+		{`{"query": "handler"}`, "lib/util_test.go:3:// handler test\nmain.go:4:func handler() {}\nmain.go:6:var handlers = 1\nweb/app.ts:1:export const handler = 1;\n"},
+		{`{"query": "handler", "word": true, "glob": "*.go"}`, "lib/util_test.go:3:// handler test\nmain.go:4:func handler() {}\n"},
+		{`{"query": "handler", "case": "smart", "glob": "!lib"}`, "main.go:3:// Handler serves requests\nmain.go:4:func handler() {}\nmain.go:6:var handlers = 1\nweb/app.ts:1:export const handler = 1;\n"},
+		{`{"query": "Handler()", "literal": true, "glob": "lib/*.{go,ts}"}`, "lib/util.go:3:func Handler() {}\n"},
+		{`{"query": "handler", "case": "insensitive", "glob": "main.go", "before": 1, "max_results": 2}`, "main.go-2-\nmain.go:3:// Handler serves requests\nmain.go:4:func handler() {}\n"},
+		{`{"query": "^func", "context": 1}`, "lib/util.go-2-\nlib/util.go:3:func Handler() {}\n--\nmain.go-3-// Handler serves requests\nmain.go:4:func handler() {}\nmain.go-5-\n"},
+	}
+	for _, tt := range tests {
+		var a rgSearchArgs
+		json.Unmarshal([]byte(tt.args), &a)
+		if a.MaxResults == 0 {
+			a.MaxResults = 100
+		}
+		q, err := newSearchQuery(a)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		found, err := goSearch(context.Background(), root, q)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := renderSearch(found, a.MaxResults, q); got != tt.want {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", tt.args, got, tt.want)
+		}
+
+		// rg must agree when installed
+		if rgPath, err := exec.LookPath("rg"); err == nil {
+			found, err := tool.executeRg(context.Background(), rgPath, q)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := renderSearch(found, a.MaxResults, q); got != tt.want {
+				t.Errorf("%s with rg:\ngot:\n%s\nwant:\n%s", tt.args, got, tt.want)
+			}
+		}
+	}
+
+	if _, err := tool.Execute(context.Background(), json.RawMessage(`{"query": "x", "case": "upper"}`)); err == nil {
+		t.Error("expected an invalid case to fail")
+	}
+}
+
+func TestSearchGlob(t *testing.T) {
+	for _, tt := range []struct {
+		glob, file string
+		want       bool
+	}{
+		{"*.go", "a/b/c.go", true},
+		{"*.go", "a/b/c.ts", false},
+		{"src/*", "src/a.go", true},
+		{"src/*", "src/a/b.go", false},
+		{"src/**/*.go", "src/a/b.go", true},
+		{"**/*_test.go", "x_test.go", true},
+		{"a/*.go", "lib/a/b.go", false},
+		{"*.[ch]", "x/y.h", true},
+		{"!vendor", "vendor/a/b.go", false},
+		{"!vendor", "src/vendor.go", true},
+		{"!*_test.go", "a/b_test.go", false},
+	} {
+		g, err := newSearchGlob(tt.glob)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := g.includes(tt.file); got != tt.want {
+			t.Errorf("glob %q on %q = %v, want %v", tt.glob, tt.file, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ai/brewol/internal/logs"
+)
+
+// maxAccountedTasks limits the per-task rows in /summary
+const maxAccountedTasks = 5
+
+// formatAccounting renders session and per-task accounting for /summary
+func formatAccounting(m logs.Metrics) string {
+	u := m.Session
+	var b strings.Builder
+
+	b.WriteString("\n  Accounting:\n")
+	fmt.Fprintf(&b, "    Tokens:      %s prompt + %s output (%d requests)\n", formatTokens(u.PromptTokens), formatTokens(u.EvalTokens), u.Requests)
+	fmt.Fprintf(&b, "    Throughput:  %.1f tok/s\n", u.TokensPerSec())
+	fmt.Fprintf(&b, "    Wall time:   model %s | tools %s | verify %s\n", formatSeconds(u.ModelSeconds), formatSeconds(u.ToolSeconds), formatSeconds(u.VerifySeconds))
+	if completed := m.CompletedTasks(); completed > 0 {
+		fmt.Fprintf(&b, "    Tasks:       %d completed, %.1f cycles per task\n", completed, m.CyclesPerCompletedTask())
+	}
+
+	ids := m.TaskIDs()
+	for i, id := range ids {
+		if i >= maxAccountedTasks {
+			fmt.Fprintf(&b, "    ... and %d more tasks\n", len(ids)-maxAccountedTasks)
+			break
+		}
+		t := m.Tasks[id]
+		done := ""
+		if t.Completed {
+			done = " ✓"
+		}
+		fmt.Fprintf(&b, "    - %s: %s tok, %d cycles, model %s%s\n", truncate(t.Title, 40), formatTokens(t.TotalTokens()), t.Cycles, formatSeconds(t.ModelSeconds), done)
+	}
+	return b.String()
+}
+
+// formatTokens renders a token count compactly (950, 12.3k, 1.2M)
+func formatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// formatSeconds renders a number of seconds as a rounded duration
+func formatSeconds(s float64) string {
+	return (time.Duration(s * float64(time.Second))).Round(time.Second).String()
+}
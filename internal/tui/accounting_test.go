@@ -0,0 +1,25 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ai/brewol/internal/logs"
+)
+
+func TestFormatAccounting(t *testing.T) {
+	var m logs.Metrics
+	m.Session.AddRequest(12000, 3400, 10, 12)
+	m.Task("a", "Fix build").Cycles = 4
+	m.Task("a", "").Completed = true
+
+	out := formatAccounting(m)
+	for _, want := range []string{"12.0k prompt + 3.4k output (1 requests)", "340.0 tok/s", "model 12s", "1 completed, 4.0 cycles per task", "- Fix build: 0 tok, 4 cycles"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if formatTokens(950) != "950" || formatTokens(1_250_000) != "1.2M" {
+		t.Errorf("unexpected token formatting: %s %s", formatTokens(950), formatTokens(1_250_000))
+	}
+}
@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/ai/brewol/internal/config"
+)
+
+// handleConfigCommand handles /config [prefix]: it lists effective settings
+// and where each came from
+func (m Model) handleConfigCommand(parts []string) (tea.Model, tea.Cmd) {
+	prefix := ""
+	if len(parts) > 1 {
+		prefix = parts[1]
+	}
+
+	m.streamContent += "\n╔══════════════════════════════════════════════════════════════╗\n"
+	m.streamContent += "║                   CONFIGURATION                              ║\n"
+	m.streamContent += "╚══════════════════════════════════════════════════════════════╝\n\n"
+	m.streamContent += formatConfig(m.engine.Settings(), m.engine.Project().Root, prefix)
+
+	// Values changed at runtime (e.g. /model, /think) differ from the loaded config
+	m.streamContent += fmt.Sprintf("\n  Active model: %s, think: %s\n", m.engine.Client().GetModel(), m.engine.Client().GetThinkMode())
+	m.streamContent += "\n═══════════════════════════════════════════════════════════════\n"
+
+	m.streamView.SetContent(m.streamContent)
+	m.streamView.GotoBottom()
+	return m, nil
+}
+
+// formatConfig renders settings whose key starts with prefix, with their sources
+func formatConfig(cfg *config.Config, workspaceRoot, prefix string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "  User config: %s\n", config.UserPath())
+	fmt.Fprintf(&b, "  Repo config: %s\n\n", config.RepoPath(workspaceRoot))
+
+	section := ""
+	shown := 0
+	for _, s := range cfg.Settings() {
+		if !strings.HasPrefix(s.Key, prefix) {
+			continue
+		}
+		if name, _, _ := strings.Cut(s.Key, "."); name != section {
+			section = name
+			fmt.Fprintf(&b, "  [%s]\n", section)
+		}
+		fmt.Fprintf(&b, "    %-44s (%s)\n", s.Key[len(section)+1:]+" = "+s.Value, s.Source)
+		shown++
+	}
+	if shown == 0 {
+		fmt.Fprintf(&b, "  No settings match %q\n", prefix)
+	}
+
+	for _, w := range cfg.Warnings {
+		fmt.Fprintf(&b, "\n  Warning: %s", w)
+	}
+	if len(cfg.Warnings) > 0 {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
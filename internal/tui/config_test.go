@@ -0,0 +1,27 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ai/brewol/internal/config"
+)
+
+func TestFormatConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.Set("model.name", "coder", config.SourceFlag)
+	cfg.Warnings = []string{"unknown key foo.bar"}
+
+	out := formatConfig(cfg, "/repo", "model")
+	for _, want := range []string{"[model]", `name = "coder"`, "(flag)", "Warning: unknown key foo.bar"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "[budget]") {
+		t.Errorf("expected prefix to filter sections:\n%s", out)
+	}
+	if out := formatConfig(cfg, "/repo", "nope"); !strings.Contains(out, `No settings match "nope"`) {
+		t.Errorf("expected no-match message:\n%s", out)
+	}
+}
@@ -0,0 +1,258 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/ai/brewol/internal/tools"
+)
+
+// diffMode selects which changes the diff panel shows
+type diffMode int
+
+const (
+	diffUnstaged   diffMode = iota // Working tree vs index
+	diffStaged                     // Index vs HEAD
+	diffCheckpoint                 // Working tree vs last checkpoint
+	numDiffModes
+)
+
+// String returns the mode label shown in the panel header
+func (d diffMode) String() string {
+	switch d {
+	case diffUnstaged:
+		return "unstaged"
+	case diffStaged:
+		return "staged"
+	case diffCheckpoint:
+		return "checkpoint"
+	default:
+		return "unknown"
+	}
+}
+
+// diffRefreshTools are tool results that may change the workspace diff
+var diffRefreshTools = map[string]bool{
+	"fs_write":       true,
+	"fs_patch":       true,
+	"shell":          true,
+	"exec":           true,
+	"git_checkout":   true,
+	"git_commit":     true,
+	"git_reset_hard": true,
+}
+
+// diffFile is a file section of a diff
+type diffFile struct {
+	Path string
+	Line int // Line of the "diff --git" header within the diff
+}
+
+// diffLoadedMsg carries a freshly loaded diff
+type diffLoadedMsg struct {
+	mode diffMode
+	base string // Checkpoint commit for diffCheckpoint
+	diff string
+	err  error
+}
+
+// diffPanel is the side panel showing the live workspace diff
+type diffPanel struct {
+	view    viewport.Model
+	mode    diffMode
+	base    string
+	raw     string
+	files   []diffFile
+	current int
+	err     error
+}
+
+// loadDiff runs git diff for mode in the background. base is the checkpoint
+// commit used by diffCheckpoint; HEAD is used when it is empty.
+func loadDiff(root string, mode diffMode, base string) tea.Cmd {
+	return func() tea.Msg {
+		var args []string
+		switch mode {
+		case diffStaged:
+			args = []string{"--cached"}
+		case diffCheckpoint:
+			if base == "" {
+				base = "HEAD"
+			}
+			args = []string{base}
+		}
+
+		// Staged diffs compare against the index, which has no untracked files
+		getDiff := tools.GetWorkingDiff
+		if mode == diffStaged {
+			getDiff = tools.GetDiff
+		}
+		diff, err := getDiff(root, args...)
+		return diffLoadedMsg{mode: mode, base: base, diff: diff, err: err}
+	}
+}
+
+// setSize resizes the panel's viewport, keeping its content
+func (p *diffPanel) setSize(width, height int) {
+	offset := p.view.YOffset
+	p.view = viewport.New(width, height)
+	p.render()
+	p.view.SetYOffset(offset)
+}
+
+// setDiff replaces the panel's diff, staying on the same file when it is
+// still part of the diff
+func (p *diffPanel) setDiff(msg diffLoadedMsg) {
+	var currentPath string
+	if p.current < len(p.files) {
+		currentPath = p.files[p.current].Path
+	}
+	sameMode := p.mode == msg.mode
+
+	p.mode = msg.mode
+	p.base = msg.base
+	p.raw = msg.diff
+	p.err = msg.err
+	p.files = parseDiffFiles(msg.diff)
+	p.current = 0
+	p.render()
+
+	if !sameMode {
+		p.view.GotoTop()
+		return
+	}
+	for i, f := range p.files {
+		if f.Path == currentPath {
+			p.current = i
+			p.view.SetYOffset(f.Line)
+			return
+		}
+	}
+	p.view.GotoTop()
+}
+
+// render colours the diff into the viewport
+func (p *diffPanel) render() {
+	switch {
+	case p.err != nil:
+		p.view.SetContent(fmt.Sprintf("[%v]", p.err))
+	case strings.TrimSpace(p.raw) == "":
+		p.view.SetContent(fmt.Sprintf("[No %s changes]", p.mode))
+	default:
+		p.view.SetContent(colorizeDiff(p.raw, p.view.Width))
+	}
+}
+
+// nextFile jumps to the next (delta 1) or previous (delta -1) file
+func (p *diffPanel) nextFile(delta int) {
+	if len(p.files) == 0 {
+		return
+	}
+	p.current = (p.current + delta + len(p.files)) % len(p.files)
+	p.view.SetYOffset(p.files[p.current].Line)
+}
+
+// scroll moves the viewport by lines and tracks the file in view
+func (p *diffPanel) scroll(lines int) {
+	if lines < 0 {
+		p.view.LineUp(-lines)
+	} else {
+		p.view.LineDown(lines)
+	}
+	for i, f := range p.files {
+		if f.Line <= p.view.YOffset {
+			p.current = i
+		}
+	}
+}
+
+// header renders the mode tabs and current file above the diff
+func (p *diffPanel) header() string {
+	active := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("230")).Background(lipgloss.Color("62"))
+	inactive := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	var tabs []string
+	for mode := diffMode(0); mode < numDiffModes; mode++ {
+		label := mode.String()
+		if mode == diffCheckpoint && p.base != "" && p.base != "HEAD" && len(p.base) >= 7 {
+			label += " " + p.base[:7]
+		}
+		if mode == p.mode {
+			tabs = append(tabs, active.Render(" "+label+" "))
+		} else {
+			tabs = append(tabs, inactive.Render(" "+label+" "))
+		}
+	}
+
+	file := "no files changed"
+	if len(p.files) > 0 {
+		file = fmt.Sprintf("[%d/%d] %s", p.current+1, len(p.files), p.files[p.current].Path)
+	}
+	return strings.Join(tabs, "") + "\n" + truncate(file, p.view.Width)
+}
+
+// parseDiffFiles finds the file sections of a diff
+func parseDiffFiles(diff string) []diffFile {
+	var files []diffFile
+	for i, line := range strings.Split(diff, "\n") {
+		if !strings.HasPrefix(line, "diff --git ") {
+			continue
+		}
+		path := strings.TrimPrefix(line, "diff --git ")
+		if idx := strings.Index(path, " b/"); idx != -1 {
+			path = path[idx+3:]
+		}
+		files = append(files, diffFile{Path: path, Line: i})
+	}
+	return files
+}
+
+// colorizeDiff colours diff lines by kind, clipping them to width
+func colorizeDiff(diff string, width int) string {
+	var (
+		fileStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("230"))
+		metaStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+		hunkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("51"))
+		addedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("82"))
+		removedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	)
+
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	for i, line := range lines {
+		line = clipLine(strings.ReplaceAll(line, "\t", "    "), width)
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			lines[i] = fileStyle.Render(line)
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"),
+			strings.HasPrefix(line, "index "), strings.HasPrefix(line, "new file"),
+			strings.HasPrefix(line, "deleted file"), strings.HasPrefix(line, "similarity"),
+			strings.HasPrefix(line, "rename "), strings.HasPrefix(line, "Binary files"):
+			lines[i] = metaStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = hunkStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = addedStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = removedStyle.Render(line)
+		default:
+			lines[i] = line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// clipLine cuts a line to at most width runes
+func clipLine(line string, width int) string {
+	if width <= 0 {
+		return line
+	}
+	runes := []rune(line)
+	if len(runes) <= width {
+		return line
+	}
+	return string(runes[:width])
+}
@@ -0,0 +1,160 @@
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var x = 1
++var x = 2
+diff --git a/util/helper.go b/util/helper.go
+new file mode 100644
+--- /dev/null
++++ b/util/helper.go
+@@ -0,0 +1 @@
++package util
+`
+
+func TestParseDiffFiles(t *testing.T) {
+	files := parseDiffFiles(sampleDiff)
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(files))
+	}
+	if files[0].Path != "main.go" || files[0].Line != 0 {
+		t.Errorf("unexpected first file: %+v", files[0])
+	}
+	if files[1].Path != "util/helper.go" || files[1].Line != 8 {
+		t.Errorf("unexpected second file: %+v", files[1])
+	}
+}
+
+func TestDiffPanel_Navigation(t *testing.T) {
+	var p diffPanel
+	p.setSize(80, 3)
+	p.setDiff(diffLoadedMsg{mode: diffUnstaged, diff: sampleDiff})
+
+	p.nextFile(1)
+	if p.current != 1 || p.view.YOffset != 8 {
+		t.Errorf("expected second file at line 8, got file %d at offset %d", p.current, p.view.YOffset)
+	}
+
+	p.nextFile(1)
+	if p.current != 0 {
+		t.Errorf("expected navigation to wrap to the first file, got %d", p.current)
+	}
+
+	p.nextFile(-1)
+	if p.current != 1 {
+		t.Errorf("expected navigation to wrap back to the last file, got %d", p.current)
+	}
+
+	// A refresh in the same mode stays on the current file
+	p.setDiff(diffLoadedMsg{mode: diffUnstaged, diff: sampleDiff})
+	if p.current != 1 {
+		t.Errorf("expected refresh to keep the current file, got %d", p.current)
+	}
+
+	// Switching mode starts from the top
+	p.setDiff(diffLoadedMsg{mode: diffStaged, diff: sampleDiff})
+	if p.current != 0 || p.view.YOffset != 0 {
+		t.Errorf("expected mode switch to reset position, got file %d at offset %d", p.current, p.view.YOffset)
+	}
+}
+
+func TestDiffPanel_Empty(t *testing.T) {
+	var p diffPanel
+	p.setSize(40, 5)
+	p.setDiff(diffLoadedMsg{mode: diffStaged})
+
+	if !strings.Contains(p.view.View(), "No staged changes") {
+		t.Errorf("expected empty-state message, got %q", p.view.View())
+	}
+	if !strings.Contains(p.header(), "no files changed") {
+		t.Errorf("expected header to report no files, got %q", p.header())
+	}
+	p.nextFile(1) // must not panic without files
+}
+
+func TestColorizeDiff_ClipsLines(t *testing.T) {
+	out := colorizeDiff("+"+strings.Repeat("x", 100), 20)
+	if strings.Contains(out, strings.Repeat("x", 20)) {
+		t.Errorf("expected line to be clipped to 20 columns")
+	}
+}
+
+func TestLoadDiff_Modes(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	run("init", "-q")
+	write("a.txt", "one\n")
+	run("add", "a.txt")
+	run("commit", "-q", "-m", "init")
+
+	write("a.txt", "two\n")
+	run("add", "a.txt")
+	write("b.txt", "staged later\n")
+	run("add", "b.txt")
+	write("a.txt", "three\n")
+	write("new.txt", "created\n")
+
+	load := func(mode diffMode) diffLoadedMsg {
+		msg, ok := loadDiff(dir, mode, "")().(diffLoadedMsg)
+		if !ok {
+			t.Fatalf("expected diffLoadedMsg")
+		}
+		if msg.err != nil {
+			t.Fatalf("unexpected error: %v", msg.err)
+		}
+		return msg
+	}
+
+	unstaged := load(diffUnstaged)
+	if !strings.Contains(unstaged.diff, "+three") || strings.Contains(unstaged.diff, "b.txt") || !strings.Contains(unstaged.diff, "+created") {
+		t.Errorf("unexpected unstaged diff:\n%s", unstaged.diff)
+	}
+
+	staged := load(diffStaged)
+	if !strings.Contains(staged.diff, "+two") || !strings.Contains(staged.diff, "b.txt") || strings.Contains(staged.diff, "new.txt") {
+		t.Errorf("unexpected staged diff:\n%s", staged.diff)
+	}
+
+	checkpoint := load(diffCheckpoint)
+	if checkpoint.base != "HEAD" {
+		t.Errorf("expected checkpoint diff to default to HEAD, got %q", checkpoint.base)
+	}
+	if !strings.Contains(checkpoint.diff, "-one") || !strings.Contains(checkpoint.diff, "+three") || !strings.Contains(checkpoint.diff, "+created") {
+		t.Errorf("unexpected checkpoint diff:\n%s", checkpoint.diff)
+	}
+
+	if files := parseDiffFiles(unstaged.diff); len(files) != 2 || files[1].Path != "new.txt" {
+		t.Errorf("expected the untracked file as a section of its own, got %+v", files)
+	}
+}
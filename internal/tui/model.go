@@ -17,7 +17,10 @@ import (
 
 	ctxmgr "github.com/ai/brewol/internal/context"
 	"github.com/ai/brewol/internal/engine"
+	"github.com/ai/brewol/internal/memory"
 	"github.com/ai/brewol/internal/ollama"
+	"github.com/ai/brewol/internal/repo"
+	"github.com/ai/brewol/internal/retrieval"
 	"github.com/ai/brewol/internal/tools"
 )
 
@@ -34,6 +37,11 @@ type KeyMap struct {
 	ScrollDown     key.Binding
 	PageUp         key.Binding
 	PageDown       key.Binding
+	DiffNextFile   key.Binding
+	DiffPrevFile   key.Binding
+	DiffMode       key.Binding
+	DiffScrollUp   key.Binding
+	DiffScrollDown key.Binding
 }
 
 // DefaultKeyMap returns the default keybindings
@@ -80,9 +88,29 @@ func DefaultKeyMap() KeyMap {
 			key.WithHelp("pgup", "page up"),
 		),
 		PageDown: key.NewBinding(
-			key.WithKeys("pgdown", "ctrl+d"),
+			key.WithKeys("pgdown"),
 			key.WithHelp("pgdn", "page down"),
 		),
+		DiffNextFile: key.NewBinding(
+			key.WithKeys("]"),
+			key.WithHelp("]", "next diff file"),
+		),
+		DiffPrevFile: key.NewBinding(
+			key.WithKeys("["),
+			key.WithHelp("[", "previous diff file"),
+		),
+		DiffMode: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "unstaged/staged/checkpoint"),
+		),
+		DiffScrollUp: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "scroll diff up"),
+		),
+		DiffScrollDown: key.NewBinding(
+			key.WithKeys("J"),
+			key.WithHelp("J", "scroll diff down"),
+		),
 	}
 }
 
@@ -97,6 +125,7 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 		{k.Escape, k.CommandMode, k.ModelPicker},
 		{k.ToggleDiff, k.ToggleThinking, k.OpenLogs, k.Help},
 		{k.ScrollUp, k.ScrollDown, k.PageUp, k.PageDown},
+		{k.DiffNextFile, k.DiffPrevFile, k.DiffMode, k.DiffScrollUp, k.DiffScrollDown},
 	}
 }
 
@@ -108,7 +137,7 @@ type Model struct {
 	spinner         spinner.Model
 	streamView      viewport.Model
 	toolLogView     viewport.Model
-	backlogView     viewport.Model
+	diff            diffPanel
 	thinkingView    viewport.Model
 	commandInput    textinput.Model
 	promptInput     textinput.Model
@@ -123,6 +152,10 @@ type Model struct {
 	suggestions     []engine.Suggestion
 	showHelp        bool
 	showDiff        bool
+	showBoard       bool
+	board           taskBoard
+	showSessions    bool
+	sessions        sessionBrowser
 	showCommand     bool
 	showModels      bool
 	showThinking    bool // Show/hide thinking pane (Ctrl+T toggle)
@@ -176,10 +209,19 @@ func AvailableCommands() []Command {
 		{Name: "/system", Description: "System prompt: show|set|load|reset|save", NeedsArg: true},
 		// Memory commands
 		{Name: "/summary", Description: "Show operational summary", NeedsArg: false},
+		{Name: "/config", Description: "Show effective configuration and sources", NeedsArg: false},
 		{Name: "/memory", Description: "Show/reset rolling memory", NeedsArg: false},
+		{Name: "/knowledge", Description: "Knowledge: list|<query>|add <fact>", NeedsArg: false},
+		{Name: "/index", Description: "Code retrieval: status|<query>", NeedsArg: false},
+		{Name: "/coverage", Description: "Coverage mode: on|off|status", NeedsArg: false},
 		// Context commands
 		{Name: "/context", Description: "Context: show|set <num>|compact", NeedsArg: false},
-		{Name: "/tasks", Description: "Show task list or compact", NeedsArg: false},
+		{Name: "/tasks", Description: "Tasks: show|board|compact|clear", NeedsArg: false},
+		{Name: "/board", Description: "Open the interactive task board", NeedsArg: false},
+		{Name: "/sessions", Description: "Browse and replay past sessions", NeedsArg: false},
+		{Name: "/pin", Description: "Pin a file into every request", NeedsArg: true},
+		{Name: "/unpin", Description: "Unpin a file", NeedsArg: true},
+		{Name: "/pins", Description: "List pinned files", NeedsArg: false},
 		// Thinking commands
 		{Name: "/think", Description: "Think: show|on|off|auto|low|medium|high", NeedsArg: false},
 		{Name: "/hidethinking", Description: "Hide thinking panel: on|off", NeedsArg: false},
@@ -215,6 +257,8 @@ func New(eng *engine.Engine) Model {
 		inputFocused:   true,
 		showThinking:   showThinking,
 		thinkingBuffer: make([]string, 0, 100), // Cap at 100 lines for display
+		board:          newTaskBoard(eng.TaskStore()),
+		sessions:       newSessionBrowser(eng.Project().Root),
 	}
 }
 
@@ -239,6 +283,21 @@ type modelsLoadedMsg struct {
 	err    error
 }
 
+// indexResultMsg carries the index stats and search results for /index
+type indexResultMsg struct {
+	model   string
+	stats   retrieval.UpdateStats
+	query   string
+	results []retrieval.Result
+	err     error
+}
+
+// coverageStartedMsg carries the outcome of entering coverage mode
+type coverageStartedMsg struct {
+	report *repo.CoverageReport
+	err    error
+}
+
 // modelTestMsg carries the result of a model test
 type modelTestMsg struct {
 	model   string
@@ -267,6 +326,15 @@ func (m Model) fetchModels() tea.Cmd {
 	}
 }
 
+// startCoverage enters coverage mode in the background, cancelled when the
+// engine stops
+func (m Model) startCoverage() tea.Cmd {
+	return func() tea.Msg {
+		report, err := m.engine.StartCoverage(m.engine.Context())
+		return coverageStartedMsg{report: report, err: err}
+	}
+}
+
 func (m Model) testModel() tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -315,7 +383,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case engineUpdateMsg:
 		m = m.handleEngineUpdate(msg.update)
-		return m, m.listenForUpdates()
+		cmds = append(cmds, m.listenForUpdates())
+		if m.showDiff && msg.update.ToolResult != nil && diffRefreshTools[msg.update.ToolResult.Name] {
+			cmds = append(cmds, m.refreshDiff(m.diff.mode))
+		}
+		return m, tea.Batch(cmds...)
+
+	case diffLoadedMsg:
+		m.diff.setDiff(msg)
+		return m, nil
 
 	case modelsLoadedMsg:
 		if msg.err == nil {
@@ -326,6 +402,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case coverageStartedMsg:
+		if msg.err != nil {
+			m.streamContent += fmt.Sprintf("\n[ERROR: %v]\n", msg.err)
+		} else {
+			m.streamContent += fmt.Sprintf("\n[Coverage mode on at %.1f%%. Use /coverage to list the under-tested units]\n", msg.report.Percent)
+		}
+		m.streamView.SetContent(m.streamContent)
+		m.streamView.GotoBottom()
+		return m, nil
+
+	case indexResultMsg:
+		return m.showIndexResult(msg), nil
+
 	case modelTestMsg:
 		if msg.success {
 			m.streamContent += fmt.Sprintf("\n[Model %s OK! Response: %s]\n", msg.model, strings.TrimSpace(msg.message))
@@ -367,6 +456,16 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// Handle task board mode
+	if m.showBoard {
+		return m.handleBoardKey(msg)
+	}
+
+	// Handle session browser mode
+	if m.showSessions {
+		return m.handleSessionsKey(msg)
+	}
+
 	// Handle model picker mode
 	if m.showModels {
 		switch msg.String() {
@@ -404,6 +503,11 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// The diff panel toggles even while typing
+	if key.Matches(msg, m.keyMap.ToggleDiff) {
+		return m.toggleDiff()
+	}
+
 	// Handle prompt input when focused
 	if m.inputFocused {
 		input := m.promptInput.Value()
@@ -521,9 +625,23 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.showModels = true
 		return m, m.fetchModels()
 
-	case key.Matches(msg, m.keyMap.ToggleDiff):
-		m.showDiff = !m.showDiff
-		m.updateViewports()
+	case m.showDiff && key.Matches(msg, m.keyMap.DiffNextFile):
+		m.diff.nextFile(1)
+		return m, nil
+
+	case m.showDiff && key.Matches(msg, m.keyMap.DiffPrevFile):
+		m.diff.nextFile(-1)
+		return m, nil
+
+	case m.showDiff && key.Matches(msg, m.keyMap.DiffMode):
+		return m, m.refreshDiff((m.diff.mode + 1) % numDiffModes)
+
+	case m.showDiff && key.Matches(msg, m.keyMap.DiffScrollUp):
+		m.diff.scroll(-1)
+		return m, nil
+
+	case m.showDiff && key.Matches(msg, m.keyMap.DiffScrollDown):
+		m.diff.scroll(1)
 		return m, nil
 
 	case key.Matches(msg, m.keyMap.ToggleThinking):
@@ -579,6 +697,28 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmd, textinput.Blink)
 }
 
+// toggleDiff shows or hides the diff panel, loading the diff when shown
+func (m Model) toggleDiff() (tea.Model, tea.Cmd) {
+	m.showDiff = !m.showDiff
+	m.updateViewports()
+	if !m.showDiff {
+		return m, nil
+	}
+	if _, side := m.panelWidths(); side == 0 {
+		m.streamContent += "\n[Diff panel needs a terminal wider than 120 columns]\n"
+		m.streamView.SetContent(m.streamContent)
+		m.streamView.GotoBottom()
+	}
+	return m, m.refreshDiff(m.diff.mode)
+}
+
+// refreshDiff reloads the diff panel in mode. The checkpoint mode diffs
+// against the last good commit recorded in working memory.
+func (m Model) refreshDiff(mode diffMode) tea.Cmd {
+	base := m.engine.MemoryManager().GetWorkingMemory().LastGoodCommit
+	return loadDiff(m.engine.Project().Root, mode, base)
+}
+
 func (m Model) handleEscape() (tea.Model, tea.Cmd) {
 	now := time.Now()
 
@@ -683,15 +823,40 @@ func (m Model) executeCommand(cmd string) (tea.Model, tea.Cmd) {
 	case "/summary":
 		return m.handleSummaryCommand()
 
+	case "/config":
+		return m.handleConfigCommand(parts)
+
 	case "/memory":
 		return m.handleMemoryCommand(parts)
 
+	case "/knowledge":
+		return m.handleKnowledgeCommand(parts)
+
+	case "/index":
+		return m.handleIndexCommand(parts)
+
+	case "/coverage":
+		return m.handleCoverageCommand(parts)
+
 	case "/context":
 		return m.handleContextCommand(parts)
 
 	case "/tasks":
 		return m.handleTasksCommand(parts)
 
+	case "/board":
+		return m.openTaskBoard()
+
+	case "/sessions":
+		m.showSessions = true
+		m.inputFocused = false
+		m.promptInput.Blur()
+		m.sessions.load()
+		return m, nil
+
+	case "/pin", "/unpin", "/pins":
+		return m.handlePinCommand(parts)
+
 	case "/think":
 		return m.handleThinkCommand(parts)
 
@@ -789,13 +954,7 @@ func (m *Model) updateViewports() {
 	footerHeight := 5 // Prompt input + status line
 	contentHeight := m.height - headerHeight - footerHeight
 
-	// Calculate widths
-	mainWidth := m.width
-	sideWidth := 0
-	if m.showDiff && m.width > 120 {
-		sideWidth = m.width / 3
-		mainWidth = m.width - sideWidth - 1
-	}
+	mainWidth, sideWidth := m.panelWidths()
 
 	// Calculate heights - reserve space for thinking pane if visible
 	thinkingHeight := 0
@@ -817,13 +976,22 @@ func (m *Model) updateViewports() {
 		m.thinkingView.SetContent(m.thinkingContent)
 	}
 
-	// Backlog view (if showing diff/side panel)
+	// Diff side panel: -2 for the border, -2 for the header
 	if sideWidth > 0 {
-		m.backlogView = viewport.New(sideWidth, contentHeight)
-		m.updateBacklogView()
+		m.diff.setSize(sideWidth-2, contentHeight-4)
 	}
 }
 
+// panelWidths returns the widths of the main content and the diff side
+// panel, which is only shown on wide terminals
+func (m Model) panelWidths() (mainWidth, sideWidth int) {
+	if m.showDiff && m.width > 120 {
+		sideWidth = m.width / 3
+		return m.width - sideWidth - 1, sideWidth
+	}
+	return m.width, 0
+}
+
 func (m *Model) updateToolLogView() {
 	var content strings.Builder
 	for i := len(m.toolLogs) - 1; i >= 0 && i >= len(m.toolLogs)-5; i-- {
@@ -845,21 +1013,6 @@ func (m *Model) updateToolLogView() {
 	m.toolLogView.SetContent(content.String())
 }
 
-func (m *Model) updateBacklogView() {
-	backlog := m.engine.GetBacklog()
-	var content strings.Builder
-	content.WriteString("BACKLOG\n")
-	content.WriteString(strings.Repeat("─", 30) + "\n")
-	for i, item := range backlog {
-		if i >= 10 {
-			content.WriteString(fmt.Sprintf("... and %d more\n", len(backlog)-10))
-			break
-		}
-		content.WriteString(fmt.Sprintf("P%d %s\n", item.Priority, truncate(item.Description, 25)))
-	}
-	m.backlogView.SetContent(content.String())
-}
-
 // View implements tea.Model
 func (m Model) View() string {
 	if m.quitting {
@@ -879,10 +1032,16 @@ func (m Model) View() string {
 	// Render main content
 	if m.showHelp {
 		b.WriteString(m.renderHelp())
+	} else if m.showBoard {
+		b.WriteString(m.board.view(m.width, m.height-8))
+	} else if m.showSessions {
+		b.WriteString(m.sessions.view(m.width, m.height-8))
 	} else if m.showModels {
 		b.WriteString(m.renderModelPicker())
 	} else if m.showCommand {
 		b.WriteString(m.renderCommandInput())
+	} else if _, side := m.panelWidths(); side > 0 {
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, m.renderMainContent(), " ", m.renderDiffPanel()))
 	} else {
 		b.WriteString(m.renderMainContent())
 	}
@@ -924,7 +1083,11 @@ func (m Model) renderHeader() string {
 	stateStr := stateStyle.Render(state.String())
 	modelStr := headerStyle.Render(fmt.Sprintf("Model: %s", model))
 	branchStr := headerStyle.Render(fmt.Sprintf("Branch: %s", branch))
-	projectStr := headerStyle.Render(fmt.Sprintf("Project: %s", project.Type))
+	projectLabel := string(project.Type)
+	if n := len(project.SubProjects); n > 0 {
+		projectLabel += fmt.Sprintf(" +%d", n)
+	}
+	projectStr := headerStyle.Render(fmt.Sprintf("Project: %s", projectLabel))
 
 	// Build think mode indicator
 	thinkMode := m.engine.Client().GetThinkMode()
@@ -938,7 +1101,8 @@ func (m Model) renderHeader() string {
 	ctxState := m.engine.GetContextState()
 	ctxMeter := m.renderContextMeter(ctxState)
 
-	stats := statsStyle.Render(fmt.Sprintf("%.1f tok/s | exit: %d", m.tokensPerSec, m.lastExitCode))
+	usage := m.engine.Metrics().Session
+	stats := statsStyle.Render(fmt.Sprintf("%.1f tok/s | Σ %s tok | exit: %d", m.tokensPerSec, formatTokens(usage.TotalTokens()), m.lastExitCode))
 
 	left := lipgloss.JoinHorizontal(lipgloss.Left, mode, " ", stateStr, " ", m.spinner.View())
 	right := lipgloss.JoinHorizontal(lipgloss.Right, thinkStr, " ", ctxMeter, " ", modelStr, " ", branchStr, " ", projectStr, " ", stats)
@@ -992,6 +1156,7 @@ func (m Model) renderContextMeter(state ctxmgr.BudgetState) string {
 
 func (m Model) renderMainContent() string {
 	var b strings.Builder
+	mainWidth, _ := m.panelWidths()
 
 	// Suggestions panel
 	if len(m.suggestions) > 0 {
@@ -999,7 +1164,7 @@ func (m Model) renderMainContent() string {
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(lipgloss.Color("62")).
 			Padding(0, 1).
-			Width(m.width - 4)
+			Width(mainWidth - 4)
 
 		var sugContent strings.Builder
 		sugContent.WriteString("SUGGESTIONS:\n")
@@ -1028,7 +1193,7 @@ func (m Model) renderMainContent() string {
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(lipgloss.Color("99")).
 			Padding(0, 1).
-			Width(m.width - 4)
+			Width(mainWidth - 4)
 
 		thinkingHeader := "THINKING"
 		if m.isThinking {
@@ -1066,6 +1231,15 @@ func (m Model) renderMainContent() string {
 	return b.String()
 }
 
+// renderDiffPanel renders the diff side panel
+func (m Model) renderDiffPanel() string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62"))
+
+	return style.Render(m.diff.header() + "\n" + m.diff.view.View())
+}
+
 func (m Model) renderHelp() string {
 	helpStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
@@ -1079,6 +1253,9 @@ func (m Model) renderHelp() string {
   Ctrl+K        Command palette
   Ctrl+M        Model picker
   Ctrl+D        Toggle diff panel
+  ] / [         Next / previous file in diff panel
+  v             Diff: unstaged, staged, since checkpoint
+  J / K         Scroll diff panel
   Ctrl+L        Show logs path
   ?             Toggle help
 
@@ -1090,12 +1267,14 @@ COMMANDS:
   /checkpoint   Create a checkpoint
   /rollback     Rollback to last checkpoint
   /speed <n>    Set throttle (0 = no throttle)
+  /board        Task board (enter makes a task current)
+  /sessions     Browse and replay past sessions
 
 SCROLLING:
   ↑/k           Scroll up
   ↓/j           Scroll down
   PgUp/Ctrl+U   Page up
-  PgDn/Ctrl+D   Page down
+  PgDn          Page down
 
 Press ? to close this help`
 
@@ -1344,6 +1523,8 @@ func (m Model) handleSummaryCommand() (tea.Model, tea.Cmd) {
 		m.streamContent += fmt.Sprintf("  Errors:      %d (last: %s)\n", summary.ErrorCount, truncate(summary.LastError, 40))
 	}
 
+	m.streamContent += formatAccounting(summary.Metrics)
+
 	if len(summary.DirtyFiles) > 0 {
 		m.streamContent += fmt.Sprintf("\n  Dirty Files: %d\n", len(summary.DirtyFiles))
 		for i, f := range summary.DirtyFiles {
@@ -1400,6 +1581,157 @@ func (m Model) handleMemoryCommand(parts []string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleIndexCommand handles /index command
+func (m Model) handleIndexCommand(parts []string) (tea.Model, tea.Cmd) {
+	index := m.engine.RetrievalIndex()
+	if index == nil {
+		m.streamContent += "\n[Code retrieval disabled. Start with --embed-model <model> to enable]\n"
+		m.streamView.SetContent(m.streamContent)
+		m.streamView.GotoBottom()
+		return m, nil
+	}
+
+	query := ""
+	if len(parts) >= 2 && parts[1] != "status" {
+		query = strings.Join(parts[1:], " ")
+	}
+	return m, queryIndex(index, query)
+}
+
+// queryIndex reads the index stats and searches it in the background, so
+// embedding the query doesn't block the UI
+func queryIndex(index *retrieval.Index, query string) tea.Cmd {
+	return func() tea.Msg {
+		msg := indexResultMsg{model: index.Model(), stats: index.Stats(), query: query}
+		if query != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			msg.results, msg.err = index.Search(ctx, query, retrieval.DefaultTopK)
+		}
+		return msg
+	}
+}
+
+// showIndexResult renders the outcome of /index
+func (m Model) showIndexResult(msg indexResultMsg) Model {
+	m.streamContent += "\n╔══════════════════════════════════════════════════════════════╗\n"
+	m.streamContent += "║                   CODE RETRIEVAL INDEX                       ║\n"
+	m.streamContent += "╚══════════════════════════════════════════════════════════════╝\n\n"
+
+	m.streamContent += fmt.Sprintf("  Model:  %s\n", msg.model)
+	m.streamContent += fmt.Sprintf("  Files:  %d\n", msg.stats.Files)
+	m.streamContent += fmt.Sprintf("  Chunks: %d\n", msg.stats.Chunks)
+
+	if msg.query != "" {
+		m.streamContent += fmt.Sprintf("\n  Results for %q:\n", msg.query)
+		if msg.err != nil {
+			m.streamContent += fmt.Sprintf("  [Error: %v]\n", msg.err)
+		} else if len(msg.results) == 0 {
+			m.streamContent += "  [No results - the index is built during the first cycle]\n"
+		}
+		for _, r := range msg.results {
+			m.streamContent += fmt.Sprintf("  %.2f  %s:%d-%d\n", r.Score, r.Path, r.StartLine, r.EndLine)
+		}
+	}
+
+	m.streamContent += "\n  Use /index <query> to preview retrieval results\n"
+	m.streamContent += "═══════════════════════════════════════════════════════════════\n"
+
+	m.streamView.SetContent(m.streamContent)
+	m.streamView.GotoBottom()
+	return m
+}
+
+// handleCoverageCommand handles /coverage command
+func (m Model) handleCoverageCommand(parts []string) (tea.Model, tea.Cmd) {
+	action := "status"
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch action {
+	case "on":
+		m.streamContent += "\n[Measuring coverage...]\n"
+		m.streamView.SetContent(m.streamContent)
+		m.streamView.GotoBottom()
+		return m, m.startCoverage()
+	case "off":
+		m.engine.StopCoverage()
+		m.streamContent += "\n[Coverage mode off]\n"
+	case "status":
+		report := m.engine.Coverage()
+		if report == nil {
+			m.streamContent += "\n[Coverage mode off. Use /coverage on to start]\n"
+			break
+		}
+		m.streamContent += fmt.Sprintf("\n[Coverage: %.1f%% at the last checkpoint (%s)]\n", report.Percent, report.Command)
+		for i, unit := range report.Units {
+			if i == 10 {
+				m.streamContent += fmt.Sprintf("  ... and %d more\n", len(report.Units)-i)
+				break
+			}
+			m.streamContent += "  " + unit.Describe() + "\n"
+		}
+	default:
+		m.streamContent += "\n[Usage: /coverage on|off|status]\n"
+	}
+
+	m.streamView.SetContent(m.streamContent)
+	m.streamView.GotoBottom()
+	return m, nil
+}
+
+// handleKnowledgeCommand handles /knowledge command
+func (m Model) handleKnowledgeCommand(parts []string) (tea.Model, tea.Cmd) {
+	store := m.engine.MemoryManager().Knowledge()
+
+	if len(parts) >= 3 && parts[1] == "add" {
+		fact, err := m.engine.MemoryManager().RememberFact(memory.Fact{
+			Kind:       memory.FactKindNote,
+			Text:       strings.Join(parts[2:], " "),
+			Confidence: 1.0, // Stated by the user
+		})
+		if err != nil {
+			m.streamContent += fmt.Sprintf("\n[Error: %v]\n", err)
+		} else {
+			m.streamContent += fmt.Sprintf("\n[Knowledge recorded: %s]\n", fact.ID)
+		}
+		m.streamView.SetContent(m.streamContent)
+		m.streamView.GotoBottom()
+		return m, nil
+	}
+
+	var facts []memory.Fact
+	title := "all facts"
+	if len(parts) >= 2 && parts[1] != "list" {
+		query := strings.Join(parts[1:], " ")
+		facts = store.Search(query, 20)
+		title = "matching \"" + query + "\""
+	} else {
+		facts = store.All()
+	}
+
+	m.streamContent += "\n╔══════════════════════════════════════════════════════════════╗\n"
+	m.streamContent += "║                   PROJECT KNOWLEDGE                          ║\n"
+	m.streamContent += "╚══════════════════════════════════════════════════════════════╝\n\n"
+	m.streamContent += fmt.Sprintf("  %d facts stored, showing %s\n\n", store.Count(), title)
+
+	if len(facts) == 0 {
+		m.streamContent += "  [No knowledge yet - facts are learned during memory updates]\n"
+	}
+	for _, f := range facts {
+		m.streamContent += fmt.Sprintf("  %s [%s] %.0f%% (session %s, verified %s)\n    %s\n",
+			f.ID, f.Kind, f.Confidence*100, f.Source, f.LastVerified.Format("2006-01-02 15:04"), f.Text)
+	}
+
+	m.streamContent += "\n  Use /knowledge <query> to search, /knowledge add <fact> to record\n"
+	m.streamContent += "═══════════════════════════════════════════════════════════════\n"
+
+	m.streamView.SetContent(m.streamContent)
+	m.streamView.GotoBottom()
+	return m, nil
+}
+
 // handleContextCommand handles /context command
 func (m Model) handleContextCommand(parts []string) (tea.Model, tea.Cmd) {
 	subCmd := "show"
@@ -1422,14 +1754,33 @@ func (m Model) handleContextCommand(parts []string) (tea.Model, tea.Cmd) {
 		m.streamContent += fmt.Sprintf("  Usage:              %.1f%%\n", state.UsageRatio*100)
 		m.streamContent += fmt.Sprintf("  Available:          %d tokens\n", state.AvailableTokens)
 
+		model := m.engine.Client().GetModel()
+		counter := m.engine.TokenCounter()
+		m.streamContent += fmt.Sprintf("\n  Counted Prompt:     %d tokens\n", state.NextPromptTokens)
+		if counter.IsExact(model) {
+			m.streamContent += "  Counting:           model tokenizer\n"
+		} else {
+			m.streamContent += fmt.Sprintf("  Counting:           estimate (%.2f chars/token)\n", counter.Estimator().Ratio(model))
+		}
+
 		if state.NeedsCompaction {
 			m.streamContent += "\n  ⚠️  COMPACTION NEEDED\n"
 		}
 
 		// Show last compaction event
 		if event := m.engine.BudgetManager().GetLastCompactionEvent(); event != nil {
-			m.streamContent += fmt.Sprintf("\n  Last Compaction: %s\n", event.CompactedItems)
+			m.streamContent += fmt.Sprintf("\n  Last Compaction: %s (%s)\n", event.CompactedItems, event.Reason)
 			m.streamContent += fmt.Sprintf("  Tokens: %d → %d\n", event.TokensBefore, event.TokensAfter)
+			for _, item := range event.Dropped {
+				m.streamContent += fmt.Sprintf("    - dropped %s\n", item)
+			}
+			for _, item := range event.Truncated {
+				m.streamContent += fmt.Sprintf("    - truncated %s\n", item)
+			}
+		}
+
+		if pins := m.engine.Pins(); len(pins) > 0 {
+			m.streamContent += fmt.Sprintf("\n  Pinned: %s\n", strings.Join(pins, ", "))
 		}
 
 		m.streamContent += "\n═══════════════════════════════════════════════════════════════\n"
@@ -1461,6 +1812,43 @@ func (m Model) handleContextCommand(parts []string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handlePinCommand handles /pin, /unpin and /pins commands
+func (m Model) handlePinCommand(parts []string) (tea.Model, tea.Cmd) {
+	switch {
+	case parts[0] == "/pin" && len(parts) >= 2:
+		if err := m.engine.Pin(parts[1]); err != nil {
+			m.streamContent += fmt.Sprintf("\n[Error: %v]\n", err)
+		} else {
+			m.streamContent += fmt.Sprintf("\n[Pinned: %s]\n", parts[1])
+		}
+
+	case parts[0] == "/unpin" && len(parts) >= 2:
+		if m.engine.Unpin(parts[1]) {
+			m.streamContent += fmt.Sprintf("\n[Unpinned: %s]\n", parts[1])
+		} else {
+			m.streamContent += fmt.Sprintf("\n[Not pinned: %s]\n", parts[1])
+		}
+
+	case parts[0] == "/pins":
+		pins := m.engine.Pins()
+		if len(pins) == 0 {
+			m.streamContent += "\n[No pinned files. Use /pin <file> to keep a file in context]\n"
+		} else {
+			m.streamContent += "\nPinned files (evicted after older transcript and memory):\n"
+			for _, pin := range pins {
+				m.streamContent += fmt.Sprintf("  - %s\n", pin)
+			}
+		}
+
+	default:
+		m.streamContent += fmt.Sprintf("\n[Usage: %s <file>]\n", parts[0])
+	}
+
+	m.streamView.SetContent(m.streamContent)
+	m.streamView.GotoBottom()
+	return m, nil
+}
+
 // handleTasksCommand handles /tasks command
 func (m Model) handleTasksCommand(parts []string) (tea.Model, tea.Cmd) {
 	subCmd := "show"
@@ -1469,6 +1857,9 @@ func (m Model) handleTasksCommand(parts []string) (tea.Model, tea.Cmd) {
 	}
 
 	switch subCmd {
+	case "board":
+		return m.openTaskBoard()
+
 	case "show":
 		tasks := m.engine.TaskStore().GetAllTasks()
 		m.streamContent += "\n╔══════════════════════════════════════════════════════════════╗\n"
@@ -1525,7 +1916,7 @@ func (m Model) handleTasksCommand(parts []string) (tea.Model, tea.Cmd) {
 		m.streamContent += "\n[Completed tasks cleared]\n"
 
 	default:
-		m.streamContent += "\n[Usage: /tasks show|compact|clear]\n"
+		m.streamContent += "\n[Usage: /tasks show|board|compact|clear]\n"
 	}
 
 	m.streamView.SetContent(m.streamContent)
@@ -1533,6 +1924,16 @@ func (m Model) handleTasksCommand(parts []string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// openTaskBoard shows the full-screen task board
+func (m Model) openTaskBoard() (tea.Model, tea.Cmd) {
+	m.showBoard = true
+	m.inputFocused = false
+	m.promptInput.Blur()
+	m.board.status = ""
+	m.board.clamp()
+	return m, nil
+}
+
 // handleThinkCommand handles /think command
 func (m Model) handleThinkCommand(parts []string) (tea.Model, tea.Cmd) {
 	subCmd := "show"
@@ -0,0 +1,383 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/ai/brewol/internal/display"
+	"github.com/ai/brewol/internal/logs"
+)
+
+// sessionBrowser lists past sessions and replays a selected one
+type sessionBrowser struct {
+	root      string
+	summaries []logs.SessionSummary
+	selected  int
+	err       error
+
+	// Replay of the opened session
+	replaying bool
+	summary   logs.SessionSummary
+	timeline  []logs.TimelineEntry
+	cursor    int
+	query     string
+	matches   []int
+	searching bool
+	search    textinput.Model
+}
+
+// newSessionBrowser creates a browser over a workspace's session logs
+func newSessionBrowser(root string) sessionBrowser {
+	ti := textinput.New()
+	ti.Placeholder = "Search timeline"
+	ti.CharLimit = 128
+	return sessionBrowser{root: root, search: ti}
+}
+
+// load reads the session list
+func (s *sessionBrowser) load() {
+	s.summaries, s.err = logs.ListSessionSummaries(s.root)
+	s.replaying = false
+	if s.selected >= len(s.summaries) {
+		s.selected = 0
+	}
+}
+
+// open replays the selected session
+func (s *sessionBrowser) open() {
+	if s.selected >= len(s.summaries) {
+		return
+	}
+
+	summary := s.summaries[s.selected]
+	timeline, err := logs.ReadTimeline(summary.Dir)
+	if err != nil {
+		s.err = err
+		return
+	}
+
+	s.summary = summary
+	s.timeline = timeline
+	s.cursor = 0
+	s.query = ""
+	s.matches = nil
+	s.replaying = true
+	s.err = nil
+}
+
+// seek moves the replay cursor by delta entries
+func (s *sessionBrowser) seek(delta int) {
+	s.seekTo(s.cursor + delta)
+}
+
+// seekTo moves the replay cursor to an entry, clamped to the timeline
+func (s *sessionBrowser) seekTo(i int) {
+	if i >= len(s.timeline) {
+		i = len(s.timeline) - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	s.cursor = i
+}
+
+// seekPercent moves the replay cursor to a fraction of the session's duration
+func (s *sessionBrowser) seekPercent(pct int) {
+	if len(s.timeline) == 0 {
+		return
+	}
+	start := s.timeline[0].Timestamp
+	span := s.timeline[len(s.timeline)-1].Timestamp.Sub(start)
+	target := start.Add(span * time.Duration(pct) / 100)
+
+	for i, t := range s.timeline {
+		if !t.Timestamp.Before(target) {
+			s.cursor = i
+			return
+		}
+	}
+	s.cursor = len(s.timeline) - 1
+}
+
+// setQuery searches the timeline and jumps to the first match at or after
+// the cursor
+func (s *sessionBrowser) setQuery(query string) {
+	s.query = strings.TrimSpace(query)
+	s.matches = logs.SearchTimeline(s.timeline, s.query)
+	if len(s.matches) == 0 {
+		return
+	}
+	for _, i := range s.matches {
+		if i >= s.cursor {
+			s.cursor = i
+			return
+		}
+	}
+	s.cursor = s.matches[0]
+}
+
+// nextMatch jumps to the next (dir 1) or previous (dir -1) match, wrapping
+func (s *sessionBrowser) nextMatch(dir int) {
+	if len(s.matches) == 0 {
+		return
+	}
+	if dir > 0 {
+		for _, i := range s.matches {
+			if i > s.cursor {
+				s.cursor = i
+				return
+			}
+		}
+		s.cursor = s.matches[0]
+		return
+	}
+	for j := len(s.matches) - 1; j >= 0; j-- {
+		if s.matches[j] < s.cursor {
+			s.cursor = s.matches[j]
+			return
+		}
+	}
+	s.cursor = s.matches[len(s.matches)-1]
+}
+
+// isMatch reports whether entry i matches the current search
+func (s *sessionBrowser) isMatch(i int) bool {
+	for _, m := range s.matches {
+		if m == i {
+			return true
+		}
+	}
+	return false
+}
+
+// handleSessionsKey handles keys while the session browser is open
+func (m Model) handleSessionsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := &m.sessions
+
+	if s.searching {
+		switch msg.String() {
+		case "esc":
+			s.searching = false
+			s.search.Blur()
+		case "enter":
+			s.searching = false
+			s.search.Blur()
+			s.setQuery(s.search.Value())
+		default:
+			var cmd tea.Cmd
+			s.search, cmd = s.search.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	if !s.replaying {
+		switch msg.String() {
+		case "esc", "q":
+			m.showSessions = false
+			m.inputFocused = true
+			m.promptInput.Focus()
+			return m, textinput.Blink
+		case "up", "k":
+			if s.selected > 0 {
+				s.selected--
+			}
+		case "down", "j":
+			if s.selected < len(s.summaries)-1 {
+				s.selected++
+			}
+		case "r":
+			s.load()
+		case "enter":
+			s.open()
+		}
+		return m, nil
+	}
+
+	switch key := msg.String(); key {
+	case "esc", "q":
+		s.replaying = false
+	case "up", "k":
+		s.seek(-1)
+	case "down", "j":
+		s.seek(1)
+	case "pgup":
+		s.seek(-10)
+	case "pgdown", " ":
+		s.seek(10)
+	case "g", "home":
+		s.seekTo(0)
+	case "G", "end":
+		s.seekTo(len(s.timeline) - 1)
+	case "0", "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		s.seekPercent(int(key[0]-'0') * 10)
+	case "/":
+		s.searching = true
+		s.search.SetValue(s.query)
+		s.search.CursorEnd()
+		s.search.Focus()
+		return m, textinput.Blink
+	case "n":
+		s.nextMatch(1)
+	case "N":
+		s.nextMatch(-1)
+	}
+	return m, nil
+}
+
+// view renders the browser in width x height cells
+func (s *sessionBrowser) view(width, height int) string {
+	if width < 60 {
+		width = 60
+	}
+	if s.replaying {
+		return s.replayView(width, height)
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	cursorStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("230")).Background(lipgloss.Color("62"))
+
+	lines := []string{titleStyle.Render("SESSIONS"), ""}
+	switch {
+	case s.err != nil:
+		lines = append(lines, fmt.Sprintf("[Error: %v]", s.err))
+	case len(s.summaries) == 0:
+		lines = append(lines, dimStyle.Render("No sessions found in "+logs.GetDefaultLogDir(s.root)))
+	default:
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("%-16s %-8s %-20s %6s %5s %9s %-9s %s",
+			"SESSION", "DURATION", "MODEL", "CYCLES", "CKPTS", "TOOLS", "OUTCOME", "GOAL")))
+
+		visible := height - 6
+		start := 0
+		if s.selected >= visible {
+			start = s.selected - visible + 1
+		}
+		for i := start; i < len(s.summaries) && i < start+visible; i++ {
+			sum := s.summaries[i]
+			model := sum.Model
+			if model == "" {
+				model = "-"
+			}
+			line := fmt.Sprintf("%-16s %-8s %-20s %6d %5d %9s %-9s %s",
+				sum.ID, display.Duration(sum.Duration), truncate(model, 20), sum.Cycles, sum.Checkpoints,
+				fmt.Sprintf("%d/%d ok", sum.ToolCalls-sum.Failures, sum.ToolCalls), sum.Outcome, sum.Goal)
+			line = truncate(line, width-4)
+			if i == s.selected {
+				line = cursorStyle.Render(line)
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	lines = append(lines, "", dimStyle.Render("↑/↓ select | enter replay | r reload | esc close"))
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Width(width - 4).
+		Render(strings.Join(lines, "\n"))
+}
+
+// replayView renders the timeline of the opened session around the cursor
+func (s *sessionBrowser) replayView(width, height int) string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	cursorStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("230")).Background(lipgloss.Color("62"))
+	sourceStyles := map[string]lipgloss.Style{
+		logs.SourceTranscript: lipgloss.NewStyle().Foreground(lipgloss.Color("33")),
+		logs.SourceTools:      lipgloss.NewStyle().Foreground(lipgloss.Color("82")),
+		logs.SourceThinking:   lipgloss.NewStyle().Foreground(lipgloss.Color("99")),
+	}
+	failedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+
+	header := titleStyle.Render("REPLAY "+s.summary.ID) +
+		dimStyle.Render(fmt.Sprintf("  %s | %s | %d cycles | %d checkpoints", display.Duration(s.summary.Duration), s.summary.Outcome, s.summary.Cycles, s.summary.Checkpoints))
+	if s.summary.Goal != "" {
+		header += "\n" + truncate("Goal: "+s.summary.Goal, width-4)
+	}
+
+	if len(s.timeline) == 0 {
+		return header + "\n\n" + dimStyle.Render("[Session has no log entries]  esc back")
+	}
+
+	// Position bar
+	barWidth := width - 30
+	pos := s.cursor * (barWidth - 1) / max(len(s.timeline)-1, 1)
+	bar := strings.Repeat("━", pos) + "●" + strings.Repeat("─", barWidth-pos-1)
+	current := s.timeline[s.cursor]
+	offset := current.Timestamp.Sub(s.timeline[0].Timestamp)
+	position := fmt.Sprintf("%s %s %d/%d", bar, display.Duration(offset), s.cursor+1, len(s.timeline))
+
+	// Entry list, keeping the cursor in the middle
+	detailHeight := 10
+	listHeight := height - detailHeight - 8
+	if listHeight < 3 {
+		listHeight = 3
+	}
+	start := s.cursor - listHeight/2
+	if start > len(s.timeline)-listHeight {
+		start = len(s.timeline) - listHeight
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	var list []string
+	for i := start; i < len(s.timeline) && i < start+listHeight; i++ {
+		t := s.timeline[i]
+		marker := " "
+		if s.isMatch(i) {
+			marker = "*"
+		}
+		line := truncate(fmt.Sprintf("%s %s %-10s %s", marker, t.Timestamp.Format("15:04:05"), t.Source, t.Title()), width-6)
+		switch {
+		case i == s.cursor:
+			line = cursorStyle.Render(line)
+		case t.Failed():
+			line = failedStyle.Render(line)
+		default:
+			line = sourceStyles[t.Source].Render(line)
+		}
+		list = append(list, line)
+	}
+
+	// Full content of the entry under the cursor
+	content := strings.TrimRight(current.Content, "\n")
+	if args, ok := current.Metadata["args"].(string); ok && args != "" {
+		content = "$ " + args + "\n" + content
+	}
+	detailLines := strings.Split(content, "\n")
+	if len(detailLines) > detailHeight {
+		detailLines = append(detailLines[:detailHeight-1], dimStyle.Render(fmt.Sprintf("... %d more lines", len(detailLines)-detailHeight+1)))
+	}
+	for i, line := range detailLines {
+		detailLines[i] = clipLine(line, width-8)
+	}
+	detail := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		Padding(0, 1).
+		Width(width - 4).
+		Render(strings.Join(detailLines, "\n"))
+
+	footer := "↑/↓ step | pgup/pgdn ×10 | g/G start/end | 0-9 seek % | / search | n/N next/prev match | esc back"
+	if s.searching {
+		footer = s.search.View()
+	} else if s.query != "" {
+		footer = fmt.Sprintf("%d matches for %q  •  %s", len(s.matches), s.query, footer)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		header,
+		position,
+		strings.Join(list, "\n"),
+		detail,
+		dimStyle.Render(truncate(footer, width)),
+	)
+}
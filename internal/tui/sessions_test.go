@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ai/brewol/internal/logs"
+)
+
+// writeSessionLog writes entries into a session's JSONL log file
+func writeSessionLog(t *testing.T, dir, name string, entries ...logs.Entry) {
+	t.Helper()
+	var b strings.Builder
+	for _, e := range entries {
+		data, _ := json.Marshal(e)
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(b.String()), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func newTestBrowser(t *testing.T) *sessionBrowser {
+	t.Helper()
+	root := t.TempDir()
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+
+	older := filepath.Join(logs.GetDefaultLogDir(root), "20240430-090000")
+	os.MkdirAll(older, 0755)
+	writeSessionLog(t, older, "transcript.jsonl", logs.Entry{Timestamp: base.Add(-time.Hour), Type: "objective", Content: "Old goal"})
+
+	dir := filepath.Join(logs.GetDefaultLogDir(root), "20240501-100000")
+	os.MkdirAll(dir, 0755)
+	writeSessionLog(t, dir, "transcript.jsonl",
+		logs.Entry{Timestamp: base, Type: "objective", Content: "Fix tests"},
+		logs.Entry{Timestamp: base.Add(50 * time.Second), Type: "assistant", Content: "Running go test again"},
+		logs.Entry{Timestamp: base.Add(100 * time.Second), Type: "checkpoint", Content: "Tests pass"},
+	)
+	writeSessionLog(t, dir, "tools.jsonl",
+		logs.Entry{Timestamp: base.Add(10 * time.Second), Type: "tool:shell", Content: "FAIL", Metadata: map[string]interface{}{"args": "go test ./...", "exit_code": 1}},
+		logs.Entry{Timestamp: base.Add(60 * time.Second), Type: "tool:shell", Content: "ok", Metadata: map[string]interface{}{"args": "go test ./...", "exit_code": 0}},
+	)
+	writeSessionLog(t, dir, "thinking.jsonl",
+		logs.Entry{Timestamp: base.Add(5 * time.Second), Type: "thinking", Content: "The parser is broken", Metadata: map[string]interface{}{"cycle_id": 0}},
+	)
+
+	b := newSessionBrowser(root)
+	b.load()
+	return &b
+}
+
+func TestSessionBrowser_ListAndOpen(t *testing.T) {
+	b := newTestBrowser(t)
+
+	if len(b.summaries) != 2 || b.summaries[0].ID != "20240501-100000" {
+		t.Fatalf("expected newest session first, got %+v", b.summaries)
+	}
+	if view := b.view(160, 30); !strings.Contains(view, "Fix tests") || !strings.Contains(view, "1/2 ok") {
+		t.Errorf("expected list to show goal and tool results, got:\n%s", view)
+	}
+
+	b.open()
+	if !b.replaying || len(b.timeline) != 6 {
+		t.Fatalf("expected replay of 6 entries, got %d", len(b.timeline))
+	}
+	if b.timeline[1].Source != logs.SourceThinking || b.timeline[2].Source != logs.SourceTools {
+		t.Errorf("expected entries interleaved by time, got %s, %s", b.timeline[1].Source, b.timeline[2].Source)
+	}
+	if view := b.view(160, 40); !strings.Contains(view, "REPLAY 20240501-100000") || !strings.Contains(view, "1/6") {
+		t.Errorf("unexpected replay view:\n%s", view)
+	}
+}
+
+func TestSessionBrowser_SeekAndSearch(t *testing.T) {
+	b := newTestBrowser(t)
+	b.open()
+
+	b.seek(-5)
+	if b.cursor != 0 {
+		t.Errorf("expected seek to clamp at start, got %d", b.cursor)
+	}
+	b.seek(100)
+	if b.cursor != 5 {
+		t.Errorf("expected seek to clamp at end, got %d", b.cursor)
+	}
+
+	b.seekPercent(50) // 50s into a 100s session
+	if b.cursor != 3 {
+		t.Errorf("expected 50%% to land on entry 3, got %d", b.cursor)
+	}
+	b.seekPercent(0)
+
+	b.setQuery("go test")
+	if len(b.matches) != 3 || b.cursor != 2 {
+		t.Fatalf("expected 3 matches starting at 2, got %v at %d", b.matches, b.cursor)
+	}
+	b.nextMatch(1)
+	if b.cursor != 3 {
+		t.Errorf("expected next match 3, got %d", b.cursor)
+	}
+	b.nextMatch(1)
+	b.nextMatch(1)
+	if b.cursor != 2 {
+		t.Errorf("expected next match to wrap to 2, got %d", b.cursor)
+	}
+	b.nextMatch(-1)
+	if b.cursor != 4 {
+		t.Errorf("expected previous match to wrap to 4, got %d", b.cursor)
+	}
+}
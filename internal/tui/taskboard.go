@@ -0,0 +1,433 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	ctxmgr "github.com/ai/brewol/internal/context"
+)
+
+// boardColumns are the task board columns, left to right
+var boardColumns = []ctxmgr.TaskStatus{
+	ctxmgr.TaskStatusPending,
+	ctxmgr.TaskStatusInProgress,
+	ctxmgr.TaskStatusCompleted,
+	ctxmgr.TaskStatusFailed,
+	ctxmgr.TaskStatusSkipped,
+}
+
+// boardCategories is the category filter cycle; "" shows all categories
+var boardCategories = []ctxmgr.TaskCategory{
+	"",
+	ctxmgr.TaskCategoryBuild,
+	ctxmgr.TaskCategoryTest,
+	ctxmgr.TaskCategoryCoverage,
+	ctxmgr.TaskCategoryGoal,
+	ctxmgr.TaskCategoryTodo,
+	ctxmgr.TaskCategoryFixme,
+	ctxmgr.TaskCategoryRefactor,
+	ctxmgr.TaskCategoryDocs,
+	ctxmgr.TaskCategoryOther,
+}
+
+// boardEdit is the text field being edited on the task board
+type boardEdit int
+
+const (
+	boardEditNone boardEdit = iota
+	boardEditAdd
+	boardEditTitle
+	boardEditDescription
+)
+
+// taskBoard is the full-screen task board over the task store
+type taskBoard struct {
+	store    *ctxmgr.TaskStore
+	column   int
+	row      int
+	category ctxmgr.TaskCategory // "" = all categories
+	priority ctxmgr.TaskPriority // 0 = all priorities
+	edit     boardEdit
+	input    textinput.Model
+	status   string // Result of the last action
+}
+
+// newTaskBoard creates a task board over store
+func newTaskBoard(store *ctxmgr.TaskStore) taskBoard {
+	ti := textinput.New()
+	ti.CharLimit = 256
+	return taskBoard{store: store, input: ti}
+}
+
+// columnTasks returns the tasks of a column that pass the filters
+func (b *taskBoard) columnTasks(status ctxmgr.TaskStatus) []*ctxmgr.Task {
+	var tasks []*ctxmgr.Task
+	for _, t := range b.store.GetTasksByStatus(status) {
+		if b.category != "" && t.Category != b.category {
+			continue
+		}
+		if b.priority != 0 && t.Priority != b.priority {
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks
+}
+
+// selected returns the task under the cursor, if any
+func (b *taskBoard) selected() *ctxmgr.Task {
+	tasks := b.columnTasks(boardColumns[b.column])
+	if b.row < 0 || b.row >= len(tasks) {
+		return nil
+	}
+	return tasks[b.row]
+}
+
+// move shifts the cursor by columns and rows
+func (b *taskBoard) move(columns, rows int) {
+	b.column = (b.column + columns + len(boardColumns)) % len(boardColumns)
+	b.row += rows
+	b.clamp()
+}
+
+// clamp keeps the cursor within the current column
+func (b *taskBoard) clamp() {
+	n := len(b.columnTasks(boardColumns[b.column]))
+	if b.row >= n {
+		b.row = n - 1
+	}
+	if b.row < 0 {
+		b.row = 0
+	}
+}
+
+// follow moves the cursor to a task, wherever it is now
+func (b *taskBoard) follow(id string) {
+	for c, status := range boardColumns {
+		for r, t := range b.columnTasks(status) {
+			if t.ID == id {
+				b.column, b.row = c, r
+				return
+			}
+		}
+	}
+	b.clamp()
+}
+
+// cycleCategory advances the category filter
+func (b *taskBoard) cycleCategory() {
+	for i, c := range boardCategories {
+		if c == b.category {
+			b.category = boardCategories[(i+1)%len(boardCategories)]
+			break
+		}
+	}
+	b.clamp()
+}
+
+// cyclePriority advances the priority filter through all, P1..P4
+func (b *taskBoard) cyclePriority() {
+	b.priority = (b.priority + 1) % (ctxmgr.TaskPriorityLow + 1)
+	b.clamp()
+}
+
+// reprioritise moves the selected task up (delta -1) or down (delta 1) a
+// priority level
+func (b *taskBoard) reprioritise(delta int) error {
+	task := b.selected()
+	if task == nil {
+		return nil
+	}
+
+	priority := task.Priority + ctxmgr.TaskPriority(delta)
+	if priority < ctxmgr.TaskPriorityCritical || priority > ctxmgr.TaskPriorityLow {
+		return nil
+	}
+	if err := b.store.UpdateTask(task.ID, func(t *ctxmgr.Task) { t.Priority = priority }); err != nil {
+		return err
+	}
+
+	// Keep the task in view when a priority filter is active
+	if b.priority != 0 {
+		b.priority = priority
+	}
+	b.follow(task.ID)
+	b.status = fmt.Sprintf("%s is now P%d", truncate(task.Title, 40), priority)
+	return nil
+}
+
+// setStatus moves the selected task to another column
+func (b *taskBoard) setStatus(status ctxmgr.TaskStatus) error {
+	task := b.selected()
+	if task == nil || task.Status == status {
+		return nil
+	}
+
+	err := b.store.UpdateTask(task.ID, func(t *ctxmgr.Task) {
+		if status == ctxmgr.TaskStatusPending {
+			t.CompletedAt = nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if err := b.store.SetTaskStatus(task.ID, status); err != nil {
+		return err
+	}
+
+	b.clamp()
+	b.status = fmt.Sprintf("%s → %s", truncate(task.Title, 40), status)
+	return nil
+}
+
+// startEdit opens the input for adding a task or editing the selected one
+func (b *taskBoard) startEdit(kind boardEdit) tea.Cmd {
+	b.input.Reset()
+	switch kind {
+	case boardEditAdd:
+		b.input.Placeholder = "New task title"
+	case boardEditTitle, boardEditDescription:
+		task := b.selected()
+		if task == nil {
+			return nil
+		}
+		b.input.Placeholder = "Title"
+		value := task.Title
+		if kind == boardEditDescription {
+			b.input.Placeholder = "Description"
+			value = task.Description
+		}
+		b.input.SetValue(value)
+		b.input.CursorEnd()
+	}
+
+	b.edit = kind
+	b.input.Focus()
+	return textinput.Blink
+}
+
+// cancelEdit closes the input without saving
+func (b *taskBoard) cancelEdit() {
+	b.edit = boardEditNone
+	b.input.Blur()
+}
+
+// submitEdit saves the input: a new user task or the edited field
+func (b *taskBoard) submitEdit() error {
+	value := strings.TrimSpace(b.input.Value())
+	kind := b.edit
+	b.cancelEdit()
+
+	switch kind {
+	case boardEditAdd:
+		if value == "" {
+			return nil
+		}
+		category := b.category
+		if category == "" {
+			category = ctxmgr.TaskCategoryGoal
+		}
+		priority := b.priority
+		if priority == 0 {
+			priority = ctxmgr.TaskPriorityHigh
+		}
+		task := &ctxmgr.Task{Title: value, Category: category, Priority: priority, Source: "user"}
+		if err := b.store.AddTask(task); err != nil {
+			return err
+		}
+		b.follow(task.ID)
+		b.status = "Added " + truncate(value, 40)
+
+	case boardEditTitle:
+		task := b.selected()
+		if task == nil || value == "" {
+			return nil
+		}
+		if err := b.store.UpdateTask(task.ID, func(t *ctxmgr.Task) { t.Title = value }); err != nil {
+			return err
+		}
+		b.status = "Renamed to " + truncate(value, 40)
+
+	case boardEditDescription:
+		task := b.selected()
+		if task == nil {
+			return nil
+		}
+		if err := b.store.UpdateTask(task.ID, func(t *ctxmgr.Task) { t.Description = value }); err != nil {
+			return err
+		}
+		b.status = "Updated description of " + truncate(task.Title, 40)
+	}
+	return nil
+}
+
+// handleBoardKey handles keys while the task board is open
+func (m Model) handleBoardKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b := &m.board
+
+	if b.edit != boardEditNone {
+		switch msg.String() {
+		case "esc":
+			b.cancelEdit()
+		case "enter":
+			if err := b.submitEdit(); err != nil {
+				b.status = fmt.Sprintf("Error: %v", err)
+			}
+		default:
+			var cmd tea.Cmd
+			b.input, cmd = b.input.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	var err error
+	switch msg.String() {
+	case "esc", "q":
+		m.showBoard = false
+		m.inputFocused = true
+		m.promptInput.Focus()
+		return m, textinput.Blink
+	case "left", "h":
+		b.move(-1, 0)
+	case "right", "l", "tab":
+		b.move(1, 0)
+	case "up", "k":
+		b.move(0, -1)
+	case "down", "j":
+		b.move(0, 1)
+	case "c":
+		b.cycleCategory()
+	case "p":
+		b.cyclePriority()
+	case "+", "=":
+		err = b.reprioritise(-1)
+	case "-":
+		err = b.reprioritise(1)
+	case "s":
+		err = b.setStatus(ctxmgr.TaskStatusSkipped)
+	case "r":
+		err = b.setStatus(ctxmgr.TaskStatusPending)
+	case "a":
+		return m, b.startEdit(boardEditAdd)
+	case "e":
+		return m, b.startEdit(boardEditTitle)
+	case "E":
+		return m, b.startEdit(boardEditDescription)
+	case "enter":
+		if task := b.selected(); task != nil {
+			if err = m.engine.SelectTask(task.ID); err == nil {
+				b.follow(task.ID)
+				b.status = "Current objective: " + truncate(task.Title, 40)
+			}
+		}
+	}
+
+	if err != nil {
+		b.status = fmt.Sprintf("Error: %v", err)
+	}
+	return m, nil
+}
+
+// view renders the board in width x height cells
+func (b *taskBoard) view(width, height int) string {
+	if width < 60 {
+		width = 60
+	}
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	cursorStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("230")).Background(lipgloss.Color("62"))
+
+	category, priority := "all", "all"
+	if b.category != "" {
+		category = string(b.category)
+	}
+	if b.priority != 0 {
+		priority = fmt.Sprintf("P%d", b.priority)
+	}
+	header := titleStyle.Render("TASK BOARD") + dimStyle.Render(fmt.Sprintf("  category: %s | priority: %s", category, priority))
+
+	// Columns, each with a border; the detail pane and help take 8 lines
+	colWidth := width/len(boardColumns) - 2
+	if colWidth < 12 {
+		colWidth = 12
+	}
+	listHeight := height - 10
+	if listHeight < 3 {
+		listHeight = 3
+	}
+
+	var columns []string
+	for c, status := range boardColumns {
+		tasks := b.columnTasks(status)
+		borderColor := lipgloss.Color("240")
+		if c == b.column {
+			borderColor = lipgloss.Color("205")
+		}
+
+		lines := []string{titleStyle.Render(fmt.Sprintf("%s (%d)", strings.ToUpper(string(status)), len(tasks)))}
+
+		// Scroll so the cursor stays visible
+		start := 0
+		if c == b.column && b.row >= listHeight-1 {
+			start = b.row - listHeight + 2
+		}
+		for r := start; r < len(tasks) && len(lines) < listHeight; r++ {
+			t := tasks[r]
+			line := truncate(fmt.Sprintf("P%d %s", t.Priority, t.Title), colWidth)
+			if c == b.column && r == b.row {
+				line = cursorStyle.Render(line)
+			}
+			lines = append(lines, line)
+		}
+
+		style := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(borderColor).
+			Width(colWidth).
+			Height(listHeight)
+		columns = append(columns, style.Render(strings.Join(lines, "\n")))
+	}
+
+	// Detail pane for the selected task, or the edit input
+	var detail strings.Builder
+	if b.edit != boardEditNone {
+		detail.WriteString(b.input.View())
+	} else if task := b.selected(); task != nil {
+		detail.WriteString(fmt.Sprintf("[P%d/%s] %s\n", task.Priority, task.Category, task.Title))
+		if task.Description != "" {
+			detail.WriteString(truncate(task.Description, width-8) + "\n")
+		}
+		if len(task.Files) > 0 {
+			detail.WriteString("Files: " + truncate(strings.Join(task.Files, ", "), width-15) + "\n")
+		}
+		if task.NextAction != "" {
+			detail.WriteString("Next: " + truncate(task.NextAction, width-14) + "\n")
+		}
+		detail.WriteString(dimStyle.Render(fmt.Sprintf("Attempts: %d | Source: %s", task.Attempts, task.Source)))
+	} else {
+		detail.WriteString(dimStyle.Render("No tasks in this column"))
+	}
+	detailStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Width(width - 4).
+		Height(4)
+
+	keys := "←/→ column | ↑/↓ task | enter make current | +/- priority | s skip | r reopen | a add | e title | E description | c category | p priority | esc close"
+	if b.status != "" {
+		keys = b.status + "  •  " + keys
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		header,
+		lipgloss.JoinHorizontal(lipgloss.Top, columns...),
+		detailStyle.Render(detail.String()),
+		dimStyle.Render(truncate(keys, width)),
+	)
+}
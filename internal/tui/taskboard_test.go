@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	ctxmgr "github.com/ai/brewol/internal/context"
+)
+
+func newTestBoard(t *testing.T) (*taskBoard, *ctxmgr.TaskStore) {
+	t.Helper()
+	store, err := ctxmgr.NewTaskStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.AddTask(&ctxmgr.Task{ID: "build", Title: "Fix build", Priority: ctxmgr.TaskPriorityCritical, Category: ctxmgr.TaskCategoryBuild})
+	store.AddTask(&ctxmgr.Task{ID: "todo", Title: "Resolve TODO", Priority: ctxmgr.TaskPriorityMedium, Category: ctxmgr.TaskCategoryTodo})
+	store.AddTask(&ctxmgr.Task{ID: "docs", Title: "Update docs", Priority: ctxmgr.TaskPriorityLow, Category: ctxmgr.TaskCategoryDocs})
+
+	b := newTaskBoard(store)
+	return &b, store
+}
+
+func TestTaskBoard_Filters(t *testing.T) {
+	b, _ := newTestBoard(t)
+
+	if n := len(b.columnTasks(ctxmgr.TaskStatusPending)); n != 3 {
+		t.Fatalf("expected 3 pending tasks, got %d", n)
+	}
+
+	b.cycleCategory() // build
+	if tasks := b.columnTasks(ctxmgr.TaskStatusPending); len(tasks) != 1 || tasks[0].ID != "build" {
+		t.Errorf("expected only the build task, got %v", tasks)
+	}
+
+	b.category = ""
+	b.cyclePriority() // P1
+	b.cyclePriority() // P2
+	b.cyclePriority() // P3
+	if tasks := b.columnTasks(ctxmgr.TaskStatusPending); len(tasks) != 1 || tasks[0].ID != "todo" {
+		t.Errorf("expected only the P3 task, got %v", tasks)
+	}
+
+	b.cyclePriority() // P4
+	b.cyclePriority() // all
+	if b.priority != 0 {
+		t.Errorf("expected priority filter to wrap to all, got %d", b.priority)
+	}
+}
+
+func TestTaskBoard_Reprioritise(t *testing.T) {
+	b, store := newTestBoard(t)
+
+	b.move(0, 2) // Update docs (P4)
+	if err := b.reprioritise(-1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := b.reprioritise(-1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	task, _ := store.GetTask("docs")
+	if task.Priority != ctxmgr.TaskPriorityHigh {
+		t.Errorf("expected P2, got P%d", task.Priority)
+	}
+	if sel := b.selected(); sel == nil || sel.ID != "docs" || b.row != 1 {
+		t.Errorf("expected cursor to follow the task to row 1, got row %d", b.row)
+	}
+
+	// Priority is bounded at P1
+	b.reprioritise(-1)
+	b.reprioritise(-1)
+	if task, _ := store.GetTask("docs"); task.Priority != ctxmgr.TaskPriorityCritical {
+		t.Errorf("expected P1, got P%d", task.Priority)
+	}
+}
+
+func TestTaskBoard_SkipAndReopen(t *testing.T) {
+	b, store := newTestBoard(t)
+
+	if err := b.setStatus(ctxmgr.TaskStatusSkipped); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task, _ := store.GetTask("build"); task.Status != ctxmgr.TaskStatusSkipped || task.CompletedAt == nil {
+		t.Errorf("expected build to be skipped, got %s", task.Status)
+	}
+
+	b.move(-1, 0) // Skipped column
+	if sel := b.selected(); sel == nil || sel.ID != "build" {
+		t.Fatalf("expected skipped task under cursor, got %v", sel)
+	}
+	if err := b.setStatus(ctxmgr.TaskStatusPending); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task, _ := store.GetTask("build"); task.Status != ctxmgr.TaskStatusPending || task.CompletedAt != nil {
+		t.Errorf("expected build to be reopened, got %s", task.Status)
+	}
+}
+
+func TestTaskBoard_AddAndEdit(t *testing.T) {
+	b, store := newTestBoard(t)
+
+	b.category = ctxmgr.TaskCategoryTest
+	b.startEdit(boardEditAdd)
+	b.input.SetValue("  Cover parser  ")
+	if err := b.submitEdit(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sel := b.selected()
+	if sel == nil || sel.Title != "Cover parser" || sel.Category != ctxmgr.TaskCategoryTest || sel.Priority != ctxmgr.TaskPriorityHigh || sel.Source != "user" {
+		t.Fatalf("unexpected added task: %+v", sel)
+	}
+
+	b.startEdit(boardEditTitle)
+	if b.input.Value() != "Cover parser" {
+		t.Errorf("expected title to prefill the input, got %q", b.input.Value())
+	}
+	b.input.SetValue("Cover the parser")
+	b.submitEdit()
+
+	b.startEdit(boardEditDescription)
+	b.input.SetValue("Table tests for edge cases")
+	b.submitEdit()
+
+	task, _ := store.GetTask(sel.ID)
+	if task.Title != "Cover the parser" || task.Description != "Table tests for edge cases" {
+		t.Errorf("unexpected edited task: %+v", task)
+	}
+	if b.edit != boardEditNone {
+		t.Error("expected edit to be closed after submit")
+	}
+}
+
+func TestTaskBoard_View(t *testing.T) {
+	b, _ := newTestBoard(t)
+
+	view := b.view(150, 30)
+	for _, want := range []string{"TASK BOARD", "PENDING (3)", "SKIPPED (0)", "Fix build", "[P1/build]"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected view to contain %q", want)
+		}
+	}
+}